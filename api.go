@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Limits on the requests of the API, which the game loop works through
+// between frames
+const (
+	// apiMaxBody is the largest request body read, as for gRPC
+	apiMaxBody = grpcMaxMessage
+	// apiMaxCells is the most cells one POST /cells changes
+	apiMaxCells = 1 << 18
+)
+
+// apiServer serves the world over HTTP. Handlers run on the server's own
+// goroutines, so they hand their work to the game loop, which runs it
+// between frames and never at the same time as a generation step.
+type apiServer struct {
+	work   chan func(g *Game)
+	stream gridStream
+}
+
+// gridState is the JSON form of the world returned by GET /grid
+type gridState struct {
+	Generation int    `json:"generation"`
+	Population int    `json:"population"`
+	Rule       string `json:"rule"`
+	Births     int    `json:"births"`
+	Deaths     int    `json:"deaths"`
+	Heat       int    `json:"heat"`
+	// Bounds is the bounding box of the live cells as min x, min y, max x
+	// and max y
+	Bounds  [4]int   `json:"bounds"`
+	Running bool     `json:"running"`
+	Speed   string   `json:"speed"`
+	Cells   [][2]int `json:"cells"`
+}
+
+// cellsRequest is the body of POST /cells
+type cellsRequest struct {
+	Alive bool     `json:"alive"`
+	Cells [][2]int `json:"cells"`
+}
+
+// speedRequest is the body of POST /speed
+type speedRequest struct {
+	Speed string `json:"speed"`
+}
+
+// startAPIServer serves the API on addr in the background, once it is
+// listening
+func startAPIServer(addr string) (*apiServer, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP API: %w", err)
+	}
+	s := &apiServer{work: make(chan func(g *Game))}
+	go func() {
+		log.Printf("serving the HTTP API on %s", l.Addr())
+		if err := http.Serve(l, s.handler()); err != nil {
+			log.Printf("HTTP API: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// runPending runs the work handlers have sent since the last frame, then
+// sends any changes to the cells to the stream clients
+func (s *apiServer) runPending(g *Game) {
+	if s == nil {
+		return
+	}
+	for {
+		select {
+		case f := <-s.work:
+			f(g)
+		default:
+			s.stream.update(g.world)
+			return
+		}
+	}
+}
+
+// do runs f on the game loop and waits for it to finish
+func (s *apiServer) do(f func(g *Game)) {
+	done := make(chan struct{})
+	s.work <- func(g *Game) {
+		f(g)
+		close(done)
+	}
+	<-done
+}
+
+// handler returns the routes of the API
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /grid", s.getGrid)
+	mux.HandleFunc("GET /grid.rle", s.getGridRLE)
+	mux.HandleFunc("POST /start", s.setRunning(true))
+	mux.HandleFunc("POST /stop", s.setRunning(false))
+	mux.HandleFunc("POST /cells", s.setCells)
+	mux.HandleFunc("POST /speed", s.setSpeed)
+	mux.HandleFunc("GET /stream", s.streamGrid)
+	return mux
+}
+
+// getGrid returns the generation and its live cells as JSON
+func (s *apiServer) getGrid(rw http.ResponseWriter, r *http.Request) {
+	var state gridState
+	s.do(func(g *Game) {
+		w := g.world
+		state = gridState{
+			Generation: w.grid.Generation,
+			Population: w.grid.Population(),
+			Births:     w.stats.Births,
+			Deaths:     w.stats.Deaths,
+			Heat:       w.stats.Heat(),
+			Bounds:     [4]int{w.stats.MinX, w.stats.MinY, w.stats.MaxX, w.stats.MaxY},
+			Rule:       w.grid.RuleName(),
+			Running:    w.isSimulating,
+			Speed:      w.speed.String(),
+			Cells:      cellPairs(sortedCells(w.grid.Cells())),
+		}
+	})
+	writeJSON(rw, state)
+}
+
+// getGridRLE returns the live cells as an RLE pattern
+func (s *apiServer) getGridRLE(rw http.ResponseWriter, r *http.Request) {
+	// Encode on the game loop but send from here, so a slow client doesn't
+	// hold up the game
+	var b bytes.Buffer
+	s.do(func(g *Game) {
+		w := g.world
+		writeRLE(&b, fmt.Sprintf("Generation %d", w.grid.Generation), w.grid.RuleName(), w.grid.Cells(), w.cellStates())
+	})
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Write(b.Bytes())
+}
+
+// setRunning returns a handler that starts or stops the simulation
+func (s *apiServer) setRunning(running bool) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		s.do(func(g *Game) { g.world.setRunning(running) })
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// setCells makes the listed cells alive or dead as one edit that can be
+// undone in the window
+func (s *apiServer) setCells(rw http.ResponseWriter, r *http.Request) {
+	var req cellsRequest
+	if !readJSON(rw, r, &req) {
+		return
+	}
+	if len(req.Cells) > apiMaxCells {
+		http.Error(rw, fmt.Sprintf("%d cells is over the limit of %d", len(req.Cells), apiMaxCells), http.StatusRequestEntityTooLarge)
+		return
+	}
+	s.do(func(g *Game) {
+		w := g.world
+		w.beginEdit()
+		for _, c := range req.Cells {
+			w.setCell(tile{X: c[0], Y: c[1]}, req.Alive)
+		}
+		w.commitEdit()
+	})
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// setSpeed changes the time between generations
+func (s *apiServer) setSpeed(rw http.ResponseWriter, r *http.Request) {
+	var req speedRequest
+	if !readJSON(rw, r, &req) {
+		return
+	}
+	d, err := time.ParseDuration(req.Speed)
+	if err != nil || d <= 0 {
+		http.Error(rw, "speed must be a positive duration such as 100ms", http.StatusBadRequest)
+		return
+	}
+	s.do(func(g *Game) { g.world.speed = d })
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// readJSON decodes the request body into v, up to apiMaxBody bytes. It
+// reports whether it could, sending the error response if not.
+func readJSON(rw http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, apiMaxBody)).Decode(v)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		http.Error(rw, fmt.Sprintf("request body is over the limit of %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return false
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSON sends v as the JSON response
+func writeJSON(rw http.ResponseWriter, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Printf("HTTP API: %v", err)
+	}
+}
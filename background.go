@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// backgroundAlpha dims the background image so cells stay easy to see
+const backgroundAlpha = 0.35
+
+// loadBackground reads an image to show behind the grid
+func loadBackground(path string) (*ebiten.Image, error) {
+	img, err := decodeImage(path)
+	if err != nil {
+		return nil, err
+	}
+	return ebiten.NewImageFromImage(img), nil
+}
+
+// drawBackground draws the background image dimmed behind the grid. It is
+// scaled to fit the grid, keeping its shape, and moves with the camera so
+// cells can be traced over it.
+func (w *World) drawBackground(screen *ebiten.Image) {
+	if w.background == nil {
+		return
+	}
+	bounds := w.background.Bounds()
+	gridW := float64(w.grid.Width * w.tileSize)
+	gridH := float64(w.grid.Height * w.tileSize)
+	scale := min(gridW/float64(bounds.Dx()), gridH/float64(bounds.Dy()))
+
+	// Centre the image on the grid
+	left, top := w.cellToScreen(0, 0)
+	x := float64(left) + (gridW-scale*float64(bounds.Dx()))/2
+	y := float64(top) + (gridH-scale*float64(bounds.Dy()))/2
+
+	var op ebiten.DrawImageOptions
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(x, y)
+	op.ColorScale.ScaleAlpha(backgroundAlpha)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(w.background, &op)
+}
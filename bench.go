@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// benchSoupSize is the side of the square random soup benchmarked
+const benchSoupSize = 128
+
+// benchWorkload is a starting grid that is run for a fixed number of
+// generations, so results can be compared between runs
+type benchWorkload struct {
+	name        string
+	generations int
+	seed        func(g *life.Grid) error
+}
+
+// benchWorkloads are the standard workloads
+var benchWorkloads = []benchWorkload{
+	{"random soup", 500, func(g *life.Grid) error {
+		random := rand.New(rand.NewSource(1))
+		for y := range benchSoupSize {
+			for x := range benchSoupSize {
+				if random.Intn(100) < defaultDensity {
+					g.Set(tile{X: x, Y: y}, true)
+				}
+			}
+		}
+		return nil
+	}},
+	{"glider gun", 2000, benchPattern("gosper-glider-gun")},
+	{"puffer train", 1000, benchPattern("puffer-train")},
+	{"spacefiller", 300, benchPattern("max")},
+}
+
+// benchPattern returns a workload seed that places a built-in pattern
+func benchPattern(name string) func(g *life.Grid) error {
+	return func(g *life.Grid) error {
+		p, err := loadBuiltinPattern(name)
+		if err != nil {
+			return err
+		}
+		for _, cell := range p.cells {
+			g.Set(cell, true)
+		}
+		return nil
+	}
+}
+
+// benchEngine is a way of stepping the grid
+type benchEngine struct {
+	name  string
+	setup func(g *life.Grid) error
+}
+
+// benchEngines are the engines compared, Conway's rule in B/S form and the
+// same rule through the custom rule interface
+var benchEngines = []benchEngine{
+	{"life", func(g *life.Grid) error { return g.SetRule(life.Conway) }},
+	{"custom", func(g *life.Grid) error {
+		r, _ := life.ParseRule(life.Conway)
+		return g.SetCustomRule("custom "+life.Conway, life.AsCustom(r))
+	}},
+}
+
+// runBench runs every workload on every engine and writes the generations
+// per second and allocations per generation as a table
+func runBench(out io.Writer) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "workload\tengine\tgenerations\tpopulation\tgen/s\tallocs/gen\tbytes/gen\t")
+	for _, wl := range benchWorkloads {
+		for _, e := range benchEngines {
+			g := life.New(gridWidth, gridHeight, life.Rule{})
+			if err := e.setup(g); err != nil {
+				return err
+			}
+			if err := wl.seed(g); err != nil {
+				return fmt.Errorf("%s: %w", wl.name, err)
+			}
+
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			start := time.Now()
+			for range wl.generations {
+				g.Step()
+			}
+			elapsed := time.Since(start)
+			runtime.ReadMemStats(&after)
+
+			n := uint64(wl.generations)
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f\t%d\t%d\t\n", wl.name, e.name, wl.generations, g.Population(),
+				float64(wl.generations)/elapsed.Seconds(), (after.Mallocs-before.Mallocs)/n, (after.TotalAlloc-before.TotalAlloc)/n)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// ctrlPressed reports whether a control key (or command on macOS) is held
+func ctrlPressed() bool {
+	return ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)
+}
+
+// keyCombo is a key together with the modifier keys that must be held
+type keyCombo struct {
+	key   ebiten.Key
+	ctrl  bool
+	shift bool
+	alt   bool
+}
+
+// key returns a combo for a key pressed without modifiers
+func key(k ebiten.Key) keyCombo {
+	return keyCombo{key: k}
+}
+
+// ctrl returns a combo for a key pressed with control
+func ctrl(k ebiten.Key) keyCombo {
+	return keyCombo{key: k, ctrl: true}
+}
+
+// shift returns a combo for a key pressed with shift
+func shift(k ebiten.Key) keyCombo {
+	return keyCombo{key: k, shift: true}
+}
+
+// modifiersHeld reports whether exactly the combo's modifiers are held, so
+// that for example C doesn't fire as well as Ctrl+C
+func (k keyCombo) modifiersHeld() bool {
+	return ctrlPressed() == k.ctrl &&
+		ebiten.IsKeyPressed(ebiten.KeyShift) == k.shift &&
+		ebiten.IsKeyPressed(ebiten.KeyAlt) == k.alt
+}
+
+// String returns the combo as shown in the help, e.g. "Ctrl+Shift+Z"
+func (k keyCombo) String() string {
+	var b strings.Builder
+	if k.ctrl {
+		b.WriteString("Ctrl+")
+	}
+	if k.alt {
+		b.WriteString("Alt+")
+	}
+	if k.shift {
+		b.WriteString("Shift+")
+	}
+	// Digit1 reads better as 1
+	b.WriteString(strings.TrimPrefix(k.key.String(), "Digit"))
+	return b.String()
+}
+
+// parseKeyCombo parses a combo such as "Ctrl+Shift+Z" or "F1". Key names
+// are the ones ebiten uses, matched without regard to case.
+func parseKeyCombo(s string) (keyCombo, error) {
+	var k keyCombo
+	parts := strings.Split(s, "+")
+	for _, mod := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(mod)) {
+		case "ctrl", "control", "cmd", "meta":
+			k.ctrl = true
+		case "shift":
+			k.shift = true
+		case "alt", "option":
+			k.alt = true
+		default:
+			return k, fmt.Errorf("unknown modifier %q in key %q", mod, s)
+		}
+	}
+	if err := k.key.UnmarshalText([]byte(strings.TrimSpace(parts[len(parts)-1]))); err != nil {
+		return k, fmt.Errorf("unknown key %q", s)
+	}
+	return k, nil
+}
+
+// binding connects an action to the keys that trigger it
+type binding struct {
+	action string
+	help   string
+	keys   []keyCombo
+	// held bindings run every frame while the key is down rather than once
+	// per press, repeat bindings run again at the key repeat rate and
+	// release bindings run when the key is let go
+	held    bool
+	repeat  bool
+	release bool
+	// modes, if set, are the modes the binding applies in, otherwise it
+	// applies in all of them but the menus. when, if set, limits it further
+	// to some situations within them.
+	modes modes
+	when  func(g *Game) bool
+	run   func(g *Game)
+}
+
+// appliesIn reports whether the binding can run in mode m and the
+// situation it needs holds
+func (b binding) appliesIn(g *Game, m mode) bool {
+	if b.modes != 0 && !b.modes.has(m) {
+		return false
+	}
+	return b.when == nil || b.when(g)
+}
+
+// triggered reports whether one of the binding's keys was pressed in mode m
+func (b binding) triggered(g *Game, m mode) bool {
+	if b.release {
+		g.notePresses(b.keys)
+	}
+	if !b.appliesIn(g, m) {
+		return false
+	}
+	for _, k := range b.keys {
+		pressed := inpututil.IsKeyJustPressed(k.key)
+		switch {
+		case b.held:
+			pressed = ebiten.IsKeyPressed(k.key)
+		case b.repeat:
+			pressed = keyRepeated(k.key)
+		case b.release:
+			pressed = inpututil.IsKeyJustReleased(k.key) && g.cleanPresses[k]
+		}
+		if pressed && k.modifiersHeld() {
+			return true
+		}
+	}
+	return false
+}
+
+// notePresses remembers whether each of the keys was pressed with exactly
+// its modifiers, so letting go of S after Ctrl+S doesn't also start the
+// simulation
+func (g *Game) notePresses(keys []keyCombo) {
+	for _, k := range keys {
+		if !inpututil.IsKeyJustPressed(k.key) {
+			continue
+		}
+		if g.cleanPresses == nil {
+			g.cleanPresses = make(map[keyCombo]bool)
+		}
+		g.cleanPresses[k] = k.modifiersHeld()
+	}
+}
+
+// Key repeat timings in ticks, after the delay a held key repeats every
+// interval
+const (
+	repeatDelay    = 24
+	repeatInterval = 4
+)
+
+// keyRepeated reports whether a key was just pressed or has been held long
+// enough to repeat this tick
+func keyRepeated(k ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(k)
+	return d == 1 || d >= repeatDelay && (d-repeatDelay)%repeatInterval == 0
+}
+
+// Conditions used by the bindings
+func hasSelection(g *Game) bool { return g.world.selecting && g.world.selection.active }
+func canTransform(g *Game) bool { return g.world.canTransform() }
+func paused(g *Game) bool       { return !g.world.isSimulating }
+
+// Modes used by the bindings. The simulation carries on under the stamp,
+// the selection and the keyboard cursor, so running and paused alone don't
+// say whether it is running.
+var (
+	// paintModes are the modes where the mouse paints cells
+	paintModes = modesOf(modeEditing, modeRunning, modePaused)
+	// transformModes have a stamp or selection to turn and mirror
+	transformModes = modesOf(modeStamping, modeSelecting)
+	// cursorModes can show the keyboard cursor, which is always shown in
+	// editing
+	cursorModes = modesOf(modeEditing, modeStamping, modeSelecting)
+	// panModes pan with the arrow keys when the cursor is hidden
+	panModes = modesOf(modeStamping, modeSelecting, modeRunning, modePaused)
+)
+
+// stampPreset returns an action that enters stamp mode with a built-in
+// pattern. Pressing it again while stamping the pattern turns it clockwise,
+// so spaceships can be aimed before they are placed.
+func stampPreset(name string) func(g *Game) {
+	return func(g *Game) {
+		p := mustLoadBuiltinPattern(name)
+		if g.world.stamp != nil && g.world.stamp.name == p.name {
+			g.world.stamp = g.world.stamp.rotate()
+			return
+		}
+		g.world.stamp = p
+	}
+}
+
+// placePreset returns an action that starts again from a built-in pattern
+// in the middle of the view
+func placePreset(name string) func(g *Game) {
+	return func(g *Game) {
+		g.world.isSimulating = false
+		g.world.leaveGameModes()
+		g.world.placeInView(mustLoadBuiltinPattern(name))
+	}
+}
+
+// bindings is the table of keyboard controls, the help overlay is generated
+// from it
+var bindings = []binding{
+	// Quitting is chosen from the title screen, so a stray Q doesn't lose
+	// the world
+	{action: "quit", help: "Back to the title screen", keys: []keyCombo{key(ebiten.KeyEscape), key(ebiten.KeyQ)},
+		run: func(g *Game) { g.leaveGame() }},
+	{action: "help", help: "Show or hide this help", keys: []keyCombo{key(ebiten.KeyH), key(ebiten.KeyF1)},
+		run: func(g *Game) { g.help = !g.help }},
+	{action: "settings", help: "Settings menu", keys: []keyCombo{key(ebiten.KeyTab)},
+		run: func(g *Game) { g.menu.open = true }},
+	{action: "patterns", help: "Pattern picker", keys: []keyCombo{key(ebiten.KeyO)},
+		run: func(g *Game) { g.picker.show(g.patternDir) }},
+	{action: "catalog", help: "Pattern catalog with previews", keys: []keyCombo{shift(ebiten.KeyO)},
+		run: func(g *Game) { g.catalog.show(g.patternDir) }},
+	{action: "recent", help: "Recently placed patterns", keys: []keyCombo{ctrl(ebiten.KeyO)},
+		run: func(g *Game) { g.recent = recentMenu{open: true} }},
+	{action: "command", help: "Type a command", keys: []keyCombo{shift(ebiten.KeySemicolon)},
+		run: func(g *Game) { g.openCommand("") }},
+
+	// Simulation
+	// Start runs on release so space can be held to pan without starting
+	{action: "start", help: "Start the simulation", keys: []keyCombo{key(ebiten.KeySpace), key(ebiten.KeyS)}, release: true,
+		modes: panModes, when: func(g *Game) bool { return cursorHidden(g) && !g.world.camera.spacePanned },
+		run: func(g *Game) { g.world.setRunning(true) }},
+	{action: "pause", help: "Pause the simulation", keys: []keyCombo{key(ebiten.KeyP)},
+		run: func(g *Game) { g.world.setRunning(false) }},
+	{action: "step", help: "Step one generation while paused", keys: []keyCombo{key(ebiten.KeyN), key(ebiten.KeyPeriod)},
+		modes: modesOf(modeEditing, modeStamping, modeSelecting, modePaused), when: paused, run: func(g *Game) {
+			// The vim profile can step a count of generations at once
+			for range g.takeCount() {
+				g.world.SimulateWorld()
+			}
+		}},
+	{action: "rewind", help: "Rewind to the previous checkpoint", keys: []keyCombo{key(ebiten.KeyB)},
+		run: func(g *Game) {
+			g.world.isSimulating = false
+			g.world.rewindToCheckpoint()
+		}},
+	{action: "random", help: "Random soup", keys: []keyCombo{key(ebiten.KeyG)},
+		run: func(g *Game) {
+			g.world.leaveGameModes()
+			g.world.generateRandomCells()
+		}},
+	{action: "reset", help: "Clear the grid", keys: []keyCombo{key(ebiten.KeyR)},
+		modes: paintModes | modesOf(modeSelecting), when: func(g *Game) bool { return !g.world.canTransform() },
+		run: func(g *Game) {
+			g.world.beginEdit()
+			g.world.setCells(make(map[tile]struct{}))
+			g.world.commitEdit()
+			g.world.isSimulating = false
+		}},
+	{action: "gun", help: "Stamp a Gosper glider gun", keys: []keyCombo{key(ebiten.Key1)},
+		run: stampPreset("gosper-glider-gun")},
+	{action: "pulsar", help: "Stamp a pulsar", keys: []keyCombo{key(ebiten.Key2)},
+		run: stampPreset("pulsar")},
+	{action: "pentadecathlon", help: "Stamp a pentadecathlon", keys: []keyCombo{key(ebiten.Key3)},
+		run: stampPreset("pentadecathlon")},
+	{action: "beacon", help: "Stamp a beacon", keys: []keyCombo{key(ebiten.Key4)},
+		run: stampPreset("beacon")},
+	{action: "toad", help: "Stamp a toad", keys: []keyCombo{key(ebiten.Key5)},
+		run: stampPreset("toad")},
+	{action: "blinker", help: "Stamp a blinker", keys: []keyCombo{key(ebiten.Key6)},
+		run: stampPreset("blinker")},
+	{action: "glider", help: "Stamp a glider, again to turn it", keys: []keyCombo{key(ebiten.Key7)},
+		run: stampPreset("glider")},
+	{action: "lwss", help: "Stamp a lightweight spaceship, again to turn it", keys: []keyCombo{key(ebiten.Key8)},
+		run: stampPreset("lwss")},
+	{action: "mwss", help: "Stamp a middleweight spaceship, again to turn it", keys: []keyCombo{key(ebiten.Key9)},
+		run: stampPreset("mwss")},
+	{action: "hwss", help: "Stamp a heavyweight spaceship, again to turn it", keys: []keyCombo{key(ebiten.Key0)},
+		run: stampPreset("hwss")},
+	{action: "r-pentomino", help: "Start again from an R-pentomino", keys: []keyCombo{shift(ebiten.Key1)},
+		run: placePreset("r-pentomino")},
+	{action: "acorn", help: "Start again from an Acorn", keys: []keyCombo{shift(ebiten.Key2)},
+		run: placePreset("acorn")},
+	{action: "diehard", help: "Start again from a Diehard", keys: []keyCombo{shift(ebiten.Key3)},
+		run: placePreset("diehard")},
+	{action: "puffer-train", help: "Start again from a puffer train and follow it", keys: []keyCombo{shift(ebiten.Key4)},
+		run: func(g *Game) {
+			placePreset("puffer-train")(g)
+			g.world.camera.follow = true
+		}},
+	{action: "max", help: "Start again from Max, a spacefiller that grows quadratically", keys: []keyCombo{shift(ebiten.Key5)},
+		run: placePreset("max")},
+	{action: "methuselahs", help: "Methuselah menu", keys: []keyCombo{shift(ebiten.KeyM)},
+		run: func(g *Game) { g.methuselahs.open = true }},
+	{action: "puzzles", help: "Puzzle menu", keys: []keyCombo{shift(ebiten.KeyP)},
+		run: func(g *Game) { g.puzzles.open = true }},
+	{action: "export-svg", help: "Export the grid as SVG", keys: []keyCombo{key(ebiten.KeyE)},
+		run: func(g *Game) {
+			name, err := g.world.exportSVG()
+			if err != nil {
+				log.Printf("export failed: %v", err)
+			} else {
+				log.Printf("exported %s", name)
+			}
+		}},
+
+	// Editing
+	{action: "select", help: "Selection mode", keys: []keyCombo{key(ebiten.KeyM)},
+		run: func(g *Game) { g.world.toggleSelecting() }},
+	{action: "symmetry", help: "Cycle drawing symmetry", keys: []keyCombo{key(ebiten.KeyK)},
+		run: func(g *Game) { g.world.symmetry = g.world.symmetry.next() }},
+	{action: "undo", help: "Undo", keys: []keyCombo{ctrl(ebiten.KeyZ)},
+		run: func(g *Game) { g.world.undo() }},
+	{action: "redo", help: "Redo", keys: []keyCombo{ctrl(ebiten.KeyY), {key: ebiten.KeyZ, ctrl: true, shift: true}},
+		run: func(g *Game) { g.world.redo() }},
+	{action: "copy", help: "Copy the selection", keys: []keyCombo{ctrl(ebiten.KeyC)},
+		modes: modesOf(modeSelecting), when: hasSelection, run: func(g *Game) { g.world.clipboard = g.world.copySelection() }},
+	{action: "cut", help: "Cut the selection", keys: []keyCombo{ctrl(ebiten.KeyX)},
+		modes: modesOf(modeSelecting), when: hasSelection, run: func(g *Game) { g.world.cutSelection() }},
+	{action: "paste", help: "Paste as a stamp", keys: []keyCombo{ctrl(ebiten.KeyV)},
+		when: func(g *Game) bool { return g.world.clipboard != nil },
+		run:  func(g *Game) { g.world.stamp = g.world.clipboard }},
+	{action: "save-selection", help: "Save the selection as a user pattern", keys: []keyCombo{ctrl(ebiten.KeyS)},
+		modes: modesOf(modeSelecting), when: hasSelection, run: func(g *Game) { g.openCommand("save ") }},
+	{action: "record-macro", help: "Record a macro or stop recording", keys: []keyCombo{ctrl(ebiten.KeyR)},
+		run: func(g *Game) {
+			if g.world.macros.recording != nil {
+				g.finishMacro()
+			} else {
+				g.openCommand("record ")
+			}
+		}},
+	{action: "play-macro", help: "Play the last macro from the pointed cell", keys: []keyCombo{ctrl(ebiten.KeyP)},
+		when: func(g *Game) bool { return g.world.macros.last != "" },
+		run:  func(g *Game) { g.world.playMacro(g.world.macros.last) }},
+	{action: "clear-selection", help: "Clear the selection", keys: []keyCombo{key(ebiten.KeyDelete), key(ebiten.KeyBackspace)},
+		modes: modesOf(modeSelecting), when: hasSelection, run: func(g *Game) { g.world.editSelection(false) }},
+	{action: "fill-selection", help: "Fill the selection", keys: []keyCombo{key(ebiten.KeyInsert)},
+		modes: modesOf(modeSelecting), when: hasSelection, run: func(g *Game) { g.world.editSelection(true) }},
+	{action: "rotate", help: "Rotate stamp or selection clockwise", keys: []keyCombo{key(ebiten.KeyR)},
+		modes: transformModes, when: canTransform, run: func(g *Game) { g.world.applyTransform((*pattern).rotate) }},
+	{action: "rotate-back", help: "Rotate stamp or selection anticlockwise", keys: []keyCombo{shift(ebiten.KeyR)},
+		modes: transformModes, when: canTransform, run: func(g *Game) { g.world.applyTransform((*pattern).rotateCounterClockwise) }},
+	{action: "flip-horizontal", help: "Mirror stamp or selection left to right", keys: []keyCombo{key(ebiten.KeyX)},
+		modes: transformModes, when: canTransform, run: func(g *Game) { g.world.applyTransform((*pattern).flipHorizontal) }},
+	{action: "flip-vertical", help: "Mirror stamp or selection top to bottom", keys: []keyCombo{key(ebiten.KeyY)},
+		modes: transformModes, when: canTransform, run: func(g *Game) { g.world.applyTransform((*pattern).flipVertical) }},
+
+	// Keyboard cursor
+	{action: "cursor", help: "Show or hide the keyboard cursor", keys: []keyCombo{key(ebiten.KeyI)},
+		run: func(g *Game) { g.world.toggleCursor() }},
+	{action: "cursor-left", help: "Move the cursor left", keys: []keyCombo{key(ebiten.KeyArrowLeft), key(ebiten.KeyA)}, repeat: true,
+		modes: cursorModes, when: cursorActive, run: func(g *Game) { g.world.moveCursor(-g.takeCount(), 0) }},
+	{action: "cursor-right", help: "Move the cursor right", keys: []keyCombo{key(ebiten.KeyArrowRight), key(ebiten.KeyD)}, repeat: true,
+		modes: cursorModes, when: cursorActive, run: func(g *Game) { g.world.moveCursor(g.takeCount(), 0) }},
+	{action: "cursor-up", help: "Move the cursor up", keys: []keyCombo{key(ebiten.KeyArrowUp), key(ebiten.KeyW)}, repeat: true,
+		modes: cursorModes, when: cursorActive, run: func(g *Game) { g.world.moveCursor(0, -g.takeCount()) }},
+	{action: "cursor-down", help: "Move the cursor down", keys: []keyCombo{key(ebiten.KeyArrowDown), key(ebiten.KeyS)}, repeat: true,
+		modes: cursorModes, when: cursorActive, run: func(g *Game) { g.world.moveCursor(0, g.takeCount()) }},
+	{action: "cursor-toggle", help: "Toggle the cell or place the stamp at the cursor", keys: []keyCombo{key(ebiten.KeyEnter), key(ebiten.KeySpace)},
+		modes: cursorModes, when: cursorActive, run: func(g *Game) { g.world.cursorAction() }},
+
+	// View
+	{action: "pan-left", help: "Pan left", keys: []keyCombo{key(ebiten.KeyArrowLeft)}, held: true,
+		modes: panModes, when: cursorHidden, run: func(g *Game) { g.world.pan(-panSpeed, 0) }},
+	{action: "pan-right", help: "Pan right", keys: []keyCombo{key(ebiten.KeyArrowRight)}, held: true,
+		modes: panModes, when: cursorHidden, run: func(g *Game) { g.world.pan(panSpeed, 0) }},
+	{action: "pan-up", help: "Pan up", keys: []keyCombo{key(ebiten.KeyArrowUp)}, held: true,
+		modes: panModes, when: cursorHidden, run: func(g *Game) { g.world.pan(0, -panSpeed) }},
+	{action: "pan-down", help: "Pan down", keys: []keyCombo{key(ebiten.KeyArrowDown)}, held: true,
+		modes: panModes, when: cursorHidden, run: func(g *Game) { g.world.pan(0, panSpeed) }},
+	{action: "home", help: "Return to the starting view", keys: []keyCombo{key(ebiten.KeyHome)},
+		run: func(g *Game) { g.world.camera.x, g.world.camera.y = 0, 0 }},
+	{action: "zoom-in", help: "Zoom in", keys: []keyCombo{key(ebiten.KeyEqual), key(ebiten.KeyKPAdd)},
+		run: func(g *Game) { g.world.zoomCentre(1) }},
+	{action: "zoom-out", help: "Zoom out", keys: []keyCombo{key(ebiten.KeyMinus), key(ebiten.KeyKPSubtract)},
+		run: func(g *Game) { g.world.zoomCentre(-1) }},
+	{action: "follow", help: "Follow the live cells", keys: []keyCombo{key(ebiten.KeyF)},
+		run: func(g *Game) { g.world.camera.follow = !g.world.camera.follow }},
+	{action: "fullscreen", help: "Fullscreen", keys: []keyCombo{key(ebiten.KeyF11), {key: ebiten.KeyEnter, alt: true}},
+		run: func(g *Game) { ebiten.SetFullscreen(!ebiten.IsFullscreen()) }},
+	{action: "grid", help: "Show or hide grid lines", keys: []keyCombo{key(ebiten.KeyL)},
+		run: func(g *Game) { g.world.showGrid = !g.world.showGrid }},
+	{action: "theme", help: "Cycle color theme", keys: []keyCombo{key(ebiten.KeyF2)},
+		run: func(g *Game) { g.world.theme = nextTheme(g.world.theme) }},
+	{action: "dark-mode", help: "Switch dark mode on or off", keys: []keyCombo{ctrl(ebiten.KeyD)},
+		run: func(g *Game) { g.world.toggleDarkMode() }},
+	{action: "color-mode", help: "Cycle cell coloring", keys: []keyCombo{key(ebiten.KeyC)},
+		run: func(g *Game) { g.world.colorMode = g.world.colorMode.next() }},
+	{action: "trails", help: "Fading trails", keys: []keyCombo{key(ebiten.KeyT)},
+		run: func(g *Game) { g.world.trails.visible = !g.world.trails.visible }},
+	{action: "debug", help: "Debug overlay", keys: []keyCombo{key(ebiten.KeyF3)},
+		run: func(g *Game) { g.debug.visible = !g.debug.visible }},
+	{action: "heatmap", help: "Activity heatmap", keys: []keyCombo{key(ebiten.KeyF4)},
+		run: func(g *Game) { g.world.heatmap.visible = !g.world.heatmap.visible }},
+	{action: "census", help: "Census of the objects", keys: []keyCombo{key(ebiten.KeyU)},
+		run: (*Game).openCensus},
+	{action: "mute-still", help: "Mute cells that have settled", keys: []keyCombo{key(ebiten.KeyF8)},
+		run: func(g *Game) { g.world.still.muted = !g.world.still.muted }},
+	{action: "smooth", help: "Fade births and deaths", keys: []keyCombo{key(ebiten.KeyF7)},
+		run: func(g *Game) { g.world.smooth = !g.world.smooth }},
+	{action: "crosshair", help: "Row and column guides", keys: []keyCombo{key(ebiten.KeyF5)},
+		run: func(g *Game) { g.world.crosshair = !g.world.crosshair }},
+	{action: "bounds", help: "Bounding box and its growth", keys: []keyCombo{key(ebiten.KeyF9)},
+		run: func(g *Game) { g.world.toggleBounds() }},
+	{action: "metrics", help: "Population, heat, density and entropy chart", keys: []keyCombo{key(ebiten.KeyF10)},
+		run: func(g *Game) { g.world.metrics.visible = !g.world.metrics.visible }},
+	{action: "ruler", help: "Ruler ticks every 10 cells", keys: []keyCombo{key(ebiten.KeyF6)},
+		run: func(g *Game) { g.world.ruler = !g.world.ruler }},
+}
+
+// mouseHelp describes the mouse controls, which aren't in the binding table
+var mouseHelp = [][2]string{
+	{"Left drag", "Paint cells, place a stamp or select"},
+	{"Right drag", "Erase cells, drop a stamp"},
+	{"Middle drag", "Pan"},
+	{"Space + left drag", "Pan"},
+	{"Wheel", "Zoom"},
+}
+
+// actionKey returns the first key bound to an action, as shown in the help
+func actionKey(action string) string {
+	i := indexOfAction(action)
+	if i < 0 || len(bindings[i].keys) == 0 {
+		return "unbound"
+	}
+	return bindings[i].keys[0].String()
+}
+
+// actionPressed reports whether one of the keys bound to an action was
+// just pressed, so overlays close on the key that opened them however it is
+// mapped
+func actionPressed(action string) bool {
+	i := indexOfAction(action)
+	if i < 0 {
+		return false
+	}
+	for _, k := range bindings[i].keys {
+		if inpututil.IsKeyJustPressed(k.key) && k.modifiersHeld() {
+			return true
+		}
+	}
+	return false
+}
+
+// applyKeymap replaces the keys of the actions named in keymap, so
+// controls can be remapped from the config file
+func applyKeymap(keymap map[string][]string) error {
+	for action, names := range keymap {
+		i := indexOfAction(action)
+		if i < 0 {
+			return fmt.Errorf("unknown action %q in key bindings", action)
+		}
+		keys := make([]keyCombo, 0, len(names))
+		for _, name := range names {
+			k, err := parseKeyCombo(name)
+			if err != nil {
+				return fmt.Errorf("binding for %s: %w", action, err)
+			}
+			keys = append(keys, k)
+		}
+		bindings[i].keys = keys
+	}
+	return nil
+}
+
+// indexOfAction returns the position of an action in the binding table
+func indexOfAction(action string) int {
+	for i, b := range bindings {
+		if b.action == action {
+			return i
+		}
+	}
+	return -1
+}
+
+// handleBindings runs every binding of mode m whose keys were pressed this
+// frame
+func (g *Game) handleBindings(m mode) {
+	// Work out what was triggered before running anything, so one binding
+	// can't change the conditions of another in the same frame
+	var triggered []binding
+	for _, b := range bindings {
+		if b.triggered(g, m) {
+			triggered = append(triggered, b)
+		}
+	}
+	for _, b := range triggered {
+		b.run(g)
+	}
+	// A count is for the next key only, whether or not that key uses it
+	if len(triggered) > 0 {
+		g.count = 0
+	}
+}
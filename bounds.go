@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// boundingBox shows the box around the live cells and how fast it grows
+type boundingBox struct {
+	visible bool
+	// from is the box when it was turned on, growth is measured from it
+	from life.GenerationStats
+}
+
+// toggleBounds shows or hides the bounding box, measuring growth from now
+func (w *World) toggleBounds() {
+	w.bounds.visible = !w.bounds.visible
+	w.bounds.from = w.liveBounds()
+}
+
+// liveBounds returns the bounding box of the live cells. The step's
+// statistics have it unless the cells have been edited since.
+func (w *World) liveBounds() life.GenerationStats {
+	s := w.stats
+	if s.Generation != w.grid.Generation || s.Population != w.grid.Population() {
+		s = life.Measure(nil, w.grid.Cells())
+		s.Generation = w.grid.Generation
+	}
+	return s
+}
+
+// boundsText describes the size of the box, and how many cells a generation
+// it has grown by in each direction since it was turned on
+func (w *World) boundsText() string {
+	s := w.liveBounds()
+	text := fmt.Sprintf("Box: %dx%d", s.Width(), s.Height())
+	if gens := s.Generation - w.bounds.from.Generation; gens > 0 {
+		text += fmt.Sprintf(", growing %.2fx%.2f a generation",
+			float64(s.Width()-w.bounds.from.Width())/float64(gens), float64(s.Height()-w.bounds.from.Height())/float64(gens))
+	}
+	return text
+}
+
+// drawBounds outlines the bounding box of the live cells
+func (w *World) drawBounds(screen *ebiten.Image) {
+	if !w.bounds.visible || w.grid.Population() == 0 {
+		return
+	}
+	s := w.liveBounds()
+	x, y := w.cellToScreen(s.MinX, s.MinY)
+	size := float32(w.tileSize)
+	vector.StrokeRect(screen, x, y, float32(s.Width())*size, float32(s.Height())*size, 2, w.theme.Warning, w.antialias)
+}
@@ -0,0 +1,36 @@
+//go:build js
+
+package main
+
+import (
+	"flag"
+	"net/url"
+	"sort"
+	"strings"
+	"syscall/js"
+)
+
+// inBrowser is set when the game runs as WebAssembly in a web page
+const inBrowser = true
+
+// browserArgs turns the query string of the page into command line flags,
+// so a link such as ?pattern=acorn or ?random=true&seed=42 picks how the game
+// starts. Parameters that aren't flags are ignored.
+func browserArgs() []string {
+	search := js.Global().Get("location").Get("search").String()
+	values, err := url.ParseQuery(strings.TrimPrefix(search, "?"))
+	if err != nil {
+		return nil
+	}
+	var args []string
+	for name, vs := range values {
+		if flag.Lookup(name) == nil {
+			continue
+		}
+		for _, v := range vs {
+			args = append(args, "-"+name+"="+v)
+		}
+	}
+	sort.Strings(args)
+	return args
+}
@@ -0,0 +1,12 @@
+//go:build !js
+
+package main
+
+// inBrowser is set when the game runs as WebAssembly in a web page
+const inBrowser = false
+
+// browserArgs returns the flags given in the page URL, there are none
+// outside a browser
+func browserArgs() []string {
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// panSpeed is how many pixels the arrow keys move the camera per frame
+const panSpeed = 8
+
+// Smallest and largest cell sizes in pixels when zooming
+const (
+	minTileSize = 2
+	maxTileSize = 64
+)
+
+// camera is the offset in pixels of the view from the grid origin
+type camera struct {
+	x, y int
+
+	// Middle mouse or space drag state. spacePanned is set once a drag
+	// with space held has moved the view, so letting go of space doesn't
+	// start the simulation as well.
+	dragging     bool
+	dragX, dragY int
+	spacePanned  bool
+
+	// follow keeps the live cells centred in the view
+	follow bool
+
+	// Touch screen gesture state
+	pinch pinch
+}
+
+// pan moves the camera by a number of pixels. Panning by hand takes over
+// from follow mode.
+func (w *World) pan(dx, dy int) {
+	w.camera.x += dx
+	w.camera.y += dy
+	w.camera.follow = false
+}
+
+// handlePan moves the camera with a middle mouse drag, or a left drag with
+// space held as in image editors
+func (w *World) handlePan() {
+	x, y := ebiten.CursorPosition()
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		w.camera.spacePanned = false
+	}
+	spaceDrag := ebiten.IsKeyPressed(ebiten.KeySpace) && ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) && !spaceDrag {
+		w.camera.dragging = false
+		return
+	}
+	if w.camera.dragging && (x != w.camera.dragX || y != w.camera.dragY) {
+		// Dragging moves the grid with the cursor
+		w.pan(w.camera.dragX-x, w.camera.dragY-y)
+		w.camera.spacePanned = w.camera.spacePanned || spaceDrag
+	}
+	w.camera.dragging = true
+	w.camera.dragX, w.camera.dragY = x, y
+}
+
+// handleZoom changes the cell size with the mouse wheel, keeping the cell
+// under the cursor in place
+func (w *World) handleZoom() {
+	x, y := ebiten.CursorPosition()
+	_, wheel := ebiten.Wheel()
+	switch {
+	case wheel > 0:
+		w.zoom(w.tileSize*5/4+1, x, y)
+	case wheel < 0:
+		w.zoom(w.tileSize*4/5, x, y)
+	}
+}
+
+// zoomCentre zooms in (dir 1) or out (dir -1) around the middle of the view
+func (w *World) zoomCentre(dir int) {
+	size := w.tileSize * 4 / 5
+	if dir > 0 {
+		size = w.tileSize*5/4 + 1
+	}
+	w.zoom(size, w.screenWidth/2, (w.gridTop+w.screenHeight)/2)
+}
+
+// zoom sets the cell size, moving the camera so that the grid point at
+// screen position x, y stays where it is
+func (w *World) zoom(size, x, y int) {
+	size = min(max(size, minTileSize), maxTileSize)
+	if size == w.tileSize {
+		return
+	}
+	y -= w.gridTop
+	w.camera.x = (x+w.camera.x)*size/w.tileSize - x
+	w.camera.y = (y+w.camera.y)*size/w.tileSize - y
+	w.tileSize = size
+}
+
+// followCells eases the camera towards the centre of the bounding box of the
+// live cells
+func (w *World) followCells() {
+	if !w.camera.follow || w.grid.Population() == 0 {
+		return
+	}
+	minX, minY, maxX, maxY := w.grid.Bounds()
+	// Camera position that puts the box centre in the middle of the view
+	targetX := (minX+maxX+1)*w.tileSize/2 - w.screenWidth/2
+	targetY := (minY+maxY+1)*w.tileSize/2 - (w.screenHeight-w.gridTop)/2
+
+	// Move a fraction of the way each frame so the view glides
+	w.camera.x += ease(targetX - w.camera.x)
+	w.camera.y += ease(targetY - w.camera.y)
+}
+
+// ease returns the step to take towards a target distance away
+func ease(distance int) int {
+	step := distance / 5
+	if step == 0 && distance != 0 {
+		// Always move at least a pixel so the target is reached
+		if distance > 0 {
+			return 1
+		}
+		return -1
+	}
+	return step
+}
+
+// screenToCell returns the cell under a screen position, false if the
+// position is outside the grid area
+func (w *World) screenToCell(x, y int) (tile, bool) {
+	if x < 0 || x >= w.screenWidth || y < w.gridTop || y >= w.screenHeight {
+		return tile{}, false
+	}
+	return tile{
+		X: floorDiv(x+w.camera.x, w.tileSize),
+		Y: floorDiv(y-w.gridTop+w.camera.y, w.tileSize),
+	}, true
+}
+
+// cellToScreen returns the screen position of the top left corner of a cell
+func (w *World) cellToScreen(x, y int) (float32, float32) {
+	return float32(x*w.tileSize - w.camera.x), float32(w.gridTop + y*w.tileSize - w.camera.y)
+}
+
+// viewCentre returns the cell in the middle of the view
+func (w *World) viewCentre() tile {
+	cell, _ := w.screenToCell(w.screenWidth/2, (w.gridTop+w.screenHeight)/2)
+	return cell
+}
+
+// visibleCells returns the range of cells at least partly inside the view
+func (w *World) visibleCells() (minX, minY, maxX, maxY int) {
+	minX = floorDiv(w.camera.x, w.tileSize)
+	minY = floorDiv(w.camera.y, w.tileSize)
+	maxX = floorDiv(w.camera.x+w.screenWidth, w.tileSize)
+	maxY = floorDiv(w.camera.y+w.screenHeight-w.gridTop, w.tileSize)
+	return minX, minY, maxX, maxY
+}
+
+// onScreen reports whether any of a cell is inside the view, cells that
+// aren't are skipped when drawing
+func (w *World) onScreen(cell tile) bool {
+	minX, minY, maxX, maxY := w.visibleCells()
+	return cell.X >= minX && cell.X <= maxX && cell.Y >= minY && cell.Y <= maxY
+}
+
+// floorDiv divides rounding towards negative infinity, so cells left of and
+// above the origin get negative coordinates
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
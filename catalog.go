@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// thumbSize is the width and height in pixels of a catalog preview
+const thumbSize = 80
+
+// catalogPadding is the space around each preview in the catalog
+const catalogPadding = 8
+
+// thumbnail is a preview of a pattern drawn in a theme's colors, the image
+// is nil when the pattern couldn't be loaded
+type thumbnail struct {
+	image *ebiten.Image
+	theme *Theme
+}
+
+// catalog is an overlay showing a preview of every pattern in the library,
+// filtered by typing part of a name
+type catalog struct {
+	open     bool
+	entries  []patternEntry
+	search   string
+	selected int
+	scroll   int
+
+	// thumbs caches the previews by entry label
+	thumbs map[string]*thumbnail
+}
+
+// show opens the catalog with a fresh list of patterns
+func (c *catalog) show(dir string) {
+	c.open = true
+	c.entries = listPatterns(dir)
+	c.search = ""
+	c.selected = 0
+	c.scroll = 0
+	if c.thumbs == nil {
+		c.thumbs = make(map[string]*thumbnail)
+	}
+}
+
+// matches returns the entries whose names contain the search text
+func (c *catalog) matches() []patternEntry {
+	search := strings.ToLower(c.search)
+	var found []patternEntry
+	for _, e := range c.entries {
+		if strings.Contains(strings.ToLower(e.name), search) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// catalogLayout returns where the previews start, how many fit across and
+// how many rows are visible
+func catalogLayout(w *World) (x, y, columns, rows int) {
+	x, y = 20, w.gridTop+20+2*charHeight
+	cellWidth := thumbSize + 2*catalogPadding
+	cellHeight := thumbSize + charHeight + 2*catalogPadding
+	columns = max((w.screenWidth-2*x)/cellWidth, 1)
+	rows = max((w.screenHeight-y-20)/cellHeight, 1)
+	return x, y, columns, rows
+}
+
+// handleCatalog filters the catalog as a name is typed, moves the selection
+// with the arrow keys and stamps the selected pattern on enter or click
+func (g *Game) handleCatalog() {
+	c := &g.catalog
+	x, y, columns, rows := catalogLayout(g.world)
+	found := c.matches()
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		c.open = false
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		g.pickCatalogEntry(found)
+		return
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		cx, cy := ebiten.CursorPosition()
+		col := (cx - x) / (thumbSize + 2*catalogPadding)
+		row := (cy - y) / (thumbSize + charHeight + 2*catalogPadding)
+		if cx >= x && cy >= y && col < columns && row < rows {
+			i := (c.scroll+row)*columns + col
+			if i < len(found) {
+				c.selected = i
+				g.pickCatalogEntry(found)
+			}
+		}
+		return
+	case keyRepeated(ebiten.KeyBackspace) && c.search != "":
+		c.search = c.search[:len(c.search)-1]
+		c.selected = 0
+	case keyRepeated(ebiten.KeyArrowLeft):
+		c.selected--
+	case keyRepeated(ebiten.KeyArrowRight):
+		c.selected++
+	case keyRepeated(ebiten.KeyArrowUp):
+		c.selected -= columns
+	case keyRepeated(ebiten.KeyArrowDown):
+		c.selected += columns
+	}
+	for _, r := range ebiten.AppendInputChars(nil) {
+		// The debug font only has ASCII
+		if r > ' ' && r <= '~' {
+			c.search += string(r)
+			c.selected = 0
+		}
+	}
+
+	// Keep the selection in range and its row scrolled into view
+	found = c.matches()
+	c.selected = min(max(c.selected, 0), max(len(found)-1, 0))
+	row := c.selected / columns
+	c.scroll = min(c.scroll, row)
+	c.scroll = max(c.scroll, row-rows+1)
+}
+
+// pickCatalogEntry loads the selected pattern into stamp mode
+func (g *Game) pickCatalogEntry(found []patternEntry) {
+	c := &g.catalog
+	if c.selected >= len(found) {
+		return
+	}
+	p, err := found[c.selected].load()
+	if err != nil {
+		log.Printf("loading pattern: %v", err)
+		return
+	}
+	g.world.stamp = p
+	c.open = false
+}
+
+// thumbnail returns the preview of an entry, drawing it the first time it
+// is shown or after the theme changes
+func (c *catalog) thumbnail(e patternEntry, theme *Theme) *thumbnail {
+	t := c.thumbs[e.label()]
+	if t != nil && t.theme == theme {
+		return t
+	}
+	if t != nil && t.image != nil {
+		t.image.Deallocate()
+	}
+	t = &thumbnail{theme: theme}
+	c.thumbs[e.label()] = t
+
+	p, err := e.load()
+	if err != nil {
+		return t
+	}
+	t.image = ebiten.NewImage(thumbSize, thumbSize)
+	t.image.Fill(theme.Background)
+	if p.width == 0 || p.height == 0 {
+		return t
+	}
+	// Scale the pattern to fit, centred, with cells at least a pixel wide
+	scale := min(float32(thumbSize)/float32(p.width), float32(thumbSize)/float32(p.height))
+	left := (thumbSize - scale*float32(p.width)) / 2
+	top := (thumbSize - scale*float32(p.height)) / 2
+	size := max(scale, 1)
+	for _, cell := range p.cells {
+		vector.DrawFilledRect(t.image, left+float32(cell.X)*scale, top+float32(cell.Y)*scale, size, size, theme.Cell, false)
+	}
+	return t
+}
+
+// draw shows the search text and a grid of previews
+func (c *catalog) draw(screen *ebiten.Image, w *World) {
+	if !c.open {
+		return
+	}
+	x, y, columns, rows := catalogLayout(w)
+	vector.DrawFilledRect(screen, 10, float32(w.gridTop+10), float32(w.screenWidth-20), float32(w.screenHeight-w.gridTop-20), overlayBackground, false)
+
+	found := c.matches()
+	header := fmt.Sprintf("Catalog (%d)  Search: %s_", len(found), c.search)
+	ebitenutil.DebugPrintAt(screen, header+"\nType to search, arrows to move, Enter or click to stamp, Esc to close", x, w.gridTop+20)
+
+	cellWidth := thumbSize + 2*catalogPadding
+	cellHeight := thumbSize + charHeight + 2*catalogPadding
+	for i := c.scroll * columns; i < min((c.scroll+rows)*columns, len(found)); i++ {
+		col, row := i%columns, i/columns-c.scroll
+		cx := x + col*cellWidth + catalogPadding
+		cy := y + row*cellHeight + catalogPadding
+
+		t := c.thumbnail(found[i], w.theme)
+		if t.image != nil {
+			var op ebiten.DrawImageOptions
+			op.GeoM.Translate(float64(cx), float64(cy))
+			screen.DrawImage(t.image, &op)
+		} else {
+			ebitenutil.DebugPrintAt(screen, "error", cx+4, cy+4)
+		}
+		if i == c.selected {
+			vector.StrokeRect(screen, float32(cx-2), float32(cy-2), thumbSize+4, thumbSize+4, 2, w.theme.Accent, false)
+		}
+
+		// Names that don't fit under the preview are cut short
+		name := found[i].name
+		if maxChars := cellWidth / charWidth; len(name) > maxChars {
+			name = name[:maxChars-1] + "~"
+		}
+		ebitenutil.DebugPrintAt(screen, name, cx, cy+thumbSize+2)
+	}
+	if len(found) == 0 {
+		ebitenutil.DebugPrintAt(screen, "No patterns match", x, y)
+	}
+}
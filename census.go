@@ -0,0 +1,403 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// censusMaxCells is the largest group of cells run to find what it is,
+// bigger groups are still settling and counted as unsettled
+const censusMaxCells = 400
+
+// censusObject is one object found in a census
+type censusObject struct {
+	name string
+	// key is the smallest fingerprint of the object's phases, the same
+	// wherever it is and however it is turned, zero for unsettled objects
+	key   uint64
+	cells map[tile]struct{}
+	// period is how many generations the object takes to repeat, 1 for
+	// still lifes and 0 when it didn't repeat on its own. moving objects
+	// repeat shifted.
+	period int
+	moving bool
+}
+
+// position is the top left of the object's box
+func (o censusObject) position() tile {
+	first := true
+	var at tile
+	for cell := range o.cells {
+		if first {
+			at, first = cell, false
+			continue
+		}
+		at.X, at.Y = min(at.X, cell.X), min(at.Y, cell.Y)
+	}
+	return at
+}
+
+// census is the objects making up a generation
+type census struct {
+	generation int
+	objects    []censusObject
+}
+
+// censusCount is how many of one kind of object a census found
+type censusCount struct {
+	name  string
+	count int
+}
+
+// takeCensus splits cells into objects and identifies each by running it on
+// its own. Only two state rules can be censused.
+func takeCensus(g *life.Grid) (*census, error) {
+	if g.States() > 2 {
+		return nil, fmt.Errorf("census needs a rule with two states, %s has %d", g.RuleName(), g.States())
+	}
+	rule := g.RuleName()
+	c := &census{generation: g.Generation}
+	for _, group := range censusGroups(g.Cells(), 2) {
+		obj := identify(group, rule)
+		// Objects near each other, like two blocks a cell apart or the four
+		// blinkers of a traffic light, are counted apart when each one
+		// repeats on its own
+		if parts := censusGroups(group, 1); obj.period > 0 && len(parts) > 1 {
+			var split []censusObject
+			for _, part := range parts {
+				if p := identify(part, rule); p.period > 0 {
+					split = append(split, p)
+				}
+			}
+			if len(split) == len(parts) {
+				c.objects = append(c.objects, split...)
+				continue
+			}
+		}
+		c.objects = append(c.objects, obj)
+	}
+	return c, nil
+}
+
+// censusGroups splits cells into groups of cells at most reach apart. Cells
+// two apart share a neighbour and can affect each other, so groups with a
+// reach of 2 evolve on their own until they meet another.
+func censusGroups(cells map[tile]struct{}, reach int) []map[tile]struct{} {
+	seen := make(map[tile]struct{}, len(cells))
+	var groups []map[tile]struct{}
+	// Start from the cells in order so the groups come out the same each time
+	for _, start := range sortedCells(cells) {
+		if _, ok := seen[start]; ok {
+			continue
+		}
+		group := map[tile]struct{}{start: {}}
+		seen[start] = struct{}{}
+		queue := []tile{start}
+		for len(queue) > 0 {
+			cell := queue[0]
+			queue = queue[1:]
+			for dy := -reach; dy <= reach; dy++ {
+				for dx := -reach; dx <= reach; dx++ {
+					next := tile{X: cell.X + dx, Y: cell.Y + dy}
+					if _, alive := cells[next]; !alive {
+						continue
+					}
+					if _, ok := seen[next]; ok {
+						continue
+					}
+					seen[next] = struct{}{}
+					group[next] = struct{}{}
+					queue = append(queue, next)
+				}
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// identify runs a group of cells on its own and names it. Known objects
+// of Conway's rule get their usual names, the rest a code in the style of
+// apgsearch: xs for still lifes with the population, xp for oscillators and
+// xq for spaceships with the period, then part of the key.
+func identify(cells map[tile]struct{}, rule string) censusObject {
+	obj := runObject(cells, rule)
+	if obj.period == 0 {
+		return obj
+	}
+	if name, ok := knownObjects()[obj.key]; ok && rule == life.Conway {
+		obj.name = name
+		return obj
+	}
+	suffix := obj.key & 0xffffff
+	switch {
+	case obj.moving:
+		obj.name = fmt.Sprintf("xq%d_%06x", obj.period, suffix)
+	case obj.period == 1:
+		obj.name = fmt.Sprintf("xs%d_%06x", len(cells), suffix)
+	default:
+		obj.name = fmt.Sprintf("xp%d_%06x", obj.period, suffix)
+	}
+	return obj
+}
+
+// runObject runs a group of cells on an empty grid until it comes back in
+// the same shape, finding its period and the smallest fingerprint of its
+// phases. Groups that don't come back are unsettled.
+func runObject(cells map[tile]struct{}, rule string) censusObject {
+	obj := censusObject{name: "unsettled", cells: cells}
+	if len(cells) > censusMaxCells {
+		return obj
+	}
+	g := life.New(0, 0, life.Rule{})
+	if err := g.SetRule(rule); err != nil {
+		return obj
+	}
+	g.Replace(copyCells(cells))
+
+	fingerprints := []uint64{life.Fingerprint(cells)}
+	for p := 1; p <= maxPeriod; p++ {
+		g.Step()
+		next := g.Cells()
+		if len(next) == 0 {
+			return obj
+		}
+		if dx, dy, ok := sameShape(cells, next); ok {
+			obj.period, obj.moving = p, dx != 0 || dy != 0
+			obj.key = slices.Min(fingerprints)
+			return obj
+		}
+		fingerprints = append(fingerprints, life.Fingerprint(next))
+	}
+	return obj
+}
+
+// knownObjectPatterns are the objects of Conway's rule named in a census,
+// as the name of a built-in pattern or the cells in RLE
+var knownObjectPatterns = []struct {
+	name, pattern string
+}{
+	{"block", "2o$2o!"},
+	{"beehive", "b2o$o2bo$b2o!"},
+	{"loaf", "b2o$o2bo$bobo$2bo!"},
+	{"boat", "2o$obo$bo!"},
+	{"ship", "2o$obo$b2o!"},
+	{"tub", "bo$obo$bo!"},
+	{"pond", "b2o$o2bo$o2bo$b2o!"},
+	{"long boat", "2o$obo$bobo$2bo!"},
+	{"barge", "bo$obo$bobo$2bo!"},
+	{"mango", "b2o$o2bo$bo2bo$2b2o!"},
+	{"eater", "2o$obo$2bo$2b2o!"},
+	{"snake", "2obo$ob2o!"},
+	{"aircraft carrier", "2o$o2bo$2b2o!"},
+	{"blinker", "blinker"},
+	{"toad", "toad"},
+	{"beacon", "beacon"},
+	{"pulsar", "pulsar"},
+	{"pentadecathlon", "pentadecathlon"},
+	{"glider", "glider"},
+	{"LWSS", "lwss"},
+	{"MWSS", "mwss"},
+	{"HWSS", "hwss"},
+}
+
+// knownObjects maps the keys of the known objects to their names, worked
+// out the first time it is needed
+var knownObjects = sync.OnceValue(func() map[uint64]string {
+	known := make(map[uint64]string, len(knownObjectPatterns))
+	for _, k := range knownObjectPatterns {
+		p, err := loadObjectPattern(k.pattern)
+		if err != nil {
+			panic(fmt.Sprintf("known object %s: %v", k.name, err))
+		}
+		known[runObject(p.cellSet(), life.Conway).key] = k.name
+	}
+	return known
+})
+
+// loadObjectPattern loads the built-in pattern of that name, or parses the
+// cells in RLE without a header
+func loadObjectPattern(s string) (*pattern, error) {
+	if p, err := loadBuiltinPattern(s); err == nil {
+		return p, nil
+	}
+	return parseRLE(strings.NewReader("x = 0, y = 0\n" + s))
+}
+
+// counts returns how many of each object the census found, most common
+// first
+func (c *census) counts() []censusCount {
+	n := make(map[string]int)
+	for _, obj := range c.objects {
+		n[obj.name]++
+	}
+	counts := make([]censusCount, 0, len(n))
+	for name, count := range n {
+		counts = append(counts, censusCount{name, count})
+	}
+	sortCounts(counts)
+	return counts
+}
+
+// sortCounts puts the most common objects first, and objects found as often
+// in order of name
+func sortCounts(counts []censusCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].name < counts[j].name
+	})
+}
+
+// positions returns where the objects of each kind are, top to bottom and
+// then left to right
+func (c *census) positions() map[string][]tile {
+	at := make(map[string][]tile)
+	for _, obj := range c.objects {
+		at[obj.name] = append(at[obj.name], obj.position())
+	}
+	for _, cells := range at {
+		sort.Slice(cells, func(i, j int) bool {
+			if cells[i].Y != cells[j].Y {
+				return cells[i].Y < cells[j].Y
+			}
+			return cells[i].X < cells[j].X
+		})
+	}
+	return at
+}
+
+// writeAsh writes a line for each kind of object, most common first, with
+// how many were found and the top left of each
+func (c *census) writeAsh(out io.Writer) error {
+	at := c.positions()
+	for _, count := range c.counts() {
+		if _, err := fmt.Fprintf(out, "%s\t%d\t%s\n", count.name, count.count, formatCells(at[count.name], -1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatCells lists cells as x,y pairs separated by spaces, at most n of
+// them followed by how many more there are, or all of them when n is
+// negative
+func formatCells(cells []tile, n int) string {
+	if n < 0 || n > len(cells) {
+		n = len(cells)
+	}
+	parts := make([]string, 0, n+1)
+	for _, cell := range cells[:n] {
+		parts = append(parts, fmt.Sprintf("%d,%d", cell.X, cell.Y))
+	}
+	if n < len(cells) {
+		parts = append(parts, fmt.Sprintf("and %d more", len(cells)-n))
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeAshFile writes the census to path, or to a timestamped file in the
+// working directory when path is empty, and returns the name written
+func (c *census) writeAshFile(path string) (string, error) {
+	if path == "" {
+		path = fmt.Sprintf("gameoflife-ash-%s.txt", time.Now().Format("20060102-150405"))
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(f, "# generation %d, %d objects\n", c.generation, len(c.objects))
+	if err := c.writeAsh(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// summary lists the most common objects on one line, at most n of them
+func (c *census) summary(n int) string {
+	counts := c.counts()
+	parts := make([]string, 0, min(n, len(counts)))
+	for _, count := range counts[:min(n, len(counts))] {
+		parts = append(parts, fmt.Sprintf("%d %s", count.count, count.name))
+	}
+	if len(counts) > n {
+		parts = append(parts, "...")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// censusRows is how many kinds of object the census overlay lists
+const censusRows = 20
+
+// censusPositions is how many positions of each kind the overlay lists
+const censusPositions = 4
+
+// censusView is an overlay showing a census of the world
+type censusView struct {
+	open   bool
+	result *census
+}
+
+// openCensus shows the census taken when the world settled, or takes one of
+// the cells now
+func (g *Game) openCensus() {
+	c, err := g.world.currentCensus()
+	if err != nil {
+		g.command.show(err.Error())
+		return
+	}
+	g.census = censusView{open: true, result: c}
+}
+
+// currentCensus returns the census taken when the world settled, or takes
+// one of the cells now
+func (w *World) currentCensus() (*census, error) {
+	if w.census != nil && w.census.generation == w.grid.Generation {
+		return w.census, nil
+	}
+	return takeCensus(w.grid)
+}
+
+// handleCensus closes the overlay on Esc or the key that opened it
+func (g *Game) handleCensus() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || actionPressed("census") {
+		g.census.open = false
+	}
+}
+
+// draw lists the objects found, most common first, like the census of
+// apgsearch, with where the first few of each kind are
+func (v *censusView) draw(screen *ebiten.Image, w *World) {
+	if !v.open {
+		return
+	}
+	c := v.result
+	var b strings.Builder
+	fmt.Fprintf(&b, "Census at generation %d, %d objects\n\n", c.generation, len(c.objects))
+	counts := c.counts()
+	at := c.positions()
+	for _, count := range counts[:min(censusRows, len(counts))] {
+		fmt.Fprintf(&b, "%6d  %-16s %s\n", count.count, count.name, formatCells(at[count.name], censusPositions))
+	}
+	if len(counts) > censusRows {
+		fmt.Fprintf(&b, "  and %d more kinds\n", len(counts)-censusRows)
+	}
+	if len(counts) == 0 {
+		b.WriteString("  nothing alive\n")
+	}
+	b.WriteString("\n:ash writes every position, Esc to close")
+	drawCentredBox(screen, w, b.String())
+}
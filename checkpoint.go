@@ -0,0 +1,90 @@
+package main
+
+// checkpoint is a snapshot of the live cells at a generation
+type checkpoint struct {
+	generation int
+	cells      map[tile]struct{}
+}
+
+// checkpointRing keeps the most recent checkpoints in a fixed size ring
+// buffer, overwriting the oldest one when full
+type checkpointRing struct {
+	every int
+	items []checkpoint
+	start int
+	count int
+}
+
+// newCheckpointRing creates a ring holding size checkpoints taken every
+// `every` generations
+func newCheckpointRing(size, every int) *checkpointRing {
+	return &checkpointRing{
+		every: every,
+		items: make([]checkpoint, max(size, 0)),
+	}
+}
+
+// record saves a copy of cells if generation is due for a checkpoint
+func (r *checkpointRing) record(generation int, cells map[tile]struct{}) {
+	if len(r.items) == 0 || r.every <= 0 || generation%r.every != 0 {
+		return
+	}
+	snapshot := make(map[tile]struct{}, len(cells))
+	for cell := range cells {
+		snapshot[cell] = struct{}{}
+	}
+
+	// Stepping again from a restored checkpoint replaces it
+	if r.count > 0 {
+		last := (r.start + r.count - 1) % len(r.items)
+		if r.items[last].generation == generation {
+			r.items[last].cells = snapshot
+			return
+		}
+	}
+
+	end := (r.start + r.count) % len(r.items)
+	r.items[end] = checkpoint{generation: generation, cells: snapshot}
+	if r.count < len(r.items) {
+		r.count++
+	} else {
+		r.start = (r.start + 1) % len(r.items)
+	}
+}
+
+// rewind drops every checkpoint taken at or after generation and returns the
+// newest one left, so repeated calls walk further back in time
+func (r *checkpointRing) rewind(generation int) (checkpoint, bool) {
+	for r.count > 0 {
+		last := r.items[(r.start+r.count-1)%len(r.items)]
+		if last.generation < generation {
+			return last, true
+		}
+		r.count--
+	}
+	return checkpoint{}, false
+}
+
+// at returns the checkpoint taken at a generation
+func (r *checkpointRing) at(generation int) (checkpoint, bool) {
+	for i := range r.count {
+		if cp := r.items[(r.start+i)%len(r.items)]; cp.generation == generation {
+			return cp, true
+		}
+	}
+	return checkpoint{}, false
+}
+
+// generations returns the generations of the checkpoints, oldest first
+func (r *checkpointRing) generations() []int {
+	gens := make([]int, r.count)
+	for i := range r.count {
+		gens[i] = r.items[(r.start+i)%len(r.items)].generation
+	}
+	return gens
+}
+
+// clear drops all checkpoints
+func (r *checkpointRing) clear() {
+	r.start, r.count = 0, 0
+}
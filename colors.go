@@ -0,0 +1,79 @@
+package main
+
+import (
+	"image/color"
+)
+
+// colorMode selects how live cells are colored
+type colorMode int
+
+const (
+	colorPlain colorMode = iota
+	// colorAge shades cells by how many generations they have survived
+	colorAge
+	// colorNeighbors tints cells by their live neighbor count and shows the
+	// cells that die in the next generation in the warning color
+	colorNeighbors
+	colorModes
+)
+
+// String returns the name of the color mode shown in the HUD
+func (m colorMode) String() string {
+	switch m {
+	case colorAge:
+		return "age"
+	case colorNeighbors:
+		return "neighbors"
+	}
+	return "plain"
+}
+
+// next returns the color mode after m, wrapping round to plain
+func (m colorMode) next() colorMode {
+	return (m + 1) % colorModes
+}
+
+// Cells older than maxAgeShade generations all get the oldest color
+const maxAgeShade = 50
+
+// cellColor returns the color to draw a live cell with
+func (w *World) cellColor(cell tile) color.RGBA {
+	// In a shared world cells placed by hand show who placed them
+	if owner, ok := w.owners[cell]; ok {
+		return playerColor(owner)
+	}
+	// In a versus game cells show whose they are
+	if w.versus != nil {
+		if s := w.grid.State(cell); s == 1 || s == 2 {
+			return versusColors[s-1]
+		}
+	}
+	// Settled cells are muted so the active regions stand out
+	if w.still.muted && w.isStill(cell) {
+		return lerpColor(w.theme.Cell, w.theme.Background, 0.6)
+	}
+	// Rules with more than two states color cells by their state
+	if states := w.grid.States(); states > 2 {
+		return w.theme.stateColor(int(w.grid.State(cell)), states)
+	}
+	switch w.colorMode {
+	case colorAge:
+		t := float64(min(w.ages[cell], maxAgeShade)) / maxAgeShade
+		return lerpColor(w.theme.Cell, w.theme.OldCell, t)
+	case colorNeighbors:
+		n := w.grid.Neighbors(cell)
+		if !w.grid.Rule.Survive[n] {
+			return w.theme.Warning
+		}
+		return lerpColor(w.theme.Cell, w.theme.OldCell, float64(n)/8)
+	}
+	return w.theme.Cell
+}
+
+// lerpColor blends from a to b, t runs from 0 (a) to 1 (b)
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
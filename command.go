@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// messageTime is how long the result of a command stays on screen
+const messageTime = 3 * time.Second
+
+// commandLine is a line at the bottom of the screen for typing commands
+// such as ":rule B36/S23"
+type commandLine struct {
+	open bool
+	text string
+
+	// message is the result of the last command, shown until messageUntil
+	message      string
+	messageUntil time.Time
+}
+
+// command is a command that can be typed on the command line
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(g *Game, args []string) error
+}
+
+// commands is the table of commands understood by the command line
+var commands = []command{
+	{name: "q", help: "Quit", run: func(g *Game, args []string) error {
+		g.quitting = true
+		return nil
+	}},
+	{name: "w", help: "Save the settings", run: func(g *Game, args []string) error {
+		g.saveSettings()
+		return nil
+	}},
+	{name: "step", usage: "[n]", help: "Step n generations", run: func(g *Game, args []string) error {
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 || n > maxCount {
+				return fmt.Errorf("step count must be from 1 to %d", maxCount)
+			}
+		}
+		for range n {
+			g.world.SimulateWorld()
+		}
+		return nil
+	}},
+	{name: "rule", usage: "<rule>", help: "Change the rule", run: func(g *Game, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: rule B3/S23")
+		}
+		if err := g.world.grid.SetRule(args[0]); err != nil {
+			return err
+		}
+		g.saveSettings()
+		return nil
+	}},
+	{name: "speed", usage: "<duration>", help: "Time between generations", run: func(g *Game, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: speed 100ms")
+		}
+		d, err := time.ParseDuration(args[0])
+		if err != nil || d <= 0 {
+			return fmt.Errorf("speed must be a positive duration such as 100ms")
+		}
+		g.world.speed = d
+		g.saveSettings()
+		return nil
+	}},
+	{name: "theme", usage: "<name>", help: "Change the color theme", run: func(g *Game, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("themes: %s", strings.Join(themeNames(), ", "))
+		}
+		t, err := themeByName(args[0])
+		if err != nil {
+			return err
+		}
+		g.world.theme = t
+		g.saveSettings()
+		return nil
+	}},
+	{name: "boundary", usage: "<edges>", help: "Change how the grid edges behave", run: func(g *Game, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: boundary unbounded, bounded or torus")
+		}
+		b, err := life.ParseBoundary(args[0])
+		if err != nil {
+			return err
+		}
+		g.world.grid.Boundary = b
+		g.saveSettings()
+		return nil
+	}},
+	{name: "pattern", usage: "<name>", help: "Stamp a built-in pattern or RLE file", run: func(g *Game, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("patterns: %s", strings.Join(builtinPatternNames(), ", "))
+		}
+		p, err := loadPattern(args[0])
+		if err != nil {
+			return err
+		}
+		g.world.stamp = p
+		return nil
+	}},
+	{name: "diff", usage: "[from] [to]", help: "Compare two generations or saved patterns, with none close the diff or compare with the last generation", run: func(g *Game, args []string) error {
+		w := g.world
+		if len(args) == 0 && w.diff != nil {
+			w.diff = nil
+			return nil
+		}
+		gens := make([]int, len(args))
+		numbers := true
+		for i, arg := range args {
+			n, err := strconv.Atoi(arg)
+			gens[i], numbers = n, numbers && err == nil
+		}
+		switch {
+		case len(args) == 0:
+			return w.diffGenerations(w.grid.Generation-1, w.grid.Generation)
+		case len(args) == 1 && numbers:
+			return w.diffGenerations(gens[0], w.grid.Generation)
+		case len(args) == 2 && numbers:
+			return w.diffGenerations(gens[0], gens[1])
+		case len(args) == 2:
+			return w.diffPatterns(args[0], args[1])
+		}
+		return fmt.Errorf("usage: diff <generation> [generation] or diff <pattern> <pattern>")
+	}},
+	{name: "versus", usage: "[cells] [generations]", help: "Start a two player game of Immigration, off to stop", run: func(g *Game, args []string) error {
+		if len(args) == 1 && args[0] == "off" {
+			g.world.leaveGameModes()
+			return nil
+		}
+		settings := []int{versusCells, versusGenerations}
+		if len(args) > len(settings) {
+			return fmt.Errorf("usage: versus [cells] [generations] or versus off")
+		}
+		for i, arg := range args {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("usage: versus [cells] [generations] or versus off")
+			}
+			settings[i] = n
+		}
+		return g.world.startVersus(settings[0], settings[1])
+	}},
+	{name: "save", usage: "<name>", help: "Save the selection as a user pattern", run: func(g *Game, args []string) error {
+		if !hasSelection(g) {
+			return fmt.Errorf("nothing selected, select a region first")
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("usage: save <name>")
+		}
+		path, err := g.world.saveSelection(g.patternDir, args[0])
+		if err != nil {
+			return err
+		}
+		g.command.show("saved " + path)
+		return nil
+	}},
+	{name: "record", usage: "[name]", help: "Record edits as a macro, without a name stop recording", run: func(g *Game, args []string) error {
+		w := g.world
+		switch {
+		case len(args) == 0 && w.macros.recording != nil:
+			g.finishMacro()
+		case len(args) == 1 && w.macros.recording == nil:
+			w.startMacro(args[0])
+		case len(args) == 1:
+			return fmt.Errorf("already recording %s", w.macros.recording.name)
+		default:
+			return fmt.Errorf("usage: record <name>, then record to stop")
+		}
+		return nil
+	}},
+	{name: "play", usage: "<name>", help: "Play a macro from the pointed cell", run: func(g *Game, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: play <name>")
+		}
+		return g.world.playMacro(args[0])
+	}},
+	{name: "script", usage: "<file>", help: "Run a Lua script, showing the last line it printed", run: func(g *Game, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: script <file>")
+		}
+		var out bytes.Buffer
+		if err := g.world.runScript(args[0], &out); err != nil {
+			return err
+		}
+		g.command.show(scriptMessage(args[0], &out))
+		return nil
+	}},
+	{name: "clear", help: "Clear the grid", run: func(g *Game, args []string) error {
+		g.world.beginEdit()
+		g.world.setCells(make(map[tile]struct{}))
+		g.world.commitEdit()
+		g.world.isSimulating = false
+		return nil
+	}},
+	{name: "ash", usage: "[file]", help: "Write the census with where each object is", run: func(g *Game, args []string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("usage: ash [file]")
+		}
+		c, err := g.world.currentCensus()
+		if err != nil {
+			return err
+		}
+		name, err := c.writeAshFile(strings.Join(args, ""))
+		if err != nil {
+			return err
+		}
+		g.command.show("wrote " + name)
+		return nil
+	}},
+	{name: "export", help: "Export the grid as SVG", run: func(g *Game, args []string) error {
+		name, err := g.world.exportSVG()
+		if err != nil {
+			return err
+		}
+		g.command.show("exported " + name)
+		return nil
+	}},
+}
+
+// runCommand runs a line typed on the command line
+func (g *Game) runCommand(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, c := range commands {
+		if c.name == fields[0] {
+			return c.run(g, fields[1:])
+		}
+	}
+	return fmt.Errorf("unknown command %q", fields[0])
+}
+
+// show displays the result of a command for a few seconds
+func (c *commandLine) show(message string) {
+	c.message = message
+	c.messageUntil = time.Now().Add(messageTime)
+	log.Print(message)
+}
+
+// openCommand starts typing a new command, text is what has been typed
+// already
+func (g *Game) openCommand(text string) {
+	g.command = commandLine{open: true, text: text}
+}
+
+// handleCommand edits the command being typed, running it on enter and
+// closing the command line on Esc
+func (g *Game) handleCommand() {
+	c := &g.command
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		c.open = false
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		c.open = false
+		if err := g.runCommand(c.text); err != nil {
+			c.show(err.Error())
+		}
+		return
+	case keyRepeated(ebiten.KeyBackspace):
+		if c.text == "" {
+			c.open = false
+			return
+		}
+		c.text = c.text[:len(c.text)-1]
+	}
+	for _, r := range ebiten.AppendInputChars(nil) {
+		// The debug font only has ASCII
+		if r >= ' ' && r <= '~' {
+			c.text += string(r)
+		}
+	}
+}
+
+// drawCommand shows the command being typed, the result of the last command
+// or the count typed so far along the bottom of the screen
+func (g *Game) drawCommand(screen *ebiten.Image) {
+	c := &g.command
+	var text string
+	switch {
+	case c.open:
+		text = ":" + c.text + "_"
+	case time.Now().Before(c.messageUntil):
+		text = c.message
+	case g.count > 0:
+		text = strconv.Itoa(g.count)
+	default:
+		return
+	}
+	y := g.world.screenHeight - charHeight - 4
+	vector.DrawFilledRect(screen, 0, float32(y), float32(g.world.screenWidth), charHeight+4, overlayBackground, false)
+	ebitenutil.DebugPrintAt(screen, text, 4, y+2)
+}
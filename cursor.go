@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// keyCursor is a cell cursor moved with the keyboard, for editing without a
+// mouse
+type keyCursor struct {
+	active bool
+	cell   tile
+}
+
+// Conditions for the bindings that change with the keyboard cursor
+func cursorActive(g *Game) bool { return g.world.cursor.active }
+func cursorHidden(g *Game) bool { return !g.world.cursor.active }
+
+// toggleCursor shows or hides the keyboard cursor. It starts on the cell
+// under the mouse, or in the middle of the view.
+func (w *World) toggleCursor() {
+	c := &w.cursor
+	c.active = !c.active
+	if !c.active {
+		return
+	}
+	if cell, ok := w.screenToCell(ebiten.CursorPosition()); ok {
+		c.cell = cell
+		return
+	}
+	c.cell = w.viewCentre()
+}
+
+// moveCursor moves the keyboard cursor by a number of cells, panning to keep
+// it in view
+func (w *World) moveCursor(dx, dy int) {
+	c := &w.cursor
+	c.cell.X += dx
+	c.cell.Y += dy
+
+	x, y := w.cellToScreen(c.cell.X, c.cell.Y)
+	size := float32(w.tileSize)
+	switch {
+	case x < 0:
+		w.pan(int(x), 0)
+	case x+size > float32(w.screenWidth):
+		w.pan(int(x+size)-w.screenWidth, 0)
+	}
+	switch {
+	case y < float32(w.gridTop):
+		w.pan(0, int(y)-w.gridTop)
+	case y+size > float32(w.screenHeight):
+		w.pan(0, int(y+size)-w.screenHeight)
+	}
+}
+
+// cursorAction places the pending stamp at the keyboard cursor, or flips
+// the cell under it and its mirror images as one undoable edit
+func (w *World) cursorAction() {
+	if w.stamp != nil {
+		w.placeStamp(w.cursor.cell)
+		return
+	}
+	alive := w.grid.Get(w.cursor.cell)
+	w.beginEdit()
+	w.paintCell(w.cursor.cell, !alive)
+	w.commitEdit()
+}
+
+// pointedCell returns the cell being pointed at, the keyboard cursor when it
+// is shown and otherwise the cell under the mouse
+func (w *World) pointedCell() (tile, bool) {
+	if w.cursor.active {
+		return w.cursor.cell, true
+	}
+	return w.screenToCell(ebiten.CursorPosition())
+}
+
+// drawCursor outlines the keyboard cursor
+func (w *World) drawCursor(screen *ebiten.Image) {
+	if !w.cursor.active {
+		return
+	}
+	x, y := w.cellToScreen(w.cursor.cell.X, w.cursor.cell.Y)
+	size := float32(w.tileSize)
+	vector.StrokeRect(screen, x, y, size, size, 2, w.theme.Accent, w.antialias)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// maxPeriod is the longest cycle that is detected, it is also how many
+// generations are kept to compare with
+const maxPeriod = 64
+
+// cycleDetector notices when the world repeats an earlier generation, which
+// means it has settled into still lifes and oscillators
+type cycleDetector struct {
+	// hashes are the shape hashes of the last generations, oldest first.
+	// states are copies of the same generations, kept only after a hash
+	// has repeated so that a busy world isn't copied every step.
+	hashes []uint64
+	states []map[tile]struct{}
+	// keep is how many more generations are copied since a hash last
+	// repeated
+	keep int
+
+	// period is how many generations the world takes to repeat, 1 for still
+	// lifes and 0 until a repeat is found. oscillating are the cells that
+	// change during the cycle.
+	period      int
+	oscillating map[tile]struct{}
+
+	// moving is how many generations the world takes to come back shifted
+	// by offset, as a lone spaceship does, 0 unless it does
+	moving int
+	offset tile
+}
+
+// record adds a generation and looks for the closest earlier one it
+// repeats, exactly or shifted. The shape hashes find the generations of the
+// same shape quickly. A repeat is only found once the earlier generation
+// was copied, which is a period after the first repeated hash.
+func (d *cycleDetector) record(cells map[tile]struct{}) {
+	h := life.ShapeHash(cells)
+	period, moving, offset := 0, 0, tile{}
+	for i := len(d.hashes) - 1; i >= 0; i-- {
+		if d.hashes[i] != h {
+			continue
+		}
+		d.keep = maxPeriod
+		if d.states[i] == nil {
+			continue
+		}
+		if dx, dy, ok := sameShape(d.states[i], cells); ok {
+			if dx == 0 && dy == 0 {
+				period = len(d.states) - i
+			} else {
+				moving, offset = len(d.states)-i, tile{X: dx, Y: dy}
+			}
+			break
+		}
+	}
+	d.moving, d.offset = moving, offset
+
+	var state map[tile]struct{}
+	if d.keep > 0 {
+		state = copyCells(cells)
+		d.keep--
+	}
+	d.states = append(d.states, state)
+	d.hashes = append(d.hashes, h)
+	if len(d.states) > maxPeriod {
+		d.states = d.states[1:]
+		d.hashes = d.hashes[1:]
+	}
+
+	if period != d.period {
+		d.period = period
+		d.oscillating = nil
+		if period > 1 {
+			d.oscillating = d.changing(period)
+		}
+	}
+}
+
+// changing returns the cells alive in some but not all of the last period
+// generations
+func (d *cycleDetector) changing(period int) map[tile]struct{} {
+	counts := make(map[tile]int)
+	for _, state := range d.states[len(d.states)-period:] {
+		for cell := range state {
+			counts[cell]++
+		}
+	}
+	changing := make(map[tile]struct{})
+	for cell, n := range counts {
+		if n < period {
+			changing[cell] = struct{}{}
+		}
+	}
+	return changing
+}
+
+// clear forgets the generations, edits start the search again
+func (d *cycleDetector) clear() {
+	*d = cycleDetector{}
+}
+
+// sameShape reports whether b is a with every cell moved by the same
+// offset, and returns the offset
+func sameShape(a, b map[tile]struct{}) (dx, dy int, ok bool) {
+	if len(a) != len(b) {
+		return 0, 0, false
+	}
+	ax, ay, _, _ := life.Bounds(a)
+	bx, by, _, _ := life.Bounds(b)
+	dx, dy = bx-ax, by-ay
+	for cell := range a {
+		if _, ok := b[tile{X: cell.X + dx, Y: cell.Y + dy}]; !ok {
+			return 0, 0, false
+		}
+	}
+	return dx, dy, true
+}
+
+// drawOscillators outlines the cells that oscillate once the world has
+// settled into a cycle
+func (w *World) drawOscillators(screen *ebiten.Image) {
+	size := float32(w.tileSize)
+	for cell := range w.cycle.oscillating {
+		if !w.onScreen(cell) {
+			continue
+		}
+		x, y := w.cellToScreen(cell.X, cell.Y)
+		vector.StrokeRect(screen, x, y, size, size, 1, w.theme.Accent, w.antialias)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// overlayBackground sits behind overlay text so it stays readable over cells
+var overlayBackground = color.RGBA{0, 0, 0, 180}
+
+// debugOverlay shows render and simulation rates for diagnosing performance
+type debugOverlay struct {
+	visible bool
+
+	// Generations per second, sampled once a second
+	sampleStart time.Time
+	sampleSteps int
+	gps         float64
+}
+
+// update samples the number of generations computed in the last second
+func (d *debugOverlay) update(totalSteps int) {
+	elapsed := time.Since(d.sampleStart)
+	if elapsed < time.Second {
+		return
+	}
+	d.gps = float64(totalSteps-d.sampleSteps) / elapsed.Seconds()
+	d.sampleStart = time.Now()
+	d.sampleSteps = totalSteps
+}
+
+// draw prints the overlay in the top left corner of the grid
+func (d *debugOverlay) draw(screen *ebiten.Image, top int, m mode) {
+	if !d.visible {
+		return
+	}
+	text := fmt.Sprintf("FPS: %.1f\nTPS: %.1f\nGen/s: %.1f\nMode: %s", ebiten.ActualFPS(), ebiten.ActualTPS(), d.gps, m)
+	vector.DrawFilledRect(screen, 0, float32(top), 16*charWidth, 4*charHeight+4, overlayBackground, false)
+	ebitenutil.DebugPrintAt(screen, text, 4, top+2)
+}
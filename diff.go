@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// cellDiff compares two states of the grid, it is shown instead of the
+// live cells while it is set
+type cellDiff struct {
+	label string
+	// added are alive only in the second state, removed only in the first
+	// and kept in both
+	added, removed, kept map[tile]struct{}
+}
+
+// newCellDiff compares the cells of two states
+func newCellDiff(label string, before, after map[tile]struct{}) *cellDiff {
+	d := &cellDiff{
+		label:   label,
+		added:   make(map[tile]struct{}),
+		removed: make(map[tile]struct{}),
+		kept:    make(map[tile]struct{}),
+	}
+	for cell := range before {
+		if _, ok := after[cell]; ok {
+			d.kept[cell] = struct{}{}
+		} else {
+			d.removed[cell] = struct{}{}
+		}
+	}
+	for cell := range after {
+		if _, ok := before[cell]; !ok {
+			d.added[cell] = struct{}{}
+		}
+	}
+	return d
+}
+
+// generationCells returns the cells of a generation still in the history,
+// which is the current and previous generations and the checkpoints
+func (w *World) generationCells(gen int) (map[tile]struct{}, error) {
+	switch {
+	case gen == w.grid.Generation:
+		return copyCells(w.grid.Cells()), nil
+	case gen == w.grid.Generation-1 && w.previous != nil:
+		return copyCells(w.previous), nil
+	}
+	if cp, ok := w.checkpoints.at(gen); ok {
+		return copyCells(cp.cells), nil
+	}
+	var kept []string
+	for _, g := range w.checkpoints.generations() {
+		kept = append(kept, strconv.Itoa(g))
+	}
+	if w.previous != nil {
+		kept = append(kept, strconv.Itoa(w.grid.Generation-1))
+	}
+	kept = append(kept, strconv.Itoa(w.grid.Generation))
+	return nil, fmt.Errorf("generation %d is not in the history, it has %s", gen, strings.Join(kept, ", "))
+}
+
+// diffGenerations shows what changed from one generation to another
+func (w *World) diffGenerations(from, to int) error {
+	before, err := w.generationCells(from)
+	if err != nil {
+		return err
+	}
+	after, err := w.generationCells(to)
+	if err != nil {
+		return err
+	}
+	w.diff = newCellDiff(fmt.Sprintf("generation %d to %d", from, to), before, after)
+	return nil
+}
+
+// diffPatterns shows what differs between two saved states. Each is placed
+// as placePattern would put it, so they line up by their top left corners.
+func (w *World) diffPatterns(from, to string) error {
+	var states [2]map[tile]struct{}
+	for i, name := range []string{from, to} {
+		p, err := loadPattern(name)
+		if err != nil {
+			return err
+		}
+		origin := w.patternOrigin(p)
+		states[i] = make(map[tile]struct{}, len(p.cells))
+		for _, cell := range p.cells {
+			states[i][tile{X: cell.X + origin.X, Y: cell.Y + origin.Y}] = struct{}{}
+		}
+	}
+	w.diff = newCellDiff(fmt.Sprintf("%s to %s", from, to), states[0], states[1])
+	return nil
+}
+
+// diffText describes the diff for the HUD
+func (d *cellDiff) diffText() string {
+	return fmt.Sprintf("Diff %s: +%d -%d, %d kept (:diff to close)", d.label, len(d.added), len(d.removed), len(d.kept))
+}
+
+// cellColor returns the color a cell is drawn in, cells in both states
+// dimmed, added cells in the accent color and removed cells in the warning
+// color. ok is false for cells in neither state.
+func (d *cellDiff) cellColor(t *Theme, cell tile) (c color.RGBA, ok bool) {
+	if _, ok := d.added[cell]; ok {
+		return t.Accent, true
+	}
+	if _, ok := d.removed[cell]; ok {
+		return t.Warning, true
+	}
+	if _, ok := d.kept[cell]; ok {
+		return lerpColor(t.Cell, t.Background, 0.6), true
+	}
+	return color.RGBA{}, false
+}
+
+// drawDiff draws the diff in place of the live cells in the colors from
+// cellColor
+func (w *World) drawDiff(screen *ebiten.Image) {
+	d := w.diff
+	for _, cells := range []map[tile]struct{}{d.kept, d.added, d.removed} {
+		for cell := range cells {
+			if w.onScreen(cell) {
+				c, _ := d.cellColor(w.theme, cell)
+				w.drawCell(screen, cell.X, cell.Y, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// exportSVG writes the current generation to a timestamped SVG file in the
+// working directory and returns its name
+func (w *World) exportSVG() (string, error) {
+	name := fmt.Sprintf("gameoflife-%s.svg", time.Now().Format("20060102-150405"))
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	if err := w.writeSVG(f); err != nil {
+		f.Close()
+		return "", err
+	}
+	return name, f.Close()
+}
+
+// writeSVG draws the grid and its live cells as SVG. Every cell is one user
+// unit wide so the image scales to any resolution. On an unbounded grid the
+// image covers the live cells with a cell to spare wherever they have gone.
+func (w *World) writeSVG(out io.Writer) error {
+	bw := bufio.NewWriter(out)
+	cells := w.grid.Cells()
+	x0, y0, width, height := 0, 0, w.grid.Width, w.grid.Height
+	if w.grid.Boundary == life.Unbounded && len(cells) > 0 {
+		minX, minY, maxX, maxY := life.Bounds(cells)
+		x0, y0, width, height = minX-1, minY-1, maxX-minX+3, maxY-minY+3
+	}
+
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"%d %d %d %d\" width=\"%d\" height=\"%d\">\n",
+		x0, y0, width, height, width*w.tileSize, height*w.tileSize)
+	fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", x0, y0, width, height, svgColor(w.theme.Background))
+
+	// Sort the cells so the same generation always produces the same file
+	fmt.Fprintf(bw, "<g fill=\"%s\">\n", svgColor(w.theme.Cell))
+	for _, cell := range sortedCells(cells) {
+		if cell.X >= x0 && cell.X < x0+width && cell.Y >= y0 && cell.Y < y0+height {
+			writeSVGCell(bw, w.theme.Shape, cell)
+		}
+	}
+	fmt.Fprintln(bw, "</g>")
+
+	// Grid lines
+	fmt.Fprintf(bw, "<path stroke=\"%s\" stroke-width=\"%g\" d=\"", svgColor(w.theme.GridLines), 1/float64(w.tileSize))
+	for x := x0; x <= x0+width; x++ {
+		fmt.Fprintf(bw, "M%d %dV%d", x, y0, y0+height)
+	}
+	for y := y0; y <= y0+height; y++ {
+		fmt.Fprintf(bw, "M%d %dH%d", x0, y, x0+width)
+	}
+	fmt.Fprintln(bw, "\"/>")
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// writeSVGCell writes one live cell in the theme's shape
+func writeSVGCell(out io.Writer, shape cellShape, cell tile) {
+	const inset = cellGap / 2
+	switch shape {
+	case shapeCircle:
+		fmt.Fprintf(out, "<circle cx=\"%g\" cy=\"%g\" r=\"%g\"/>\n", float64(cell.X)+0.5, float64(cell.Y)+0.5, 0.5-inset)
+	case shapeRounded:
+		fmt.Fprintf(out, "<rect x=\"%g\" y=\"%g\" width=\"%g\" height=\"%g\" rx=\"%g\"/>\n",
+			float64(cell.X)+inset, float64(cell.Y)+inset, 1-cellGap, 1-cellGap, (1-cellGap)*cornerRadius)
+	default:
+		fmt.Fprintf(out, "<rect x=\"%d\" y=\"%d\" width=\"1\" height=\"1\"/>\n", cell.X, cell.Y)
+	}
+}
+
+// exportFrames simulates generations 0 to n and writes each one as an RLE
+// file. When path ends in .zip the frames are stored in a zip archive,
+// otherwise path is a directory that is created if needed.
+func (w *World) exportFrames(path string, n int) error {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		zw := zip.NewWriter(f)
+		err = w.writeFrames(n, func(name string) (io.Writer, error) {
+			return zw.Create(name)
+		})
+		if err == nil {
+			err = zw.Close()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+	var f *os.File
+	err := w.writeFrames(n, func(name string) (io.Writer, error) {
+		if f != nil {
+			if err := f.Close(); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		f, err = os.Create(filepath.Join(path, name))
+		return f, err
+	})
+	if f != nil {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// writeFrames advances the world n generations, writing every generation to
+// the writer returned by create for its frame name. RLE starts each pattern
+// at its own top left, so a #R line gives where that is on the grid and
+// moving patterns can be followed from frame to frame.
+func (w *World) writeFrames(n int, create func(name string) (io.Writer, error)) error {
+	for i := 0; i <= n; i++ {
+		if i > 0 {
+			w.SimulateWorld()
+		}
+		out, err := create(fmt.Sprintf("frame-%05d.rle", w.grid.Generation))
+		if err != nil {
+			return err
+		}
+		cells := w.grid.Cells()
+		if len(cells) > 0 {
+			minX, minY, _, _ := life.Bounds(cells)
+			if _, err := fmt.Fprintf(out, "#R %d %d\n", minX, minY); err != nil {
+				return err
+			}
+		}
+		name := fmt.Sprintf("Generation %d", w.grid.Generation)
+		if err := writeRLE(out, name, w.grid.RuleName(), cells, w.cellStates()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedCells returns cells in reading order, top row first
+func sortedCells(cells map[tile]struct{}) []tile {
+	sorted := make([]tile, 0, len(cells))
+	for cell := range cells {
+		sorted = append(sorted, cell)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Y != sorted[j].Y {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+	return sorted
+}
+
+// svgColor formats a color as an SVG hex color
+func svgColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
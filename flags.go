@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// config holds the options set on the command line
+type config struct {
+	width       int
+	height      int
+	tile        int
+	rule        string
+	speed       time.Duration
+	pattern     string
+	script      string
+	image       string
+	threshold   uint
+	background  string
+	random      bool
+	density     int
+	stillAfter  int
+	soup        string
+	theme       string
+	boundary    string
+	antialias   bool
+	keepRunning bool
+	profile     string
+
+	// configPath is the settings file, savedRule the rule stored in it
+	configPath string
+	savedRule  string
+	patternDir string
+	keys       map[string][]string
+	// stateColors replaces the themes' palettes for multi-state rules
+	stateColors []string
+
+	checkpoints     int
+	checkpointEvery int
+
+	exportFrames string
+	frames       int
+
+	headless      bool
+	bench         bool
+	search        int
+	ash           string
+	lifespans     bool
+	perturb       string
+	variants      int
+	terminal      bool
+	generations   int
+	out           string
+	seed          int64
+	http          string
+	grpc          string
+	pprof         string
+	populationLog string
+	host          string
+	join          string
+	name          string
+}
+
+// parseFlags reads the command line flags into a config, using the settings
+// file for anything not given on the command line
+func parseFlags() (config, error) {
+	var cfg config
+	flag.IntVar(&cfg.width, "width", gridWidth, "grid width in cells")
+	flag.IntVar(&cfg.height, "height", gridHeight, "grid height in cells")
+	flag.IntVar(&cfg.tile, "tile", tileSize, "size of a cell in pixels")
+	flag.StringVar(&cfg.rule, "rule", "", "rule in B/S notation or one of "+strings.Join(life.RuleNames(), ", ")+" (default "+life.Conway+" or the pattern's rule)")
+	flag.DurationVar(&cfg.speed, "speed", 300*time.Millisecond, "time between generations")
+	flag.StringVar(&cfg.pattern, "pattern", "", "RLE pattern file or built-in pattern name to load at start")
+	flag.StringVar(&cfg.script, "script", "", "Lua script run on the world after the starting pattern is placed, printing to standard output")
+	flag.StringVar(&cfg.image, "image", "", "PNG or JPEG image whose dark pixels seed the grid")
+	flag.UintVar(&cfg.threshold, "threshold", 128, "gray level (0-255) below which an image pixel is a live cell")
+	flag.StringVar(&cfg.background, "background", "", "PNG or JPEG image shown dimmed behind the grid")
+	flag.BoolVar(&cfg.random, "random", false, "start with a random soup")
+	flag.StringVar(&cfg.soup, "soup-symmetry", soupAsymmetric.String(), "symmetry of random soups: none, C2, C4, D2 or D8")
+	flag.IntVar(&cfg.density, "density", defaultDensity, fmt.Sprintf("percentage of cells alive in a random soup (%d-%d)", minDensity, maxDensity))
+	flag.IntVar(&cfg.stillAfter, "still-after", defaultStillAfter, fmt.Sprintf("generations without a change before a cell is muted as still with F8 (%d-%d)", minStillAfter, maxStillAfter))
+	flag.StringVar(&cfg.theme, "theme", themes[0].Name, "color theme: "+strings.Join(themeNames(), ", "))
+	flag.StringVar(&cfg.boundary, "boundary", life.Unbounded.String(), "grid edges: unbounded, bounded or torus")
+	flag.BoolVar(&cfg.antialias, "antialias", false, "smooth the edges of cells and lines")
+	flag.BoolVar(&cfg.keepRunning, "keep-running", false, "keep simulating when the world dies out or settles instead of pausing")
+	flag.StringVar(&cfg.profile, "profile", "default", "key profile: "+strings.Join(profileNames(), ", "))
+	flag.StringVar(&cfg.configPath, "config", defaultConfigPath(), "settings file, changes made in the settings menu are saved here")
+	flag.IntVar(&cfg.checkpoints, "checkpoints", 10, "number of checkpoints kept for rewinding")
+	flag.IntVar(&cfg.checkpointEvery, "checkpoint-every", 50, "generations between checkpoints")
+	flag.StringVar(&cfg.exportFrames, "export-frames", "", "write generations 0..-frames as RLE files to this directory or .zip and exit")
+	flag.IntVar(&cfg.frames, "frames", 100, "number of generations written by -export-frames")
+	flag.BoolVar(&cfg.headless, "headless", false, "run -generations generations without a window, write the result as RLE and exit")
+	flag.BoolVar(&cfg.bench, "bench", false, "time the engines on standard workloads, print generations per second and allocations and exit")
+	flag.IntVar(&cfg.search, "search", 0, "run this many random soups from -seed without a window, census the ash of each, write the object counts to -out and exit")
+	flag.StringVar(&cfg.ash, "ash", "", "write every object -search finds in each soup with where it is to this file")
+	flag.BoolVar(&cfg.lifespans, "lifespans", false, "run the -pattern and variants of it with one cell toggled until each settles, write their lifespans to -out and exit")
+	flag.StringVar(&cfg.perturb, "perturb", "", "cells toggled by -lifespans, such as \"0,0 3,-1\" from the pattern's top left (default every cell in and around the pattern)")
+	flag.IntVar(&cfg.variants, "variants", 0, "toggle this many random cells in and around the pattern for -lifespans instead, using -seed")
+	flag.BoolVar(&cfg.terminal, "tui", false, "play in the terminal with block characters instead of a window")
+	flag.IntVar(&cfg.generations, "generations", 100, "number of generations simulated by -headless")
+	flag.StringVar(&cfg.out, "out", "", "file written by -headless, -search and -lifespans (default standard output)")
+	flag.Int64Var(&cfg.seed, "seed", 0, "random seed for -random soups, 0 picks one from the clock, and the first seed of -search, which starts from 1 for 0")
+	flag.StringVar(&cfg.http, "http", "", "serve an HTTP API for the grid on this address, such as localhost:8080")
+	flag.StringVar(&cfg.grpc, "grpc", "", "serve the gRPC control service of proto/gameoflife.proto on this address, such as localhost:9090")
+	flag.StringVar(&cfg.populationLog, "log-population", "", "append the generation, population, births and deaths of every generation to this CSV file")
+	flag.StringVar(&cfg.pprof, "pprof", "", "serve Go profiles at /debug/pprof/ on this address, such as localhost:6060")
+	flag.StringVar(&cfg.host, "host", "", "share the world with players who -join this address, such as :7000")
+	flag.StringVar(&cfg.join, "join", "", "join the world shared by a -host at this address, such as example.com:7000")
+	flag.StringVar(&cfg.name, "name", defaultPlayerName(), "name your cells are shown under in a shared world")
+	flag.StringVar(&cfg.patternDir, "patterns", defaultPatternDir(), "directory of user RLE patterns listed in the pattern picker")
+	// In a browser the flags come from the page URL instead
+	if err := flag.CommandLine.Parse(append(os.Args[1:], browserArgs()...)); err != nil {
+		return cfg, err
+	}
+	if cfg.ash != "" && cfg.search == 0 {
+		return cfg, errors.New("-ash needs -search")
+	}
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	s, err := loadSettings(cfg.configPath)
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.applySettings(s, set)
+}
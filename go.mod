@@ -1,6 +1,6 @@
 module github.com/afroash/gameoflife
 
-go 1.22.4
+go 1.24
 
 require github.com/hajimehoshi/ebiten/v2 v2.8.5
 
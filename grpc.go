@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "github.com/afroash/gameoflife/proto"
+)
+
+// grpcMaxMessage is the largest request accepted, the default of gRPC
+// servers
+const grpcMaxMessage = 4 << 20
+
+// grpcMaxSteps is the most generations one Step call runs, as they run on
+// the game loop and hold up the window
+const grpcMaxSteps = 10000
+
+// gRPC status codes returned by the control service
+const (
+	grpcOK                = 0
+	grpcInvalidArgument   = 3
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcInternal          = 13
+)
+
+// grpcError is a call that failed with a status other than internal
+type grpcError struct {
+	code int
+	msg  string
+}
+
+func (e *grpcError) Error() string {
+	return e.msg
+}
+
+// startGRPCServer serves the control service of proto/gameoflife.proto on
+// addr in the background, once it is listening. Its work goes to the game
+// loop through s, which is started when the HTTP API isn't served as well.
+func startGRPCServer(addr string, s *apiServer) (*apiServer, error) {
+	if s == nil {
+		s = &apiServer{work: make(chan func(g *Game))}
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("gRPC API: %w", err)
+	}
+	srv := &http.Server{Handler: s.grpcHandler(), Protocols: new(http.Protocols)}
+	// Clients without TLS speak HTTP/2 from the start
+	srv.Protocols.SetUnencryptedHTTP2(true)
+	go func() {
+		log.Printf("serving the gRPC API on %s", l.Addr())
+		if err := srv.Serve(l); err != nil {
+			log.Printf("gRPC API: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// grpcHandler returns the methods of the control service
+func (s *apiServer) grpcHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /gameoflife.GameOfLife/Step", s.grpcUnary(s.grpcStep))
+	mux.HandleFunc("POST /gameoflife.GameOfLife/LoadPattern", s.grpcUnary(s.grpcLoadPattern))
+	mux.HandleFunc("POST /gameoflife.GameOfLife/GetState", s.grpcUnary(s.grpcGetState))
+	mux.HandleFunc("POST /gameoflife.GameOfLife/Subscribe", s.grpcSubscribe)
+	mux.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+		startGRPCResponse(rw)
+		finishGRPCResponse(rw, &grpcError{grpcUnimplemented, "unknown method " + r.URL.Path})
+	})
+	return mux
+}
+
+// grpcUnary returns a handler for a method that answers one request with
+// one response
+func (s *apiServer) grpcUnary(call func(req []byte) ([]byte, error)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if !checkGRPCRequest(rw, r) {
+			return
+		}
+		req, err := readGRPCMessage(r.Body)
+		var resp []byte
+		if err == nil {
+			resp, err = call(req)
+		}
+		startGRPCResponse(rw)
+		if err == nil {
+			err = writeGRPCMessage(rw, resp)
+		}
+		finishGRPCResponse(rw, err)
+	}
+}
+
+// grpcStep advances the simulation, stepping like the step key does
+func (s *apiServer) grpcStep(data []byte) ([]byte, error) {
+	var req pb.StepRequest
+	if err := req.Unmarshal(data); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	if req.Generations < 1 || req.Generations > grpcMaxSteps {
+		return nil, &grpcError{grpcInvalidArgument, fmt.Sprintf("generations must be from 1 to %d", grpcMaxSteps)}
+	}
+	var state *pb.State
+	s.do(func(g *Game) {
+		for range req.Generations {
+			g.world.SimulateWorld()
+		}
+		state = grpcState(g.world)
+	})
+	return state.Marshal(), nil
+}
+
+// grpcLoadPattern replaces the cells with a pattern centred on the grid, as
+// an edit that can be undone, switching to the pattern's rule if it has one
+func (s *apiServer) grpcLoadPattern(data []byte) ([]byte, error) {
+	var req pb.LoadPatternRequest
+	if err := req.Unmarshal(data); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	p, err := loadBuiltinPattern(req.Pattern)
+	if err != nil {
+		if p, err = parseRLE(strings.NewReader(req.Pattern)); err != nil {
+			return nil, &grpcError{grpcInvalidArgument, err.Error()}
+		}
+	}
+	var state *pb.State
+	s.do(func(g *Game) {
+		w := g.world
+		w.isSimulating = false
+		w.leaveGameModes()
+		if p.rule != "" {
+			if err = w.grid.SetRule(p.rule); err != nil {
+				return
+			}
+		}
+		origin := w.patternOrigin(p)
+		w.beginEdit()
+		w.setCells(make(map[tile]struct{}))
+		for _, cell := range p.cells {
+			w.setCell(tile{X: cell.X + origin.X, Y: cell.Y + origin.Y}, true)
+		}
+		w.commitEdit()
+		state = grpcState(w)
+	})
+	if err != nil {
+		return nil, &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	return state.Marshal(), nil
+}
+
+// grpcGetState returns the generation and its live cells
+func (s *apiServer) grpcGetState(data []byte) ([]byte, error) {
+	var req pb.GetStateRequest
+	if err := req.Unmarshal(data); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	var state *pb.State
+	s.do(func(g *Game) { state = grpcState(g.world) })
+	return state.Marshal(), nil
+}
+
+// grpcSubscribe streams the grid followed by the changes to it, as the
+// websocket at /stream does, until the client goes away
+func (s *apiServer) grpcSubscribe(rw http.ResponseWriter, r *http.Request) {
+	if !checkGRPCRequest(rw, r) {
+		return
+	}
+	var req pb.SubscribeRequest
+	data, err := readGRPCMessage(r.Body)
+	if err == nil {
+		err = req.Unmarshal(data)
+	}
+	startGRPCResponse(rw)
+	if err != nil {
+		finishGRPCResponse(rw, &grpcError{grpcInvalidArgument, err.Error()})
+		return
+	}
+	flush := http.NewResponseController(rw).Flush
+	if err := flush(); err != nil {
+		return
+	}
+
+	c := &streamClient{send: make(chan []byte, streamBuffer), gone: make(chan struct{}), encode: encodeChange}
+	s.do(func(g *Game) { s.stream.add(c, g.world) })
+	defer s.do(func(g *Game) { s.stream.remove(c) })
+	for {
+		select {
+		case data := <-c.send:
+			if err := writeGRPCMessage(rw, data); err != nil {
+				return
+			}
+			if err := flush(); err != nil {
+				return
+			}
+		case <-c.gone:
+			finishGRPCResponse(rw, &grpcError{grpcResourceExhausted, "fell too far behind the changes"})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// grpcState returns the world's generation and its live cells
+func grpcState(w *World) *pb.State {
+	cells := sortedCells(w.grid.Cells())
+	state := &pb.State{
+		Generation: int64(w.grid.Generation),
+		Population: int64(len(cells)),
+		Rule:       w.grid.RuleName(),
+		Running:    w.isSimulating,
+		Cells:      make([]pb.Cell, 0, len(cells)),
+	}
+	for _, cell := range cells {
+		state.Cells = append(state.Cells, pb.Cell{X: int64(cell.X), Y: int64(cell.Y)})
+	}
+	return state
+}
+
+// encodeChange encodes a stream message as the Change a subscriber is sent
+func encodeChange(msg streamMessage) ([]byte, error) {
+	change := pb.Change{
+		Generation: int64(msg.Generation),
+		Reset:      msg.Reset,
+		Born:       make([]pb.Cell, 0, len(msg.Born)),
+		Died:       make([]pb.Cell, 0, len(msg.Died)),
+	}
+	for _, c := range msg.Born {
+		change.Born = append(change.Born, pb.Cell{X: int64(c[0]), Y: int64(c[1])})
+	}
+	for _, c := range msg.Died {
+		change.Died = append(change.Died, pb.Cell{X: int64(c[0]), Y: int64(c[1])})
+	}
+	return change.Marshal(), nil
+}
+
+// checkGRPCRequest answers requests that aren't gRPC with an HTTP error
+// and reports whether the request is gRPC
+func checkGRPCRequest(rw http.ResponseWriter, r *http.Request) bool {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(rw, "expected a gRPC request", http.StatusUnsupportedMediaType)
+		return false
+	}
+	return true
+}
+
+// readGRPCMessage reads the one message of a request. A message is sent
+// as a compressed flag and a four byte length before its bytes.
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, fmt.Sprintf("reading the request: %v", err)}
+	}
+	if prefix[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed requests aren't supported"}
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if n > grpcMaxMessage {
+		return nil, &grpcError{grpcResourceExhausted, fmt.Sprintf("request of %d bytes is over the limit of %d", n, grpcMaxMessage)}
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, fmt.Sprintf("reading the request: %v", err)}
+	}
+	return data, nil
+}
+
+// writeGRPCMessage sends a message of the response, uncompressed
+func writeGRPCMessage(w io.Writer, data []byte) error {
+	prefix := [5]byte{0}
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// startGRPCResponse sends the headers of a response, its status follows
+// the messages in the trailers
+func startGRPCResponse(rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", "application/grpc")
+	rw.WriteHeader(http.StatusOK)
+}
+
+// finishGRPCResponse sets the trailers giving the status of a call, OK
+// when err is nil
+func finishGRPCResponse(rw http.ResponseWriter, err error) {
+	code, msg := grpcOK, ""
+	var e *grpcError
+	switch {
+	case errors.As(err, &e):
+		code, msg = e.code, e.msg
+	case err != nil:
+		code, msg = grpcInternal, err.Error()
+	}
+	rw.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if msg != "" {
+		rw.Header().Set(http.TrailerPrefix+"Grpc-Message", grpcEscape(msg))
+	}
+}
+
+// grpcEscape percent-encodes a status message as gRPC asks, leaving
+// printable ASCII other than % as it is
+func grpcEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
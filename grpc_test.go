@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// TestGRPCInterop plays requests recorded from grpc-go's client to the
+// control service and compares the answers with what grpc-go's server
+// sends for the same states, byte for byte
+func TestGRPCInterop(t *testing.T) {
+	w := NewWorld(10, 10, 10, life.Rule{})
+	if err := w.grid.SetRule(life.Conway); err != nil {
+		t.Fatal(err)
+	}
+	for x := 1; x <= 3; x++ {
+		w.grid.Set(tile{X: x, Y: 1}, true)
+	}
+	s := &apiServer{work: make(chan func(g *Game))}
+	g := &Game{world: w}
+	go func() {
+		for f := range s.work {
+			f(g)
+		}
+	}()
+	defer close(s.work)
+
+	// A horizontal blinker, then the vertical one a step turns it into
+	for _, call := range []struct{ method, file string }{
+		{"GetState", "get_state"},
+		{"Step", "step"},
+	} {
+		req, err := os.ReadFile(filepath.Join("testdata", "grpc", call.file+".request"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", "grpc", call.file+".response"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest("POST", "/gameoflife.GameOfLife/"+call.method, bytes.NewReader(req))
+		// The headers grpc-go's client sends, other than the HTTP/2 ones
+		r.Header.Set("Content-Type", "application/grpc")
+		r.Header.Set("Te", "trailers")
+		rec := httptest.NewRecorder()
+		s.grpcHandler().ServeHTTP(rec, r)
+
+		resp := rec.Result()
+		got, _ := io.ReadAll(resp.Body)
+		if ct := resp.Header.Get("Content-Type"); ct != "application/grpc" {
+			t.Errorf("%s: content type %q, want application/grpc", call.method, ct)
+		}
+		if status := resp.Trailer.Get("Grpc-Status"); status != "0" {
+			t.Errorf("%s: status %q, %s, want 0", call.method, status, resp.Trailer.Get("Grpc-Message"))
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got % x, want % x", call.method, got, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// runHeadless advances the world n generations without opening a window and
+// writes the last one as RLE to path, or to standard output when path is
+// empty
+func (w *World) runHeadless(n int, path string) error {
+	for range n {
+		w.SimulateWorld()
+	}
+	name := fmt.Sprintf("Generation %d, population %d", w.grid.Generation, w.grid.Population())
+	if path == "" {
+		return writeRLE(os.Stdout, name, w.grid.RuleName(), w.grid.Cells(), w.cellStates())
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeRLE(f, name, w.grid.RuleName(), w.grid.Cells(), w.cellStates()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
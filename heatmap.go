@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// heatmap counts how often each cell has changed state during a run
+type heatmap struct {
+	visible bool
+	counts  map[tile]int
+	hottest int
+}
+
+// record adds the births and deaths between two generations
+func (h *heatmap) record(previous, next map[tile]struct{}) {
+	if h.counts == nil {
+		h.counts = make(map[tile]int)
+	}
+	bump := func(cell tile) {
+		h.counts[cell]++
+		h.hottest = max(h.hottest, h.counts[cell])
+	}
+	for cell := range previous {
+		if _, survived := next[cell]; !survived {
+			bump(cell)
+		}
+	}
+	for cell := range next {
+		if _, wasAlive := previous[cell]; !wasAlive {
+			bump(cell)
+		}
+	}
+}
+
+// clear forgets all recorded activity
+func (h *heatmap) clear() {
+	h.counts = nil
+	h.hottest = 0
+}
+
+// drawHeatmap shades every cell that has changed by how often it changed
+// relative to the most active cell
+func (w *World) drawHeatmap(screen *ebiten.Image) {
+	if !w.heatmap.visible || w.heatmap.hottest == 0 {
+		return
+	}
+	size := float32(w.tileSize)
+	for cell, n := range w.heatmap.counts {
+		if !w.onScreen(cell) {
+			continue
+		}
+		alpha := uint8(40 + 180*n/w.heatmap.hottest)
+		x, y := w.cellToScreen(cell.X, cell.Y)
+		vector.DrawFilledRect(screen, x, y, size, size, fade(w.theme.Heat, alpha), w.antialias)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// helpLines returns one line per control, built from the binding table
+func helpLines() []string {
+	var lines []string
+	for _, b := range bindings {
+		var keys []string
+		for _, k := range b.keys {
+			keys = append(keys, k.String())
+		}
+		lines = append(lines, fmt.Sprintf("%-22s %s", strings.Join(keys, " / "), b.help))
+	}
+	for _, m := range mouseHelp {
+		lines = append(lines, fmt.Sprintf("%-22s %s", m[0], m[1]))
+	}
+	for _, c := range commands {
+		lines = append(lines, fmt.Sprintf("%-22s %s", strings.TrimSpace(":"+c.name+" "+c.usage), c.help))
+	}
+	return lines
+}
+
+// handleHelp closes the help overlay on Esc or the keys bound to help
+func (g *Game) handleHelp() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || actionPressed("help") {
+		g.help = false
+	}
+}
+
+// drawHelp lists the controls over the grid, in as many columns as needed
+// to fit the screen
+func (g *Game) drawHelp(screen *ebiten.Image) {
+	if !g.help {
+		return
+	}
+	const margin = 10
+	w := g.world
+	vector.DrawFilledRect(screen, 0, float32(w.gridTop), float32(w.screenWidth), float32(w.screenHeight-w.gridTop), overlayBackground, false)
+
+	lines := helpLines()
+	rows := max((w.screenHeight-w.gridTop-2*margin)/charHeight, 1)
+	columnWidth := 0
+	for _, line := range lines {
+		columnWidth = max(columnWidth, len(line)*charWidth+2*margin)
+	}
+	for i := 0; i < len(lines); i += rows {
+		column := lines[i:min(i+rows, len(lines))]
+		x := margin + i/rows*columnWidth
+		ebitenutil.DebugPrintAt(screen, strings.Join(column, "\n"), x, w.gridTop+margin)
+	}
+}
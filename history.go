@@ -0,0 +1,93 @@
+package main
+
+// maxHistory is the number of edits that can be undone
+const maxHistory = 100
+
+// cellChange is the state of a cell before and after an edit
+type cellChange struct {
+	before, after bool
+}
+
+// edit is one undoable change to the grid, such as a drawing stroke
+type edit map[tile]cellChange
+
+// history records edits made to the grid. Simulation steps are not
+// recorded, undoing an edit only restores the cells it touched.
+type history struct {
+	pending edit
+	undo    []edit
+	redo    []edit
+}
+
+// beginEdit starts recording cell changes into a new edit
+func (w *World) beginEdit() {
+	if w.history.pending == nil {
+		w.history.pending = make(edit)
+	}
+}
+
+// commitEdit stores the pending edit on the undo stack
+func (w *World) commitEdit() {
+	e := w.history.pending
+	w.history.pending = nil
+
+	// Drop cells that ended up back where they started
+	for cell, c := range e {
+		if c.before == c.after {
+			delete(e, cell)
+		}
+	}
+	if len(e) == 0 {
+		return
+	}
+	w.recordMacroEdit(e, false)
+	w.history.undo = append(w.history.undo, e)
+	if len(w.history.undo) > maxHistory {
+		w.history.undo = w.history.undo[1:]
+	}
+	w.history.redo = nil
+}
+
+// recordChange notes a cell changing state if an edit is being recorded
+func (w *World) recordChange(cell tile, alive bool) {
+	if w.history.pending == nil {
+		return
+	}
+	c, seen := w.history.pending[cell]
+	if !seen {
+		c.before = w.grid.Get(cell)
+	}
+	c.after = alive
+	w.history.pending[cell] = c
+}
+
+// undo reverts the most recent edit
+func (w *World) undo() {
+	w.commitEdit()
+	n := len(w.history.undo)
+	if n == 0 {
+		return
+	}
+	e := w.history.undo[n-1]
+	w.history.undo = w.history.undo[:n-1]
+	for cell, c := range e {
+		w.setCell(cell, c.before)
+	}
+	w.recordMacroEdit(e, true)
+	w.history.redo = append(w.history.redo, e)
+}
+
+// redo applies the most recently undone edit again
+func (w *World) redo() {
+	n := len(w.history.redo)
+	if n == 0 {
+		return
+	}
+	e := w.history.redo[n-1]
+	w.history.redo = w.history.redo[:n-1]
+	for cell, c := range e {
+		w.setCell(cell, c.after)
+	}
+	w.recordMacroEdit(e, false)
+	w.history.undo = append(w.history.undo, e)
+}
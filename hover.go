@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// drawHover outlines the cell under the cursor so it's clear which cell a
+// click will change
+func (w *World) drawHover(screen *ebiten.Image) {
+	cell, ok := w.screenToCell(ebiten.CursorPosition())
+	if !ok {
+		return
+	}
+	x, y := w.cellToScreen(cell.X, cell.Y)
+	size := float32(w.tileSize)
+	vector.StrokeRect(screen, x, y, size, size, 1, fade(w.theme.Cell, 160), w.antialias)
+}
+
+// drawCrosshair shades the row and column of the cell being pointed at
+func (w *World) drawCrosshair(screen *ebiten.Image) {
+	if !w.crosshair {
+		return
+	}
+	cell, ok := w.pointedCell()
+	if !ok {
+		return
+	}
+	x, y := w.cellToScreen(cell.X, cell.Y)
+	size := float32(w.tileSize)
+	guide := fade(w.theme.Accent, 50)
+	vector.DrawFilledRect(screen, x, float32(w.gridTop), size, float32(w.screenHeight-w.gridTop), guide, w.antialias)
+	vector.DrawFilledRect(screen, 0, y, float32(w.screenWidth), size, guide, w.antialias)
+}
+
+// rulerTickLength is the length in pixels of the ruler's tick marks
+const rulerTickLength = 8
+
+// drawRuler draws tick marks every ten cells along the top and left edges
+// of the grid, labelled with their coordinate when there is room
+func (w *World) drawRuler(screen *ebiten.Image) {
+	if !w.ruler {
+		return
+	}
+	tick := w.theme.Accent
+	top, left := float32(w.gridTop), float32(0)
+	// Labels need about five characters between ticks
+	labelled := 10*w.tileSize >= 5*charWidth
+
+	firstX := floorDiv(w.camera.x, 10*w.tileSize) * 10
+	for i := firstX; ; i += 10 {
+		x, _ := w.cellToScreen(i, 0)
+		if x > float32(w.screenWidth) {
+			break
+		}
+		vector.StrokeLine(screen, x, top, x, top+rulerTickLength, 2, tick, w.antialias)
+		if labelled {
+			ebitenutil.DebugPrintAt(screen, strconv.Itoa(i), int(x)+2, w.gridTop+rulerTickLength-4)
+		}
+	}
+
+	firstY := floorDiv(w.camera.y, 10*w.tileSize) * 10
+	for i := firstY; ; i += 10 {
+		_, y := w.cellToScreen(0, i)
+		if y > float32(w.screenHeight) {
+			break
+		}
+		vector.StrokeLine(screen, left, y, left+rulerTickLength, y, 2, tick, w.antialias)
+		if labelled {
+			ebitenutil.DebugPrintAt(screen, strconv.Itoa(i), rulerTickLength+2, int(y)-charHeight/2)
+		}
+	}
+}
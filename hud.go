@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Size of a character of the debug font in pixels
+const (
+	charWidth  = 6
+	charHeight = 16
+)
+
+// hudItem is an entry on the status line, drawn over a box of the
+// highlight color when it has one
+type hudItem struct {
+	text      string
+	highlight color.Color
+}
+
+// hudItems returns the entries of the status line
+func (w *World) hudItems() []hudItem {
+	items := []hudItem{{text: fmt.Sprintf("Generation: %d", w.grid.Generation)}}
+
+	// An extinct world is highlighted
+	var highlight color.Color
+	if w.grid.Population() == 0 {
+		highlight = w.theme.Warning
+	}
+	items = append(items, hudItem{fmt.Sprintf("Population: %d", w.grid.Population()), highlight})
+	if w.stats.Generation == w.grid.Generation && w.grid.Generation > 0 {
+		items = append(items, hudItem{text: fmt.Sprintf("Heat: %d", w.stats.Heat())})
+	}
+	if w.stopped != "" && !w.isSimulating {
+		items = append(items, hudItem{w.stopped, w.theme.Warning})
+	} else if w.grid.Population() == 0 && w.diedOut > 0 {
+		items = append(items, hudItem{text: fmt.Sprintf("Died out at generation %d", w.diedOut)})
+	}
+
+	// The cell under the cursor
+	if cell, ok := w.pointedCell(); ok {
+		state := "dead"
+		if w.grid.Get(cell) {
+			state = "alive"
+		}
+		items = append(items, hudItem{text: fmt.Sprintf("Cell: %d,%d %s", cell.X, cell.Y, state)})
+	}
+
+	switch p := w.cycle.period; {
+	case p == 1 && w.grid.Population() > 0:
+		items = append(items, hudItem{text: "Still life"})
+	case p > 1:
+		items = append(items, hudItem{text: fmt.Sprintf("Period %d, %d cells oscillating, heat %.1f", p, len(w.cycle.oscillating), w.sparkline.heat(p))})
+	case w.cycle.moving > 0:
+		items = append(items, hudItem{text: fmt.Sprintf("Moving %d,%d every %d generations",
+			w.cycle.offset.X, w.cycle.offset.Y, w.cycle.moving)})
+	}
+	if w.census != nil && len(w.census.objects) > 0 {
+		items = append(items, hudItem{text: fmt.Sprintf("Ash: %s (%s)", w.census.summary(3), actionKey("census"))})
+	}
+	if w.versus != nil {
+		items = append(items, w.versusHUD()...)
+	}
+	if w.puzzle != nil {
+		items = append(items, hudItem{w.puzzleText(), w.puzzleHighlight()})
+	}
+	if w.diff != nil {
+		items = append(items, hudItem{w.diff.diffText(), w.theme.Accent})
+	}
+	if w.bounds.visible {
+		items = append(items, hudItem{text: w.boundsText()})
+	}
+	if w.symmetry != symmetryNone {
+		items = append(items, hudItem{text: fmt.Sprintf("Symmetry: %s", w.symmetry)})
+	}
+	if w.colorMode != colorPlain {
+		items = append(items, hudItem{text: fmt.Sprintf("Colors: %s", w.colorMode)})
+	}
+	if m := w.macros.recording; m != nil {
+		items = append(items, hudItem{fmt.Sprintf("Recording macro: %s (%s to stop)", m.name, actionKey("record-macro")), w.theme.Warning})
+	}
+	if w.session != nil {
+		items = append(items, hudItem{text: w.session.status()})
+	}
+	if w.stamp != nil {
+		// Every stamp can be turned and mirrored before it is placed
+		name := w.stamp.name
+		if name == "" {
+			name = "clipboard"
+		}
+		items = append(items, hudItem{text: fmt.Sprintf("Stamp: %s (%s turn, %s/%s mirror)", name,
+			actionKey("rotate"), actionKey("flip-horizontal"), actionKey("flip-vertical"))})
+	}
+	return items
+}
+
+// drawHUD draws the status line in the bar above the grid
+func (w *World) drawHUD(screen *ebiten.Image) {
+	x := 4
+	for _, item := range w.hudItems() {
+		x = drawHUDText(screen, x, item.text, item.highlight)
+	}
+	w.drawSparkline(screen)
+}
+
+// drawHUDText prints text at x on the status line, over a highlight box when
+// one is given, and returns the x position for the next item
+func drawHUDText(screen *ebiten.Image, x int, text string, highlight color.Color) int {
+	width := len(text) * charWidth
+	if highlight != nil {
+		vector.DrawFilledRect(screen, float32(x-2), 2, float32(width+4), charHeight, highlight, false)
+	}
+	ebitenutil.DebugPrintAt(screen, text, x, 2)
+	return x + width + 3*charWidth
+}
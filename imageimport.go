@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+)
+
+// decodeImage reads a PNG or JPEG file
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+// loadImagePattern reads a PNG or JPEG image and turns its dark pixels into
+// live cells. Images larger than maxWidth x maxHeight are scaled down by
+// averaging blocks of pixels so the result fits the grid.
+func loadImagePattern(path string, maxWidth, maxHeight int, threshold uint8) (*pattern, error) {
+	img, err := decodeImage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	// Pixels per cell, rounded up so the whole image fits
+	scale := max(1,
+		(bounds.Dx()+maxWidth-1)/maxWidth,
+		(bounds.Dy()+maxHeight-1)/maxHeight,
+	)
+
+	p := &pattern{
+		name:   filepath.Base(path),
+		width:  (bounds.Dx() + scale - 1) / scale,
+		height: (bounds.Dy() + scale - 1) / scale,
+	}
+	for y := 0; y < p.height; y++ {
+		for x := 0; x < p.width; x++ {
+			block := image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale).Add(bounds.Min).Intersect(bounds)
+			if averageGray(img, block) < threshold {
+				p.cells = append(p.cells, tile{X: x, Y: y})
+			}
+		}
+	}
+	return p, nil
+}
+
+// averageGray returns the mean luminance of the pixels in r. Transparent
+// pixels count as white so they stay dead.
+func averageGray(img image.Image, r image.Rectangle) uint8 {
+	var sum, n int
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := img.At(x, y)
+			_, _, _, a := c.RGBA()
+			gray := int(color.GrayModel.Convert(c).(color.Gray).Y)
+			// Colors are alpha-premultiplied, so blending with a white
+			// background only needs the uncovered part added
+			sum += gray + 255*int(0xffff-a)/0xffff
+			n++
+		}
+	}
+	if n == 0 {
+		return 255
+	}
+	return uint8(sum / n)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// layerKey is everything that moves or restyles the whole view. When any of
+// it changes the cached layers are drawn again from scratch.
+type layerKey struct {
+	width, height    int
+	tileSize         int
+	cameraX, cameraY int
+	theme            *Theme
+	showGrid         bool
+	antialias        bool
+}
+
+// worldLayers caches the grid lines and live cells in offscreen images so a
+// frame only redraws the cells that changed since the last one
+type worldLayers struct {
+	key   layerKey
+	grid  *ebiten.Image
+	cells *ebiten.Image
+
+	// drawn is the color of every cell on the cell layer
+	drawn map[tile]color.RGBA
+}
+
+// layerKey returns the current view settings the layers depend on
+func (w *World) layerKey() layerKey {
+	return layerKey{
+		width:     w.screenWidth,
+		height:    w.screenHeight,
+		tileSize:  w.tileSize,
+		cameraX:   w.camera.x,
+		cameraY:   w.camera.y,
+		theme:     w.theme,
+		showGrid:  w.showGrid,
+		antialias: w.antialias,
+	}
+}
+
+// updateLayers starts the layers again when the view has changed
+func (w *World) updateLayers() {
+	l := &w.layers
+	key := w.layerKey()
+	if l.grid != nil && l.key == key {
+		return
+	}
+	if l.grid == nil || l.key.width != key.width || l.key.height != key.height {
+		if l.grid != nil {
+			l.grid.Deallocate()
+			l.cells.Deallocate()
+		}
+		l.grid = ebiten.NewImage(max(key.width, 1), max(key.height, 1))
+		l.cells = ebiten.NewImage(max(key.width, 1), max(key.height, 1))
+	}
+	l.key = key
+	l.grid.Clear()
+	w.DrawWorld(l.grid)
+	l.cells.Clear()
+	l.drawn = make(map[tile]color.RGBA)
+}
+
+// drawGridLayer draws the cached grid lines
+func (w *World) drawGridLayer(screen *ebiten.Image) {
+	w.updateLayers()
+	screen.DrawImage(w.layers.grid, nil)
+}
+
+// drawCellLayer brings the cached cells up to date and draws them. While
+// births and deaths are fading every cell is drawn directly instead.
+func (w *World) drawCellLayer(screen *ebiten.Image) {
+	if w.transitionProgress() < 1 {
+		w.drawLiveCells(screen)
+		return
+	}
+	w.updateLayers()
+	l := &w.layers
+
+	// Clear cells that died or changed color, then draw the new ones
+	for cell, c := range l.drawn {
+		if w.grid.Get(cell) && w.cellColor(cell) == c {
+			continue
+		}
+		w.clearLayerCell(cell)
+		delete(l.drawn, cell)
+	}
+	for cell := range w.grid.Cells() {
+		if _, ok := l.drawn[cell]; ok || !w.onScreen(cell) {
+			continue
+		}
+		c := w.cellColor(cell)
+		w.drawCell(l.cells, cell.X, cell.Y, c)
+		l.drawn[cell] = c
+	}
+	screen.DrawImage(l.cells, nil)
+}
+
+// clearLayerCell makes a cell on the cell layer transparent again
+func (w *World) clearLayerCell(cell tile) {
+	sx, sy := w.cellToScreen(cell.X, cell.Y)
+	x, y := int(sx), int(sy)
+	rect := image.Rect(x, y, x+w.tileSize, y+w.tileSize).Intersect(w.layers.cells.Bounds())
+	if rect.Empty() {
+		return
+	}
+	w.layers.cells.SubImage(rect).(*ebiten.Image).Clear()
+}
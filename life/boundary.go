@@ -0,0 +1,119 @@
+package life
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Boundary decides what happens to cells at the edge of the grid
+type Boundary int
+
+const (
+	// Unbounded lets patterns grow past the grid forever
+	Unbounded Boundary = iota
+	// Bounded kills cells that leave the grid
+	Bounded
+	// Torus wraps the edges round so the grid has no edge
+	Torus
+	// Boundaries is the number of boundary modes
+	Boundaries
+)
+
+// String returns the name of the boundary mode
+func (b Boundary) String() string {
+	switch b {
+	case Bounded:
+		return "bounded"
+	case Torus:
+		return "torus"
+	}
+	return "unbounded"
+}
+
+// Next returns the boundary mode after b, wrapping round to unbounded
+func (b Boundary) Next() Boundary {
+	return (b + 1) % Boundaries
+}
+
+// ParseBoundary parses a boundary mode name
+func ParseBoundary(s string) (Boundary, error) {
+	for b := Unbounded; b < Boundaries; b++ {
+		if strings.EqualFold(s, b.String()) {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown boundary %q, expected unbounded, bounded or torus", s)
+}
+
+// InGrid reports whether a cell is inside the grid
+func (g *Grid) InGrid(c Cell) bool {
+	return c.X >= 0 && c.X < g.Width && c.Y >= 0 && c.Y < g.Height
+}
+
+// Wrap maps a cell onto the grid when its edges wrap round
+func (g *Grid) Wrap(c Cell) Cell {
+	if g.Boundary != Torus {
+		return c
+	}
+	return Cell{
+		X: ((c.X % g.Width) + g.Width) % g.Width,
+		Y: ((c.Y % g.Height) + g.Height) % g.Height,
+	}
+}
+
+// neighbors returns the neighbors of a cell in reading order. On a torus
+// less than three cells across, the neighbors on either side wrap onto the
+// same cell or onto the cell itself, and counted is false for those so no
+// cell counts twice or as its own neighbor.
+func (g *Grid) neighbors(c Cell) (cells [8]Cell, counted [8]bool) {
+	small := g.Boundary == Torus && (g.Width < 3 || g.Height < 3)
+	k := 0
+	for j := -1; j <= 1; j++ {
+		for i := -1; i <= 1; i++ {
+			if i == 0 && j == 0 {
+				continue
+			}
+			n := g.Wrap(Cell{X: c.X + i, Y: c.Y + j})
+			cells[k], counted[k] = n, !small || n != c && !slices.Contains(cells[:k], n)
+			k++
+		}
+	}
+	return cells, counted
+}
+
+// confine moves cells set outside the grid back onto it, or removes them
+// when the grid is bounded. The cells and their states go into new maps, so
+// one returned by Cells earlier is left as it was.
+func (g *Grid) confine() {
+	if g.Boundary == Unbounded {
+		return
+	}
+	outside := false
+	for cell := range g.cells {
+		if !g.InGrid(cell) {
+			outside = true
+			break
+		}
+	}
+	if !outside {
+		return
+	}
+	cells := make(map[Cell]struct{}, len(g.cells))
+	var states map[Cell]State
+	for cell := range g.cells {
+		// Wrap leaves cells alone on a bounded grid
+		if g.Boundary == Bounded && !g.InGrid(cell) {
+			continue
+		}
+		c := g.Wrap(cell)
+		cells[c] = struct{}{}
+		if s, ok := g.states[cell]; ok {
+			if states == nil {
+				states = make(map[Cell]State)
+			}
+			states[c] = s
+		}
+	}
+	g.cells, g.states = cells, states
+}
@@ -0,0 +1,51 @@
+package life
+
+import "testing"
+
+// torus returns an empty Conway grid whose edges wrap
+func torus(t *testing.T, width, height int) *Grid {
+	rule, err := ParseRule(Conway)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(width, height, rule)
+	g.Boundary = Torus
+	return g
+}
+
+func TestSmallTorus(t *testing.T) {
+	// On a torus two cells wide the cell to the left and the one to the
+	// right are the same cell, and must only count once
+	g := torus(t, 2, 4)
+	g.Set(Cell{X: 0, Y: 0}, true)
+	g.Set(Cell{X: 1, Y: 0}, true)
+	if n := g.Neighbors(Cell{X: 0, Y: 0}); n != 1 {
+		t.Errorf("0,0 has %d neighbors, want 1", n)
+	}
+	if n := g.Neighbors(Cell{X: 0, Y: 1}); n != 2 {
+		t.Errorf("0,1 has %d neighbors, want 2", n)
+	}
+
+	// A cell alone on a one cell torus isn't its own neighbor
+	g = torus(t, 1, 1)
+	g.Set(Cell{}, true)
+	if n := g.Neighbors(Cell{}); n != 0 {
+		t.Errorf("a lone cell has %d neighbors, want 0", n)
+	}
+
+	// The custom rule stepping sees the same neighbors
+	for _, custom := range []bool{false, true} {
+		g := torus(t, 2, 4)
+		if custom {
+			if err := g.SetCustomRule("conway", AsCustom(g.Rule)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		g.Set(Cell{X: 0, Y: 0}, true)
+		g.Set(Cell{X: 1, Y: 0}, true)
+		g.Step()
+		if p := g.Population(); p != 0 {
+			t.Errorf("custom %v: %d cells alive after a step, want 0", custom, p)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package life
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// State is the state of a cell under a custom rule, 0 is dead
+type State uint8
+
+// CustomRule is a cellular automaton that isn't described by B/S notation,
+// such as one with more than two states. Custom rules are registered by
+// name and chosen with Grid.SetRule like built-in ones.
+type CustomRule interface {
+	// States returns the number of states including the dead state 0
+	States() int
+	// Next returns the next state of a cell given its state and those of
+	// its neighbors, in reading order from the top left. A dead cell with
+	// only dead neighbors must stay dead.
+	Next(state State, neighbors [8]State) State
+}
+
+var (
+	customMu    sync.RWMutex
+	customRules = make(map[string]CustomRule)
+)
+
+// RegisterRule makes a custom rule available under name, usually from an
+// init function. It panics if the name is taken, as two rules with one name
+// is a programming error.
+func RegisterRule(name string, r CustomRule) {
+	customMu.Lock()
+	defer customMu.Unlock()
+	if r == nil {
+		panic("life: RegisterRule rule is nil")
+	}
+	if _, dup := customRules[name]; dup {
+		panic("life: RegisterRule called twice for rule " + name)
+	}
+	customRules[name] = r
+}
+
+// LookupRule returns the custom rule registered under name
+func LookupRule(name string) (CustomRule, bool) {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	r, ok := customRules[name]
+	return r, ok
+}
+
+// RuleNames returns the names of the registered custom rules in order
+func RuleNames() []string {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	names := make([]string, 0, len(customRules))
+	for name := range customRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetRule switches the grid to a registered custom rule or a rule in B/S
+// notation. Live cells start again in state 1.
+func (g *Grid) SetRule(name string) error {
+	if r, ok := LookupRule(name); ok {
+		return g.SetCustomRule(name, r)
+	}
+	r, err := ParseRule(name)
+	if err != nil {
+		return err
+	}
+	g.Rule = r
+	g.custom, g.customName = nil, ""
+	g.states = nil
+	return nil
+}
+
+// SetCustomRule switches the grid to a custom rule that needn't be
+// registered, shown under name. Live cells start again in state 1.
+func (g *Grid) SetCustomRule(name string, r CustomRule) error {
+	if r.States() < 2 || r.States() > 256 {
+		return fmt.Errorf("rule %q has %d states, expected 2 to 256", name, r.States())
+	}
+	g.custom, g.customName = r, name
+	g.states = nil
+	return nil
+}
+
+// RuleName returns the name of the custom rule in use, or the rule in B/S
+// notation
+func (g *Grid) RuleName() string {
+	if g.custom != nil {
+		return g.customName
+	}
+	return g.Rule.String()
+}
+
+// States returns the number of states a cell can be in, including dead
+func (g *Grid) States() int {
+	if g.custom != nil {
+		return g.custom.States()
+	}
+	return 2
+}
+
+// State returns the state of a cell, live cells of B/S rules are state 1
+func (g *Grid) State(c Cell) State {
+	if !g.Get(c) {
+		return 0
+	}
+	if s, ok := g.states[c]; ok {
+		return s
+	}
+	return 1
+}
+
+// SetState puts a cell in a state, which must be below States
+func (g *Grid) SetState(c Cell, s State) {
+	g.Set(c, s != 0)
+	if s > 1 {
+		if g.states == nil {
+			g.states = make(map[Cell]State)
+		}
+		g.states[c] = s
+	}
+}
+
+// stepCustom advances the grid one generation under its custom rule. Only
+// live cells and their neighbors can change, as a dead cell with only dead
+// neighbors stays dead. It returns how many cells stayed alive in another
+// state.
+func (g *Grid) stepCustom() (changes int) {
+	next := make(map[Cell]struct{})
+	states := make(map[Cell]State)
+	seen := make(map[Cell]bool)
+	for cell := range g.cells {
+		for i := -1; i <= 1; i++ {
+			for j := -1; j <= 1; j++ {
+				c := g.Wrap(Cell{X: cell.X + i, Y: cell.Y + j})
+				if seen[c] {
+					continue
+				}
+				seen[c] = true
+				// Nothing is born outside a bounded grid
+				if g.Boundary == Bounded && !g.InGrid(c) {
+					continue
+				}
+				old := g.State(c)
+				s := g.custom.Next(old, g.neighborStates(c))
+				if s == 0 {
+					continue
+				}
+				if old != 0 && s != old {
+					changes++
+				}
+				next[c] = struct{}{}
+				if s > 1 {
+					states[c] = s
+				}
+			}
+		}
+	}
+	g.cells, g.states = next, states
+	return changes
+}
+
+// neighborStates returns the states of the neighbors of a cell in reading
+// order
+func (g *Grid) neighborStates(c Cell) [8]State {
+	var n [8]State
+	cells, counted := g.neighbors(c)
+	for k, cell := range cells {
+		if counted[k] {
+			n[k] = g.State(cell)
+		}
+	}
+	return n
+}
+
+// AsCustom returns a B/S rule as a two state custom rule. It steps more
+// slowly than the rule itself, but lets the two ways of stepping be
+// compared.
+func AsCustom(r Rule) CustomRule {
+	return bsRule{r}
+}
+
+// bsRule runs a B/S rule as a custom rule
+type bsRule struct {
+	Rule
+}
+
+func (bsRule) States() int { return 2 }
+
+func (r bsRule) Next(state State, neighbors [8]State) State {
+	n := 0
+	for _, s := range neighbors {
+		if s != 0 {
+			n++
+		}
+	}
+	if state == 0 && r.Birth[n] || state != 0 && r.Survive[n] {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package life
+
+// orientations are the eight ways of turning and mirroring a pattern, as
+// the matrix each cell is multiplied by
+var orientations = [8][4]int{
+	{1, 0, 0, 1}, {0, -1, 1, 0}, {-1, 0, 0, -1}, {0, 1, -1, 0},
+	{-1, 0, 0, 1}, {0, 1, 1, 0}, {1, 0, 0, -1}, {0, -1, -1, 0},
+}
+
+// Fingerprint hashes a set of cells so that the same shape anywhere on the
+// grid, turned or mirrored in any way, hashes the same. Each orientation is
+// moved to start at 0,0 and hashed, and the smallest hash is kept. Different
+// shapes almost always have different fingerprints.
+func Fingerprint(cells map[Cell]struct{}) uint64 {
+	fp, _ := canonical(cells)
+	return fp
+}
+
+// ShapeHash hashes a set of cells so that the same shape anywhere on the
+// grid hashes the same. Unlike Fingerprint it doesn't turn the shape, which
+// makes it cheap enough to take every generation.
+func ShapeHash(cells map[Cell]struct{}) uint64 {
+	minX, minY := orientedMin(cells, 0)
+	h := uint64(len(cells))
+	for c := range cells {
+		h += mix(c.X-minX, c.Y-minY)
+	}
+	return h
+}
+
+// Normalize returns the cells in the orientation their fingerprint comes
+// from, moved so their bounding box starts at 0,0. Shapes with the same
+// fingerprint normalize to the same cells.
+func Normalize(cells map[Cell]struct{}) map[Cell]struct{} {
+	_, o := canonical(cells)
+	minX, minY := orientedMin(cells, o)
+	normal := make(map[Cell]struct{}, len(cells))
+	for c := range cells {
+		x, y := orient(c, o)
+		normal[Cell{X: x - minX, Y: y - minY}] = struct{}{}
+	}
+	return normal
+}
+
+// canonical returns the smallest hash over the orientations and the
+// orientation that gave it
+func canonical(cells map[Cell]struct{}) (uint64, int) {
+	var best uint64
+	bestO := 0
+	for o := range orientations {
+		minX, minY := orientedMin(cells, o)
+		h := uint64(len(cells))
+		for c := range cells {
+			x, y := orient(c, o)
+			h += mix(x-minX, y-minY)
+		}
+		if o == 0 || h < best {
+			best, bestO = h, o
+		}
+	}
+	return best, bestO
+}
+
+// orient turns a cell by one of the orientations
+func orient(c Cell, o int) (x, y int) {
+	m := orientations[o]
+	return m[0]*c.X + m[1]*c.Y, m[2]*c.X + m[3]*c.Y
+}
+
+// orientedMin returns the top left corner of the cells' bounding box once
+// they are turned
+func orientedMin(cells map[Cell]struct{}, o int) (minX, minY int) {
+	first := true
+	for c := range cells {
+		x, y := orient(c, o)
+		if first {
+			minX, minY, first = x, y, false
+			continue
+		}
+		minX, minY = min(minX, x), min(minY, y)
+	}
+	return minX, minY
+}
+
+// mix scrambles a cell position with the splitmix64 finalizer, summing the
+// results hashes a set without depending on the order it is visited in
+func mix(x, y int) uint64 {
+	z := uint64(uint32(x))<<32 | uint64(uint32(y))
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
@@ -0,0 +1,50 @@
+package life
+
+import "testing"
+
+// glider is a glider heading down and right
+var glider = map[Cell]struct{}{{X: 1, Y: 0}: {}, {X: 2, Y: 1}: {}, {X: 0, Y: 2}: {}, {X: 1, Y: 2}: {}, {X: 2, Y: 2}: {}}
+
+func TestFingerprint(t *testing.T) {
+	fp := Fingerprint(glider)
+	normal := Normalize(glider)
+	for o := range orientations {
+		moved := make(map[Cell]struct{})
+		for c := range glider {
+			x, y := orient(c, o)
+			moved[Cell{X: x + 17, Y: y - 9}] = struct{}{}
+		}
+		if got := Fingerprint(moved); got != fp {
+			t.Errorf("orientation %d: fingerprint %x, want %x", o, got, fp)
+		}
+		if got := Normalize(moved); !sameCells(got, normal) {
+			t.Errorf("orientation %d: normalized to\n%swant\n%s", o, draw(got), draw(normal))
+		}
+	}
+	if minX, minY, _, _ := Bounds(normal); minX != 0 || minY != 0 {
+		t.Errorf("normalized glider starts at %d,%d, want 0,0", minX, minY)
+	}
+
+	// The same cells in another shape must differ
+	line := map[Cell]struct{}{{X: 0, Y: 0}: {}, {X: 1, Y: 0}: {}, {X: 2, Y: 0}: {}, {X: 3, Y: 0}: {}, {X: 4, Y: 0}: {}}
+	if Fingerprint(line) == fp {
+		t.Error("a line of five has the glider's fingerprint")
+	}
+}
+
+func TestShapeHash(t *testing.T) {
+	h := ShapeHash(glider)
+	moved := make(map[Cell]struct{})
+	turned := make(map[Cell]struct{})
+	for c := range glider {
+		moved[Cell{X: c.X - 40, Y: c.Y + 3}] = struct{}{}
+		x, y := orient(c, 1)
+		turned[Cell{X: x, Y: y}] = struct{}{}
+	}
+	if got := ShapeHash(moved); got != h {
+		t.Errorf("moved glider hashes to %x, want %x", got, h)
+	}
+	if ShapeHash(turned) == h {
+		t.Error("a turned glider has the glider's shape hash")
+	}
+}
@@ -0,0 +1,49 @@
+package life
+
+// hooks are the functions called after every step, in the order they were
+// added
+type hooks struct {
+	generation []func(gen int, g *Grid)
+	born       []func(c Cell)
+	extinction []func(gen int)
+}
+
+// OnGeneration calls f after every step with the new generation number and
+// the grid, once the cell born and extinction hooks have run
+func (g *Grid) OnGeneration(f func(gen int, g *Grid)) {
+	g.hooks.generation = append(g.hooks.generation, f)
+}
+
+// OnCellBorn calls f for every cell that comes alive in a step, in no
+// particular order. Cells set by hand are not births.
+func (g *Grid) OnCellBorn(f func(c Cell)) {
+	g.hooks.born = append(g.hooks.born, f)
+}
+
+// OnExtinction calls f with the generation number when a step leaves no live
+// cells where there were some
+func (g *Grid) OnExtinction(f func(gen int)) {
+	g.hooks.extinction = append(g.hooks.extinction, f)
+}
+
+// runHooks calls the hooks for a step from the previous live cells
+func (g *Grid) runHooks(previous map[Cell]struct{}) {
+	if len(g.hooks.born) > 0 {
+		for c := range g.cells {
+			if _, wasAlive := previous[c]; wasAlive {
+				continue
+			}
+			for _, f := range g.hooks.born {
+				f(c)
+			}
+		}
+	}
+	if len(previous) > 0 && len(g.cells) == 0 {
+		for _, f := range g.hooks.extinction {
+			f(g.Generation)
+		}
+	}
+	for _, f := range g.hooks.generation {
+		f(g.Generation, g)
+	}
+}
@@ -0,0 +1,92 @@
+package life
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestOnCellBorn(t *testing.T) {
+	g := New(16, 16, Rule{})
+	if err := g.SetRule(Conway); err != nil {
+		t.Fatal(err)
+	}
+	born := make(map[Cell]int)
+	g.OnCellBorn(func(c Cell) { born[c]++ })
+	// Setting cells by hand isn't a birth
+	for x := 4; x <= 6; x++ {
+		g.Set(Cell{X: x, Y: 5}, true)
+	}
+	if len(born) != 0 {
+		t.Fatalf("cells set by hand reported as born: %v", born)
+	}
+	g.Step()
+	want := map[Cell]int{{X: 5, Y: 4}: 1, {X: 5, Y: 6}: 1}
+	if !maps.Equal(born, want) {
+		t.Errorf("born %v, want %v", born, want)
+	}
+}
+
+func TestOnExtinction(t *testing.T) {
+	g := New(16, 16, Rule{})
+	if err := g.SetRule(Conway); err != nil {
+		t.Fatal(err)
+	}
+	var extinct []int
+	g.OnExtinction(func(gen int) { extinct = append(extinct, gen) })
+	// A blinker lives on and a lone cell dies in one step, after which the
+	// empty grid stays empty
+	for x := 4; x <= 6; x++ {
+		g.Set(Cell{X: x, Y: 5}, true)
+	}
+	g.Step()
+	if len(extinct) != 0 {
+		t.Fatalf("extinction reported at %v while a blinker lives", extinct)
+	}
+	g.Replace(map[Cell]struct{}{{X: 1, Y: 1}: {}})
+	g.Step()
+	g.Step()
+	if len(extinct) != 1 || extinct[0] != 2 {
+		t.Errorf("extinction reported at %v, want only generation 2", extinct)
+	}
+}
+
+func TestOnGeneration(t *testing.T) {
+	conway := New(16, 16, Rule{})
+	if err := conway.SetRule(Conway); err != nil {
+		t.Fatal(err)
+	}
+	for x := 4; x <= 6; x++ {
+		conway.Set(Cell{X: x, Y: 5}, true)
+	}
+	brain := New(16, 16, Rule{})
+	if err := brain.SetCustomRule("brians-brain", briansBrain{}); err != nil {
+		t.Fatal(err)
+	}
+	brain.SetState(Cell{X: 4, Y: 5}, 1)
+	brain.SetState(Cell{X: 5, Y: 5}, 1)
+
+	for name, g := range map[string]*Grid{"life": conway, "custom": brain} {
+		var calls []string
+		g.OnCellBorn(func(Cell) {
+			if len(calls) == 0 || calls[len(calls)-1] != "born" {
+				calls = append(calls, "born")
+			}
+		})
+		var gens []int
+		g.OnGeneration(func(gen int, hooked *Grid) {
+			calls = append(calls, "generation")
+			if hooked != g || gen != g.Generation {
+				t.Errorf("%s: generation hook got generation %d of %p, want %d of %p", name, gen, hooked, g.Generation, g)
+			}
+			gens = append(gens, gen)
+		})
+		g.Step()
+		g.Step()
+		if len(gens) != 2 || gens[0] != 1 || gens[1] != 2 {
+			t.Errorf("%s: generation hook got %v, want [1 2]", name, gens)
+		}
+		if len(calls) < 2 || calls[0] != "born" || calls[1] != "generation" {
+			t.Errorf("%s: hooks ran in the order %v, want births before the generation", name, calls)
+		}
+	}
+}
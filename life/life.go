@@ -0,0 +1,156 @@
+// Package life simulates life-like cellular automata on an unbounded,
+// bounded or wrapping grid. It has no dependency on any frontend.
+package life
+
+// Cell is the position of a cell on the grid
+type Cell struct {
+	X, Y int
+}
+
+// Grid is a generation of live cells together with the rule and edges that
+// decide the next one
+type Grid struct {
+	Rule     Rule
+	Boundary Boundary
+	// Width and Height are the size of the grid for bounded and wrapping
+	// edges, unbounded grids grow past them
+	Width, Height int
+	// Generation counts the steps taken, it can be set to start counting
+	// from elsewhere
+	Generation int
+
+	cells map[Cell]struct{}
+	// custom is the registered rule used instead of Rule when set, states
+	// holds the live cells of a custom rule that are past state 1
+	custom     CustomRule
+	customName string
+	states     map[Cell]State
+
+	hooks hooks
+	stats GenerationStats
+}
+
+// New creates an empty grid of width x height cells
+func New(width, height int, rule Rule) *Grid {
+	return &Grid{
+		Rule:   rule,
+		Width:  width,
+		Height: height,
+		cells:  make(map[Cell]struct{}),
+	}
+}
+
+// Get reports whether a cell is alive
+func (g *Grid) Get(c Cell) bool {
+	_, alive := g.cells[c]
+	return alive
+}
+
+// Set makes a cell alive or dead, a live cell is in state 1
+func (g *Grid) Set(c Cell, alive bool) {
+	delete(g.states, c)
+	if alive {
+		g.cells[c] = struct{}{}
+	} else {
+		delete(g.cells, c)
+	}
+}
+
+// Population returns the number of live cells
+func (g *Grid) Population() int {
+	return len(g.cells)
+}
+
+// Cells returns the live cells. The map belongs to the grid and must not be
+// modified, Step and Replace swap in a new map so one returned earlier keeps
+// holding its generation.
+func (g *Grid) Cells() map[Cell]struct{} {
+	return g.cells
+}
+
+// Replace makes cells the live cells, the grid takes ownership of the map
+func (g *Grid) Replace(cells map[Cell]struct{}) {
+	g.cells = cells
+	g.states = nil
+}
+
+// Bounds returns the inclusive bounding box of the live cells, all zero
+// when there are none
+func (g *Grid) Bounds() (minX, minY, maxX, maxY int) {
+	return Bounds(g.cells)
+}
+
+// Bounds returns the inclusive bounding box of a set of cells, all zero when
+// the set is empty
+func Bounds(cells map[Cell]struct{}) (minX, minY, maxX, maxY int) {
+	first := true
+	for c := range cells {
+		if first {
+			minX, minY, maxX, maxY = c.X, c.Y, c.X, c.Y
+			first = false
+			continue
+		}
+		minX, minY = min(minX, c.X), min(minY, c.Y)
+		maxX, maxY = max(maxX, c.X), max(maxY, c.Y)
+	}
+	return minX, minY, maxX, maxY
+}
+
+// Step advances the grid one generation
+func (g *Grid) Step() {
+	g.confine()
+	previous := g.cells
+	changes := 0
+	if g.custom != nil {
+		changes = g.stepCustom()
+	} else {
+		g.stepLife()
+	}
+	g.Generation++
+	g.stats = Measure(previous, g.cells)
+	g.stats.Generation = g.Generation
+	g.stats.Changes = changes
+	g.runHooks(previous)
+}
+
+// stepLife advances the grid one generation under its B/S rule
+func (g *Grid) stepLife() {
+	// Create a new map to store the next generation of cells
+	next := make(map[Cell]struct{})
+	// Iterate over all the cells
+	for cell := range g.cells {
+		// The cell survives if the rule allows its neighbor count
+		if g.Rule.Survive[g.Neighbors(cell)] {
+			next[cell] = struct{}{}
+		}
+		// Check the neighbors of the cell
+		neighbors, _ := g.neighbors(cell)
+		for _, neighbor := range neighbors {
+			// Live neighbors are handled by their own iteration
+			if _, isAlive := g.cells[neighbor]; isAlive {
+				continue
+			}
+			// Nothing is born outside a bounded grid
+			if g.Boundary == Bounded && !g.InGrid(neighbor) {
+				continue
+			}
+			// The dead neighbor is born if the rule allows its neighbor count
+			if g.Rule.Birth[g.Neighbors(neighbor)] {
+				next[neighbor] = struct{}{}
+			}
+		}
+	}
+	g.cells = next
+}
+
+// Neighbors counts the live neighbors of a cell
+func (g *Grid) Neighbors(c Cell) int {
+	n := 0
+	cells, counted := g.neighbors(c)
+	for k, cell := range cells {
+		if _, isAlive := g.cells[cell]; isAlive && counted[k] {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,79 @@
+package life
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule describes a life-like cellular automaton in B/S notation, a dead
+// cell with n live neighbors is born when Birth[n] is set and a live one
+// survives when Survive[n] is.
+type Rule struct {
+	Birth   [9]bool
+	Survive [9]bool
+}
+
+// Conway is the standard Game of Life rule.
+const Conway = "B3/S23"
+
+// ParseRule parses a rule string such as "B3/S23" or "23/3".
+func ParseRule(s string) (Rule, error) {
+	var r Rule
+	s = strings.ToUpper(strings.TrimSpace(s))
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return r, fmt.Errorf("invalid rule %q: expected B<digits>/S<digits>", s)
+	}
+
+	var birth, survive string
+	switch {
+	case strings.HasPrefix(parts[0], "B") && strings.HasPrefix(parts[1], "S"):
+		birth, survive = parts[0][1:], parts[1][1:]
+	case strings.HasPrefix(parts[0], "S") && strings.HasPrefix(parts[1], "B"):
+		survive, birth = parts[0][1:], parts[1][1:]
+	default:
+		// Old style S/B notation without letters, e.g. "23/3"
+		survive, birth = parts[0], parts[1]
+	}
+
+	if err := parseCounts(birth, &r.Birth); err != nil {
+		return r, fmt.Errorf("invalid rule %q: %w", s, err)
+	}
+	if err := parseCounts(survive, &r.Survive); err != nil {
+		return r, fmt.Errorf("invalid rule %q: %w", s, err)
+	}
+	// Births from zero neighbours would fill the infinite plane
+	if r.Birth[0] {
+		return r, fmt.Errorf("invalid rule %q: B0 rules are not supported", s)
+	}
+	return r, nil
+}
+
+// parseCounts marks every neighbour count digit found in s.
+func parseCounts(s string, counts *[9]bool) error {
+	for _, c := range s {
+		if c < '0' || c > '8' {
+			return fmt.Errorf("bad neighbour count %q", c)
+		}
+		counts[c-'0'] = true
+	}
+	return nil
+}
+
+// String returns the rule in B/S notation.
+func (r Rule) String() string {
+	var b strings.Builder
+	b.WriteString("B")
+	for i, ok := range r.Birth {
+		if ok {
+			fmt.Fprintf(&b, "%d", i)
+		}
+	}
+	b.WriteString("/S")
+	for i, ok := range r.Survive {
+		if ok {
+			fmt.Fprintf(&b, "%d", i)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,18 @@
+package life
+
+// Simulator is an engine that advances live cells a generation at a time.
+// Every engine is checked against the same golden states, see
+// simulator_test.go.
+type Simulator interface {
+	// Get reports whether a cell is alive
+	Get(c Cell) bool
+	// Set makes a cell alive or dead
+	Set(c Cell, alive bool)
+	// Step advances one generation
+	Step()
+	// Cells returns the live cells, which must not be modified
+	Cells() map[Cell]struct{}
+}
+
+// Grid steps B/S rules directly and custom rules through their interface
+var _ Simulator = (*Grid)(nil)
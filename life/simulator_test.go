@@ -0,0 +1,207 @@
+package life
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// simulators are the engines checked against the golden states
+var simulators = []struct {
+	name string
+	new  func(g golden) Simulator
+}{
+	{"life", func(gd golden) Simulator {
+		g := New(gd.width, gd.height, gd.rule)
+		g.Boundary = gd.boundary
+		return g
+	}},
+	{"custom", func(gd golden) Simulator {
+		g := New(gd.width, gd.height, Rule{})
+		g.Boundary = gd.boundary
+		if err := g.SetCustomRule(gd.rule.String(), AsCustom(gd.rule)); err != nil {
+			panic(err)
+		}
+		return g
+	}},
+}
+
+// golden is a starting pattern and the cells it must have after a number
+// of generations, read from a file in testdata
+type golden struct {
+	rule          Rule
+	boundary      Boundary
+	width, height int
+	generations   int
+	start, want   map[Cell]struct{}
+}
+
+// readGolden reads a golden state. Lines starting with # are comments, then
+// come rule, boundary, size and generations settings, and the start and
+// want pictures of . for dead and O for alive cells, both drawn from the
+// top left of the grid.
+func readGolden(path string) (golden, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return golden{}, err
+	}
+	defer f.Close()
+
+	gd := golden{width: 64, height: 64}
+	r, _ := ParseRule(Conway)
+	gd.rule = r
+	var picture map[Cell]struct{}
+	y := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case line == "start":
+			gd.start = make(map[Cell]struct{})
+			picture, y = gd.start, 0
+		case line == "want":
+			gd.want = make(map[Cell]struct{})
+			picture, y = gd.want, 0
+		case picture != nil:
+			for x, c := range line {
+				switch c {
+				case 'O':
+					picture[Cell{X: x, Y: y}] = struct{}{}
+				case '.':
+				default:
+					return gd, fmt.Errorf("%s: unexpected %q in picture", path, c)
+				}
+			}
+			y++
+		case fields[0] == "rule" && len(fields) == 2:
+			if gd.rule, err = ParseRule(fields[1]); err != nil {
+				return gd, err
+			}
+		case fields[0] == "boundary" && len(fields) == 2:
+			if gd.boundary, err = ParseBoundary(fields[1]); err != nil {
+				return gd, err
+			}
+		case fields[0] == "size" && len(fields) == 3:
+			if _, err := fmt.Sscan(fields[1]+" "+fields[2], &gd.width, &gd.height); err != nil {
+				return gd, fmt.Errorf("%s: bad size: %w", path, err)
+			}
+		case fields[0] == "generations" && len(fields) == 2:
+			if _, err := fmt.Sscan(fields[1], &gd.generations); err != nil {
+				return gd, fmt.Errorf("%s: bad generations: %w", path, err)
+			}
+		default:
+			return gd, fmt.Errorf("%s: unexpected line %q", path, line)
+		}
+	}
+	if gd.start == nil || gd.want == nil {
+		return gd, fmt.Errorf("%s: needs a start and a want picture", path)
+	}
+	return gd, scanner.Err()
+}
+
+// draw returns cells as a picture in the golden format, for failures
+func draw(cells map[Cell]struct{}) string {
+	if len(cells) == 0 {
+		return "(no cells)\n"
+	}
+	minX, minY, maxX, maxY := Bounds(cells)
+	var b strings.Builder
+	fmt.Fprintf(&b, "from %d,%d\n", minX, minY)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			if _, ok := cells[Cell{X: x, Y: y}]; ok {
+				b.WriteByte('O')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func sameCells(a, b map[Cell]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for c := range a {
+		if _, ok := b[c]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.life"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no golden states in testdata")
+	}
+	for _, path := range paths {
+		gd, err := readGolden(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".life")
+		for _, sim := range simulators {
+			t.Run(name+"/"+sim.name, func(t *testing.T) {
+				s := sim.new(gd)
+				for c := range gd.start {
+					s.Set(c, true)
+				}
+				for range gd.generations {
+					s.Step()
+				}
+				if got := s.Cells(); !sameCells(got, gd.want) {
+					t.Errorf("after %d generations got\n%swant\n%s", gd.generations, draw(got), draw(gd.want))
+				}
+			})
+		}
+	}
+}
+
+// TestSimulatorsAgree runs a random soup on every engine and checks they
+// stay in step
+func TestSimulatorsAgree(t *testing.T) {
+	gd := golden{width: 32, height: 32, start: make(map[Cell]struct{})}
+	gd.rule, _ = ParseRule(Conway)
+	// A fixed soup built from a small linear congruential generator
+	seed := uint32(1)
+	for y := range 16 {
+		for x := range 16 {
+			seed = seed*1664525 + 1013904223
+			if seed>>28 < 6 {
+				gd.start[Cell{X: x, Y: y}] = struct{}{}
+			}
+		}
+	}
+	for _, b := range []Boundary{Unbounded, Bounded, Torus} {
+		gd.boundary = b
+		var sims []Simulator
+		for _, sim := range simulators {
+			s := sim.new(gd)
+			for c := range gd.start {
+				s.Set(c, true)
+			}
+			sims = append(sims, s)
+		}
+		for gen := 1; gen <= 200; gen++ {
+			for _, s := range sims {
+				s.Step()
+			}
+			for i, s := range sims[1:] {
+				if !sameCells(s.Cells(), sims[0].Cells()) {
+					t.Fatalf("%s: %s and %s differ at generation %d", b, simulators[i+1].name, simulators[0].name, gen)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package life
+
+// GenerationStats describes the step that made a generation
+type GenerationStats struct {
+	Generation int
+	Population int
+	// Births are the cells that came alive in the step and Deaths the cells
+	// that died
+	Births, Deaths int
+	// Changes are the cells that stayed alive in another state, under a
+	// custom rule with more than two states
+	Changes int
+	// MinX, MinY, MaxX and MaxY are the inclusive bounding box of the live
+	// cells, all zero when there are none
+	MinX, MinY, MaxX, MaxY int
+}
+
+// Width returns the width of the bounding box, zero when nothing is alive
+func (s GenerationStats) Width() int {
+	if s.Population == 0 {
+		return 0
+	}
+	return s.MaxX - s.MinX + 1
+}
+
+// Height returns the height of the bounding box, zero when nothing is alive
+func (s GenerationStats) Height() int {
+	if s.Population == 0 {
+		return 0
+	}
+	return s.MaxY - s.MinY + 1
+}
+
+// Heat returns how many cells changed state in the step
+func (s GenerationStats) Heat() int {
+	return s.Births + s.Deaths + s.Changes
+}
+
+// Measure works out the statistics of a step from the live cells before and
+// after it, leaving the generation number to the caller
+func Measure(previous, next map[Cell]struct{}) GenerationStats {
+	s := GenerationStats{Population: len(next)}
+	first := true
+	for c := range next {
+		if _, wasAlive := previous[c]; !wasAlive {
+			s.Births++
+		}
+		if first {
+			s.MinX, s.MinY, s.MaxX, s.MaxY = c.X, c.Y, c.X, c.Y
+			first = false
+			continue
+		}
+		s.MinX, s.MinY = min(s.MinX, c.X), min(s.MinY, c.Y)
+		s.MaxX, s.MaxY = max(s.MaxX, c.X), max(s.MaxY, c.Y)
+	}
+	// Every cell alive before either survived or died
+	s.Deaths = len(previous) - (len(next) - s.Births)
+	return s
+}
+
+// Stats returns the statistics of the last step, all zero before the first.
+// Cells set since the step are not counted.
+func (g *Grid) Stats() GenerationStats {
+	return g.stats
+}
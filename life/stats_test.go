@@ -0,0 +1,74 @@
+package life
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	g := New(16, 16, Rule{})
+	if err := g.SetRule(Conway); err != nil {
+		t.Fatal(err)
+	}
+	// A horizontal blinker turns vertical, losing its ends and gaining
+	// cells above and below the middle
+	for x := 4; x <= 6; x++ {
+		g.Set(Cell{X: x, Y: 5}, true)
+	}
+	g.Step()
+	want := GenerationStats{Generation: 1, Population: 3, Births: 2, Deaths: 2, MinX: 5, MinY: 4, MaxX: 5, MaxY: 6}
+	if got := g.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if heat := g.Stats().Heat(); heat != 4 {
+		t.Errorf("heat is %d, want 4", heat)
+	}
+	if w, h := g.Stats().Width(), g.Stats().Height(); w != 1 || h != 3 {
+		t.Errorf("bounding box is %dx%d, want 1x3", w, h)
+	}
+
+	g.Replace(map[Cell]struct{}{{X: 1, Y: 1}: {}})
+	g.Step()
+	want = GenerationStats{Generation: 2, Deaths: 1}
+	if got := g.Stats(); got != want {
+		t.Errorf("after dying out got %+v, want %+v", got, want)
+	}
+}
+
+// briansBrain fires dead cells with two firing neighbors, and fired cells
+// go on to dying then dead
+type briansBrain struct{}
+
+func (briansBrain) States() int { return 3 }
+
+func (briansBrain) Next(state State, neighbors [8]State) State {
+	if state != 0 {
+		return (state + 1) % 3
+	}
+	firing := 0
+	for _, n := range neighbors {
+		if n == 1 {
+			firing++
+		}
+	}
+	if firing == 2 {
+		return 1
+	}
+	return 0
+}
+
+func TestStatsStates(t *testing.T) {
+	g := New(16, 16, Rule{})
+	if err := g.SetCustomRule("brians-brain", briansBrain{}); err != nil {
+		t.Fatal(err)
+	}
+	// Two firing cells start dying, which keeps them alive in another
+	// state, and fire the four cells above and below them
+	g.SetState(Cell{X: 4, Y: 5}, 1)
+	g.SetState(Cell{X: 5, Y: 5}, 1)
+	g.Step()
+	want := GenerationStats{Generation: 1, Population: 6, Births: 4, Changes: 2, MinX: 4, MinY: 4, MaxX: 5, MaxY: 6}
+	if got := g.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if heat := g.Stats().Heat(); heat != 6 {
+		t.Errorf("heat is %d, want 6", heat)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// lifespanResult is how one variant of a pattern ran
+type lifespanResult struct {
+	// cell is the cell toggled from the pattern's top left, changed is
+	// false for the pattern as it is
+	cell    tile
+	changed bool
+
+	lifespan   int
+	settled    bool
+	population int
+}
+
+// parsePerturbations parses cells given as x,y pairs separated by spaces or
+// semicolons, counted from the pattern's top left
+func parsePerturbations(s string) ([]tile, error) {
+	var cells []tile
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ';' }) {
+		xs, ys, ok := strings.Cut(field, ",")
+		x, errX := strconv.Atoi(xs)
+		y, errY := strconv.Atoi(ys)
+		if !ok || errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid cell %q, expected x,y", field)
+		}
+		cells = append(cells, tile{X: x, Y: y})
+	}
+	return cells, nil
+}
+
+// runLifespans runs the pattern placed in the world, then a variant of it
+// for each cell with that cell toggled, until each settles, and writes
+// their lifespans to path or to standard output when path is empty. With
+// no cells given, random variants are run when random is set, otherwise
+// every cell in the pattern's box and the ring around it is tried.
+func (w *World) runLifespans(p *pattern, cells []tile, random int, path string) error {
+	if len(cells) == 0 {
+		cells = w.perturbationArea(p, random)
+	}
+	origin := w.patternOrigin(p)
+	base := copyCells(w.grid.Cells())
+
+	results := []lifespanResult{w.runVariant(base)}
+	for _, cell := range cells {
+		variant := copyCells(base)
+		at := tile{X: origin.X + cell.X, Y: origin.Y + cell.Y}
+		if _, alive := variant[at]; alive {
+			delete(variant, at)
+		} else {
+			variant[at] = struct{}{}
+		}
+		r := w.runVariant(variant)
+		r.cell, r.changed = cell, true
+		results = append(results, r)
+	}
+
+	if path == "" {
+		return writeLifespans(os.Stdout, p, results)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeLifespans(f, p, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// perturbationArea returns the cells tried when none are given, random ones
+// from the pattern's box grown by a cell when n is set, otherwise all of
+// them
+func (w *World) perturbationArea(p *pattern, n int) []tile {
+	var area []tile
+	for y := -1; y <= p.height; y++ {
+		for x := -1; x <= p.width; x++ {
+			area = append(area, tile{X: x, Y: y})
+		}
+	}
+	if n == 0 {
+		return area
+	}
+	cells := make([]tile, n)
+	for i := range cells {
+		cells[i] = area[w.random.Intn(len(area))]
+	}
+	return cells
+}
+
+// runVariant runs cells on a grid like the world's until they settle
+func (w *World) runVariant(cells map[tile]struct{}) lifespanResult {
+	g := life.New(w.grid.Width, w.grid.Height, life.Rule{})
+	g.Boundary = w.grid.Boundary
+	// The world's rule has already been checked
+	_ = g.SetRule(w.grid.RuleName())
+	g.Replace(cells)
+	lifespan, settled := runUntilSettled(g)
+	return lifespanResult{lifespan: lifespan, settled: settled, population: g.Population()}
+}
+
+// writeLifespans writes a table of the variants, then how many of them
+// lived longer or shorter than the pattern
+func writeLifespans(out io.Writer, p *pattern, results []lifespanResult) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "toggled\tlifespan\tfinal population\t")
+	for _, r := range results {
+		cell := "none"
+		if r.changed {
+			cell = fmt.Sprintf("%d,%d", r.cell.X, r.cell.Y)
+		}
+		lifespan := strconv.Itoa(r.lifespan)
+		if !r.settled {
+			lifespan = fmt.Sprintf("over %d", searchLimit)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t\n", cell, lifespan, r.population)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	original := results[0]
+	longer, shorter := 0, 0
+	for _, r := range results[1:] {
+		switch {
+		case !r.settled && original.settled, r.settled == original.settled && r.lifespan > original.lifespan:
+			longer++
+		case r.lifespan < original.lifespan:
+			shorter++
+		}
+	}
+	name := p.name
+	if name == "" {
+		name = "the pattern"
+	}
+	_, err := fmt.Fprintf(out, "\n%d of %d variants of %s live longer, %d shorter\n", longer, len(results)-1, name, shorter)
+	return err
+}
@@ -0,0 +1,878 @@
+package lua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxDepth is how deep calls can nest before a script is stopped with a
+// stack overflow
+const maxDepth = 200
+
+// interruptEvery is how many steps run between calls of the interrupt
+const interruptEvery = 1024
+
+// funcProto is a function as parsed, made into a value each time its
+// function expression runs
+type funcProto struct {
+	name   string
+	source string
+	line   int
+	// params are in the first slots, the rest hold locals
+	params int
+	vararg bool
+	slots  int
+	upvals []upvalDesc
+	body   block
+}
+
+// upvalDesc says where a function finds a variable of an enclosing one,
+// in a local slot of the function around it or among that one's upvalues
+type upvalDesc struct {
+	name      string
+	fromLocal bool
+	index     int
+}
+
+// frame is a call of a Lua function. Each local is a variable of its own
+// so closures made in a loop each keep the one of their iteration.
+type frame struct {
+	state   *State
+	fn      *Function
+	slots   []*Value
+	varargs []Value
+	ret     []Value
+}
+
+// tick counts a step on line, a call or a turn of a loop, and stops the
+// script once it has run out of steps or its interrupt returns an error
+func (f *frame) tick(line int) error {
+	s := f.state
+	s.steps++
+	var err error
+	if s.limit > 0 && s.steps > s.limit {
+		err = fmt.Errorf("script ran for more than %d steps", s.limit)
+	} else if s.interrupt != nil && s.steps%interruptEvery == 0 {
+		err = s.interrupt()
+	}
+	if err != nil {
+		return &Error{Value: fmt.Sprintf("%s:%d: %v", f.fn.proto.source, line, err), stop: true}
+	}
+	return nil
+}
+
+// errorf returns a runtime error on line of the function running
+func (f *frame) errorf(line int, format string, args ...any) error {
+	return &Error{Value: fmt.Sprintf("%s:%d: %s", f.fn.proto.source, line, fmt.Sprintf(format, args...))}
+}
+
+// control says how a statement finished
+type control int
+
+const (
+	ctlNext control = iota
+	ctlBreak
+	ctlReturn
+)
+
+type (
+	// expr is an expression, which evaluates to one value
+	expr interface {
+		eval(f *frame) (Value, error)
+	}
+	// multiExpr is an expression that can give any number of values, a
+	// call or ..., which are all kept at the end of a list
+	multiExpr interface {
+		expr
+		evalMulti(f *frame) ([]Value, error)
+	}
+	// stmt is a statement
+	stmt interface {
+		exec(f *frame) (control, error)
+	}
+	// block is a list of statements run in order
+	block []stmt
+)
+
+func (b block) exec(f *frame) (control, error) {
+	for _, s := range b {
+		if c, err := s.exec(f); err != nil || c != ctlNext {
+			return c, err
+		}
+	}
+	return ctlNext, nil
+}
+
+// evalList evaluates expressions to a list of values, all the values of a
+// call or ... at the end and the first of any before it
+func evalList(f *frame, exprs []expr) ([]Value, error) {
+	values := make([]Value, 0, len(exprs))
+	for i, e := range exprs {
+		if m, ok := e.(multiExpr); ok && i == len(exprs)-1 {
+			vs, err := m.evalMulti(f)
+			if err != nil {
+				return nil, err
+			}
+			return append(values, vs...), nil
+		}
+		v, err := e.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Expressions
+
+type constExpr struct{ value Value }
+
+func (e *constExpr) eval(*frame) (Value, error) { return e.value, nil }
+
+type localExpr struct {
+	slot int
+	name string
+}
+
+func (e *localExpr) eval(f *frame) (Value, error) { return *f.slots[e.slot], nil }
+
+type upvalExpr struct {
+	index int
+	name  string
+}
+
+func (e *upvalExpr) eval(f *frame) (Value, error) { return *f.fn.upvals[e.index], nil }
+
+type globalExpr struct{ name string }
+
+func (e *globalExpr) eval(f *frame) (Value, error) { return f.state.globals.Get(e.name), nil }
+
+type varargExpr struct{}
+
+func (e *varargExpr) eval(f *frame) (Value, error) {
+	if len(f.varargs) == 0 {
+		return nil, nil
+	}
+	return f.varargs[0], nil
+}
+
+func (e *varargExpr) evalMulti(f *frame) ([]Value, error) {
+	return append([]Value(nil), f.varargs...), nil
+}
+
+// parenExpr is an expression in brackets, which gives only its first value
+type parenExpr struct{ x expr }
+
+func (e *parenExpr) eval(f *frame) (Value, error) { return e.x.eval(f) }
+
+type indexExpr struct {
+	obj, key expr
+	line     int
+}
+
+func (e *indexExpr) eval(f *frame) (Value, error) {
+	obj, err := e.obj.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	key, err := e.key.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	return f.index(obj, key, e.line, describe(e.obj))
+}
+
+// index returns obj[key], looking up the methods of strings in the string
+// library. what names obj in the error if it can't be indexed.
+func (f *frame) index(obj, key Value, line int, what string) (Value, error) {
+	switch o := obj.(type) {
+	case *Table:
+		return o.Get(key), nil
+	case string:
+		return f.state.stringLib.Get(key), nil
+	}
+	return nil, f.errorf(line, "attempt to index a %s value%s", TypeName(obj), what)
+}
+
+// describe names where a value came from in error messages, as Lua does:
+// the variable it was read from, or the field for a key that isn't a
+// constant string
+func describe(e expr) string {
+	switch e := e.(type) {
+	case *globalExpr:
+		return fmt.Sprintf(" (global '%s')", e.name)
+	case *localExpr:
+		return fmt.Sprintf(" (local '%s')", e.name)
+	case *upvalExpr:
+		return fmt.Sprintf(" (upvalue '%s')", e.name)
+	case *indexExpr:
+		if k, ok := e.key.(*constExpr); ok {
+			if s, ok := k.value.(string); ok {
+				return fmt.Sprintf(" (field '%s')", s)
+			}
+		}
+		return " (field '?')"
+	}
+	return ""
+}
+
+type callExpr struct {
+	fn   expr
+	args []expr
+	line int
+}
+
+func (e *callExpr) eval(f *frame) (Value, error) {
+	vs, err := e.evalMulti(f)
+	if err != nil || len(vs) == 0 {
+		return nil, err
+	}
+	return vs[0], nil
+}
+
+func (e *callExpr) evalMulti(f *frame) ([]Value, error) {
+	fn, err := e.fn.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	args, err := evalList(f, e.args)
+	if err != nil {
+		return nil, err
+	}
+	return f.call(fn, args, e.line, describe(e.fn))
+}
+
+// methodExpr is a method call obj:name(args), which passes obj first
+type methodExpr struct {
+	obj  expr
+	name string
+	args []expr
+	line int
+}
+
+func (e *methodExpr) eval(f *frame) (Value, error) {
+	vs, err := e.evalMulti(f)
+	if err != nil || len(vs) == 0 {
+		return nil, err
+	}
+	return vs[0], nil
+}
+
+func (e *methodExpr) evalMulti(f *frame) ([]Value, error) {
+	obj, err := e.obj.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	fn, err := f.index(obj, e.name, e.line, describe(e.obj))
+	if err != nil {
+		return nil, err
+	}
+	args, err := evalList(f, e.args)
+	if err != nil {
+		return nil, err
+	}
+	return f.call(fn, append([]Value{obj}, args...), e.line, fmt.Sprintf(" (method '%s')", e.name))
+}
+
+// call calls a function value from line, placing errors raised by Go
+// functions there
+func (f *frame) call(fn Value, args []Value, line int, callee string) ([]Value, error) {
+	if err := f.tick(line); err != nil {
+		return nil, err
+	}
+	results, err := f.state.call(fn, args)
+	if err == nil {
+		return results, nil
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return nil, f.errorf(line, "%v", err)
+	}
+	if e.notCallable {
+		return nil, f.errorf(line, "attempt to call a %s value%s", TypeName(fn), callee)
+	}
+	if e.bare {
+		if msg, ok := e.Value.(string); ok {
+			return nil, f.errorf(line, "%s", msg)
+		}
+		e.bare = false
+	}
+	return nil, e
+}
+
+type functionExpr struct{ proto *funcProto }
+
+func (e *functionExpr) eval(f *frame) (Value, error) {
+	fn := &Function{name: e.proto.name, proto: e.proto, upvals: make([]*Value, len(e.proto.upvals))}
+	for i, u := range e.proto.upvals {
+		if u.fromLocal {
+			fn.upvals[i] = f.slots[u.index]
+		} else {
+			fn.upvals[i] = f.fn.upvals[u.index]
+		}
+	}
+	return fn, nil
+}
+
+// tableField is a field of a table constructor, key is nil for the next
+// position in the list
+type tableField struct {
+	key, value expr
+}
+
+type tableExpr struct {
+	fields []tableField
+	line   int
+}
+
+func (e *tableExpr) eval(f *frame) (Value, error) {
+	t := NewTable()
+	var list []Value
+	for i, field := range e.fields {
+		if field.key != nil {
+			key, err := field.key.eval(f)
+			if err != nil {
+				return nil, err
+			}
+			value, err := field.value.eval(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.Set(key, value); err != nil {
+				return nil, f.errorf(e.line, "%v", err)
+			}
+			continue
+		}
+		values, err := evalList(f, []expr{field.value})
+		if err != nil {
+			return nil, err
+		}
+		if _, multi := field.value.(multiExpr); !multi || i < len(e.fields)-1 {
+			values = values[:min(1, len(values))]
+			if len(values) == 0 {
+				values = []Value{nil}
+			}
+		}
+		list = append(list, values...)
+	}
+	t.setList(list)
+	return t, nil
+}
+
+type andExpr struct{ l, r expr }
+
+func (e *andExpr) eval(f *frame) (Value, error) {
+	l, err := e.l.eval(f)
+	if err != nil || !truthy(l) {
+		return l, err
+	}
+	return e.r.eval(f)
+}
+
+type orExpr struct{ l, r expr }
+
+func (e *orExpr) eval(f *frame) (Value, error) {
+	l, err := e.l.eval(f)
+	if err != nil || truthy(l) {
+		return l, err
+	}
+	return e.r.eval(f)
+}
+
+type notExpr struct{ x expr }
+
+func (e *notExpr) eval(f *frame) (Value, error) {
+	x, err := e.x.eval(f)
+	return !truthy(x), err
+}
+
+type negExpr struct {
+	x    expr
+	line int
+}
+
+func (e *negExpr) eval(f *frame) (Value, error) {
+	x, err := e.x.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := toNumber(x)
+	if !ok {
+		return nil, f.errorf(e.line, "attempt to perform arithmetic on a %s value", TypeName(x))
+	}
+	return -n, nil
+}
+
+type lenExpr struct {
+	x    expr
+	line int
+}
+
+func (e *lenExpr) eval(f *frame) (Value, error) {
+	x, err := e.x.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case string:
+		return float64(len(x)), nil
+	case *Table:
+		return float64(x.Len()), nil
+	}
+	return nil, f.errorf(e.line, "attempt to get length of a %s value", TypeName(x))
+}
+
+// binExpr is an operator between two values other than and and or
+type binExpr struct {
+	op   string
+	l, r expr
+	line int
+}
+
+func (e *binExpr) eval(f *frame) (Value, error) {
+	l, err := e.l.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.r.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "==":
+		return l == r, nil
+	case "~=":
+		return l != r, nil
+	case "<":
+		return f.less(l, r, e.line)
+	case ">":
+		return f.less(r, l, e.line)
+	case "<=":
+		return f.lessEqual(l, r, e.line)
+	case ">=":
+		return f.lessEqual(r, l, e.line)
+	case "..":
+		ls, lok := concatString(l)
+		rs, rok := concatString(r)
+		if !lok || !rok {
+			bad := l
+			if lok {
+				bad = r
+			}
+			return nil, f.errorf(e.line, "attempt to concatenate a %s value", TypeName(bad))
+		}
+		return ls + rs, nil
+	}
+	a, aok := toNumber(l)
+	b, bok := toNumber(r)
+	if !aok || !bok {
+		bad := l
+		if aok {
+			bad = r
+		}
+		return nil, f.errorf(e.line, "attempt to perform arithmetic on a %s value", TypeName(bad))
+	}
+	return arith(e.op, a, b), nil
+}
+
+// arith applies an arithmetic operator, with floored division and modulo
+// as Lua has them
+func arith(op string, a, b float64) float64 {
+	switch op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		return a / b
+	case "//":
+		return math.Floor(a / b)
+	case "%":
+		if math.IsInf(b, 0) && !math.IsInf(a, 0) && !math.IsNaN(a) {
+			if a == 0 || (a > 0) == (b > 0) {
+				return a
+			}
+			return b
+		}
+		m := math.Mod(a, b)
+		if m != 0 && (m < 0) != (b < 0) {
+			m += b
+		}
+		return m
+	case "^":
+		return math.Pow(a, b)
+	}
+	panic("lua: unknown operator " + op)
+}
+
+// concatString returns the text of a value for .., numbers included
+func concatString(v Value) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return formatNumber(v), true
+	}
+	return "", false
+}
+
+func (f *frame) less(l, r Value, line int) (Value, error) {
+	switch a := l.(type) {
+	case float64:
+		if b, ok := r.(float64); ok {
+			return a < b, nil
+		}
+	case string:
+		if b, ok := r.(string); ok {
+			return a < b, nil
+		}
+	}
+	return nil, f.compareError(l, r, line)
+}
+
+func (f *frame) lessEqual(l, r Value, line int) (Value, error) {
+	switch a := l.(type) {
+	case float64:
+		if b, ok := r.(float64); ok {
+			return a <= b, nil
+		}
+	case string:
+		if b, ok := r.(string); ok {
+			return a <= b, nil
+		}
+	}
+	return nil, f.compareError(l, r, line)
+}
+
+func (f *frame) compareError(l, r Value, line int) error {
+	if TypeName(l) == TypeName(r) {
+		return f.errorf(line, "attempt to compare two %s values", TypeName(l))
+	}
+	return f.errorf(line, "attempt to compare %s with %s", TypeName(l), TypeName(r))
+}
+
+// Statements
+
+type localStmt struct {
+	slots []int
+	exprs []expr
+}
+
+func (s *localStmt) exec(f *frame) (control, error) {
+	values, err := evalList(f, s.exprs)
+	if err != nil {
+		return ctlNext, err
+	}
+	for i, slot := range s.slots {
+		var v Value
+		if i < len(values) {
+			v = values[i]
+		}
+		f.slots[slot] = &v
+	}
+	return ctlNext, nil
+}
+
+// localFunctionStmt declares the local before making the function, so
+// the function can call itself
+type localFunctionStmt struct {
+	slot int
+	fn   *functionExpr
+}
+
+func (s *localFunctionStmt) exec(f *frame) (control, error) {
+	cell := new(Value)
+	f.slots[s.slot] = cell
+	fn, err := s.fn.eval(f)
+	*cell = fn
+	return ctlNext, err
+}
+
+type assignStmt struct {
+	targets []expr
+	exprs   []expr
+	line    int
+}
+
+func (s *assignStmt) exec(f *frame) (control, error) {
+	// Tables and keys are evaluated before the values
+	type place struct {
+		obj, key Value
+	}
+	places := make([]place, len(s.targets))
+	for i, t := range s.targets {
+		if ix, ok := t.(*indexExpr); ok {
+			obj, err := ix.obj.eval(f)
+			if err != nil {
+				return ctlNext, err
+			}
+			key, err := ix.key.eval(f)
+			if err != nil {
+				return ctlNext, err
+			}
+			places[i] = place{obj, key}
+		}
+	}
+	values, err := evalList(f, s.exprs)
+	if err != nil {
+		return ctlNext, err
+	}
+	for i, t := range s.targets {
+		var v Value
+		if i < len(values) {
+			v = values[i]
+		}
+		switch t := t.(type) {
+		case *localExpr:
+			*f.slots[t.slot] = v
+		case *upvalExpr:
+			*f.fn.upvals[t.index] = v
+		case *globalExpr:
+			f.state.globals.Set(t.name, v)
+		case *indexExpr:
+			tbl, ok := places[i].obj.(*Table)
+			if !ok {
+				return ctlNext, f.errorf(t.line, "attempt to index a %s value%s", TypeName(places[i].obj), describe(t.obj))
+			}
+			if err := tbl.Set(places[i].key, v); err != nil {
+				return ctlNext, f.errorf(t.line, "%v", err)
+			}
+		}
+	}
+	return ctlNext, nil
+}
+
+// callStmt is a call whose results are thrown away
+type callStmt struct{ call multiExpr }
+
+func (s *callStmt) exec(f *frame) (control, error) {
+	_, err := s.call.evalMulti(f)
+	return ctlNext, err
+}
+
+type doStmt struct{ body block }
+
+func (s *doStmt) exec(f *frame) (control, error) { return s.body.exec(f) }
+
+type whileStmt struct {
+	cond expr
+	body block
+	line int
+}
+
+func (s *whileStmt) exec(f *frame) (control, error) {
+	for {
+		if err := f.tick(s.line); err != nil {
+			return ctlNext, err
+		}
+		cond, err := s.cond.eval(f)
+		if err != nil || !truthy(cond) {
+			return ctlNext, err
+		}
+		c, err := s.body.exec(f)
+		if err != nil || c == ctlReturn {
+			return c, err
+		}
+		if c == ctlBreak {
+			return ctlNext, nil
+		}
+	}
+}
+
+// repeatStmt runs its body until cond, which can see the body's locals
+type repeatStmt struct {
+	body block
+	cond expr
+	line int
+}
+
+func (s *repeatStmt) exec(f *frame) (control, error) {
+	for {
+		if err := f.tick(s.line); err != nil {
+			return ctlNext, err
+		}
+		c, err := s.body.exec(f)
+		if err != nil || c == ctlReturn {
+			return c, err
+		}
+		if c == ctlBreak {
+			return ctlNext, nil
+		}
+		cond, err := s.cond.eval(f)
+		if err != nil || truthy(cond) {
+			return ctlNext, err
+		}
+	}
+}
+
+type ifStmt struct {
+	conds  []expr
+	blocks []block
+	// orElse is the else block, nil without one
+	orElse block
+}
+
+func (s *ifStmt) exec(f *frame) (control, error) {
+	for i, cond := range s.conds {
+		v, err := cond.eval(f)
+		if err != nil {
+			return ctlNext, err
+		}
+		if truthy(v) {
+			return s.blocks[i].exec(f)
+		}
+	}
+	return s.orElse.exec(f)
+}
+
+type numericForStmt struct {
+	slot               int
+	start, limit, step expr
+	body               block
+	line               int
+}
+
+func (s *numericForStmt) exec(f *frame) (control, error) {
+	var bounds [3]float64
+	for i, e := range []expr{s.start, s.limit, s.step} {
+		if e == nil {
+			bounds[i] = 1
+			continue
+		}
+		v, err := e.eval(f)
+		if err != nil {
+			return ctlNext, err
+		}
+		n, ok := toNumber(v)
+		if !ok {
+			return ctlNext, f.errorf(s.line, "'for' %s value must be a number", [3]string{"initial", "limit", "step"}[i])
+		}
+		bounds[i] = n
+	}
+	start, limit, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 {
+		return ctlNext, f.errorf(s.line, "'for' step is zero")
+	}
+	for i := start; step > 0 && i <= limit || step < 0 && i >= limit; i += step {
+		if err := f.tick(s.line); err != nil {
+			return ctlNext, err
+		}
+		v := Value(i)
+		f.slots[s.slot] = &v
+		c, err := s.body.exec(f)
+		if err != nil || c == ctlReturn {
+			return c, err
+		}
+		if c == ctlBreak {
+			break
+		}
+	}
+	return ctlNext, nil
+}
+
+type genericForStmt struct {
+	slots []int
+	exprs []expr
+	body  block
+	line  int
+}
+
+func (s *genericForStmt) exec(f *frame) (control, error) {
+	values, err := evalList(f, s.exprs)
+	if err != nil {
+		return ctlNext, err
+	}
+	values = append(values, nil, nil, nil)
+	iter, state, ctl := values[0], values[1], values[2]
+	for {
+		results, err := f.call(iter, []Value{state, ctl}, s.line, " (for iterator)")
+		if err != nil {
+			return ctlNext, err
+		}
+		if len(results) == 0 || results[0] == nil {
+			return ctlNext, nil
+		}
+		ctl = results[0]
+		for i, slot := range s.slots {
+			var v Value
+			if i < len(results) {
+				v = results[i]
+			}
+			f.slots[slot] = &v
+		}
+		c, err := s.body.exec(f)
+		if err != nil || c == ctlReturn {
+			return c, err
+		}
+		if c == ctlBreak {
+			return ctlNext, nil
+		}
+	}
+}
+
+type returnStmt struct{ exprs []expr }
+
+func (s *returnStmt) exec(f *frame) (control, error) {
+	values, err := evalList(f, s.exprs)
+	f.ret = values
+	return ctlReturn, err
+}
+
+type breakStmt struct{}
+
+func (breakStmt) exec(*frame) (control, error) { return ctlBreak, nil }
+
+// Errors
+
+// Error is an error raised in a script, by error with any value or by the
+// interpreter with a message saying where it went wrong
+type Error struct {
+	Value Value
+	// bare is set on messages from error and Go functions until the line
+	// they were raised on is put before them
+	bare bool
+	// notCallable is set when something that isn't a function was called
+	notCallable bool
+	// stop is set when the script ran out of steps or was interrupted,
+	// which pcall doesn't catch
+	stop bool
+}
+
+func (e *Error) Error() string {
+	return ToString(e.Value)
+}
+
+// Errorf returns an error for a Go function to raise, given the place it
+// was called from like one raised with error
+func Errorf(format string, args ...any) error {
+	return &Error{Value: fmt.Sprintf(format, args...), bare: true}
+}
+
+// callLua runs a Lua function with args and returns its results
+func (s *State) callLua(fn *Function, args []Value) ([]Value, error) {
+	p := fn.proto
+	f := &frame{state: s, fn: fn, slots: make([]*Value, p.slots)}
+	for i := range p.params {
+		var v Value
+		if i < len(args) {
+			v = args[i]
+		}
+		f.slots[i] = &v
+	}
+	if p.vararg && len(args) > p.params {
+		f.varargs = args[p.params:]
+	}
+	if _, err := p.body.exec(f); err != nil {
+		return nil, err
+	}
+	return f.ret, nil
+}
+
+// argError names the argument of a Go function that was wrong
+func argError(fn string, i int, msg string) error {
+	return Errorf("bad argument #%d to '%s' (%s)", i+1, fn, msg)
+}
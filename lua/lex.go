@@ -0,0 +1,340 @@
+package lua
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind is what sort of token a token is
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokNumber
+	tokString
+	// tokSymbol is a keyword or an operator, spelt out in text
+	tokSymbol
+)
+
+// token is a token of a chunk and the line it starts on
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	line int
+}
+
+// keywords are the reserved words, lexed as symbols
+var keywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true,
+	"end": true, "false": true, "for": true, "function": true, "goto": true,
+	"if": true, "in": true, "local": true, "nil": true, "not": true,
+	"or": true, "repeat": true, "return": true, "then": true, "true": true,
+	"until": true, "while": true,
+}
+
+// symbols are the operators and punctuation, longest first so the lexer
+// takes the longest that matches
+var symbols = []string{
+	"...", "..", "==", "~=", "<=", ">=", "//", "::", "<<", ">>",
+	"+", "-", "*", "/", "%", "^", "#", "&", "~", "|", "<", ">", "=",
+	"(", ")", "{", "}", "[", "]", ";", ":", ",", ".",
+}
+
+// lexer splits a chunk into tokens
+type lexer struct {
+	source string
+	src    string
+	pos    int
+	line   int
+}
+
+// lex returns the tokens of a chunk, ending with tokEOF
+func lex(source, src string) ([]token, error) {
+	l := &lexer{source: source, src: src, line: 1}
+	// A first line starting with # is a shebang line for Unix
+	if strings.HasPrefix(src, "#") {
+		for l.pos < len(src) && src[l.pos] != '\n' {
+			l.pos++
+		}
+	}
+	var tokens []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// errorf returns a syntax error on the current line
+func (l *lexer) errorf(format string, args ...any) error {
+	return &Error{Value: fmt.Sprintf("%s:%d: %s", l.source, l.line, fmt.Sprintf(format, args...))}
+}
+
+// next returns the next token, after skipping spaces and comments
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpace(); err != nil {
+		return token{}, err
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, line: l.line}, nil
+	}
+	line := l.line
+	c := l.src[l.pos]
+	switch {
+	case isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		name := l.src[start:l.pos]
+		if keywords[name] {
+			return token{kind: tokSymbol, text: name, line: line}, nil
+		}
+		return token{kind: tokName, text: name, line: line}, nil
+	case isDigit(c) || c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1]):
+		return l.number()
+	case c == '"' || c == '\'':
+		s, err := l.quoted(c)
+		return token{kind: tokString, text: s, line: line}, err
+	case c == '[' && l.longBracket() >= 0:
+		s, err := l.long()
+		return token{kind: tokString, text: s, line: line}, err
+	}
+	for _, sym := range symbols {
+		if strings.HasPrefix(l.src[l.pos:], sym) {
+			l.pos += len(sym)
+			return token{kind: tokSymbol, text: sym, line: line}, nil
+		}
+	}
+	return token{}, l.errorf("unexpected symbol %q", c)
+}
+
+// skipSpace moves past spaces, newlines and comments
+func (l *lexer) skipSpace() error {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			l.pos += 2
+			if l.pos < len(l.src) && l.src[l.pos] == '[' && l.longBracket() >= 0 {
+				if _, err := l.long(); err != nil {
+					return err
+				}
+				continue
+			}
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// number lexes a decimal or hexadecimal number
+func (l *lexer) number() (token, error) {
+	start, line := l.pos, l.line
+	hex := strings.HasPrefix(l.src[l.pos:], "0x") || strings.HasPrefix(l.src[l.pos:], "0X")
+	if hex {
+		l.pos += 2
+	}
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		exponent := !hex && (c == 'e' || c == 'E')
+		if exponent && l.pos+1 < len(l.src) && (l.src[l.pos+1] == '+' || l.src[l.pos+1] == '-') {
+			l.pos += 2
+			continue
+		}
+		if !isDigit(c) && c != '.' && !exponent && !(hex && isHexDigit(c)) && !isLetter(c) {
+			break
+		}
+		l.pos++
+	}
+	text := l.src[start:l.pos]
+	n, ok := parseNumber(text)
+	if !ok {
+		return token{}, l.errorf("malformed number near '%s'", text)
+	}
+	return token{kind: tokNumber, num: n, text: text, line: line}, nil
+}
+
+// quoted lexes a string in quotes, turning escapes into what they stand
+// for
+func (l *lexer) quoted(quote byte) (string, error) {
+	l.pos++
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return "", l.errorf("unfinished string")
+		}
+		c := l.src[l.pos]
+		l.pos++
+		if c == quote {
+			return b.String(), nil
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		if l.pos >= len(l.src) {
+			return "", l.errorf("unfinished string")
+		}
+		e := l.src[l.pos]
+		l.pos++
+		switch e {
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '"', '\'':
+			b.WriteByte(e)
+		case '\n':
+			l.line++
+			b.WriteByte('\n')
+		case 'x':
+			if l.pos+2 > len(l.src) {
+				return "", l.errorf("hexadecimal digit expected")
+			}
+			n, err := strconv.ParseUint(l.src[l.pos:l.pos+2], 16, 8)
+			if err != nil {
+				return "", l.errorf("hexadecimal digit expected")
+			}
+			b.WriteByte(byte(n))
+			l.pos += 2
+		case 'z':
+			for l.pos < len(l.src) && strings.IndexByte(" \t\r\n\f\v", l.src[l.pos]) >= 0 {
+				if l.src[l.pos] == '\n' {
+					l.line++
+				}
+				l.pos++
+			}
+		default:
+			if !isDigit(e) {
+				return "", l.errorf("invalid escape sequence '\\%c'", e)
+			}
+			n := int(e - '0')
+			for i := 0; i < 2 && l.pos < len(l.src) && isDigit(l.src[l.pos]); i++ {
+				n = n*10 + int(l.src[l.pos]-'0')
+				l.pos++
+			}
+			if n > 255 {
+				return "", l.errorf("decimal escape too large")
+			}
+			b.WriteByte(byte(n))
+		}
+	}
+}
+
+// longBracket returns the level of the long bracket [==[ at the current
+// position, how many equals signs it has, or -1 if there isn't one
+func (l *lexer) longBracket() int {
+	i := l.pos + 1
+	for i < len(l.src) && l.src[i] == '=' {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == '[' {
+		return i - l.pos - 1
+	}
+	return -1
+}
+
+// long lexes a string or comment in long brackets, which runs to the
+// closing bracket of the same level with no escapes
+func (l *lexer) long() (string, error) {
+	level := l.longBracket()
+	l.pos += level + 2
+	// A newline straight after the opening bracket isn't part of it
+	if strings.HasPrefix(l.src[l.pos:], "\r\n") {
+		l.pos += 2
+		l.line++
+	} else if strings.HasPrefix(l.src[l.pos:], "\n") {
+		l.pos++
+		l.line++
+	}
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(l.src[l.pos:], closing)
+	if end < 0 {
+		return "", l.errorf("unfinished long string or comment")
+	}
+	s := l.src[l.pos : l.pos+end]
+	l.line += strings.Count(s, "\n")
+	l.pos += end + len(closing)
+	return s, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// parseNumber parses a number as written in Lua, decimal or hexadecimal
+// with 0x, and with spaces around it as tonumber allows
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	neg := false
+	if t, ok := strings.CutPrefix(s, "-"); ok {
+		neg, s = true, t
+	}
+	var n float64
+	if t, ok := strings.CutPrefix(strings.ToLower(s), "0x"); ok {
+		if t == "" {
+			return 0, false
+		}
+		for _, c := range []byte(t) {
+			if !isHexDigit(c) {
+				return 0, false
+			}
+			d, _ := strconv.ParseUint(string(c), 16, 8)
+			n = n*16 + float64(d)
+		}
+	} else {
+		// ParseFloat takes forms Lua doesn't, such as inf and 1_000
+		if s == "" {
+			return 0, false
+		}
+		for _, c := range []byte(s) {
+			if !isDigit(c) && !strings.ContainsRune(".eE+-", rune(c)) {
+				return 0, false
+			}
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		n = f
+	}
+	if neg {
+		n = -n
+	}
+	return n, true
+}
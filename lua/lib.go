@@ -0,0 +1,655 @@
+package lua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openLibs loads the standard library into the globals
+func (s *State) openLibs() {
+	base := map[string]GoFunction{
+		"print":    s.print,
+		"type":     luaType,
+		"tostring": luaToString,
+		"tonumber": luaToNumber,
+		"pairs":    luaPairs,
+		"ipairs":   luaIpairs,
+		"select":   luaSelect,
+		"error":    luaError,
+		"assert":   luaAssert,
+		"pcall":    s.pcall,
+		"unpack":   luaUnpack,
+	}
+	for name, fn := range base {
+		s.Register(name, fn)
+	}
+	s.SetGlobal("_G", s.globals)
+	s.SetGlobal("_VERSION", "Lua 5.3")
+
+	s.openLib("math", map[string]GoFunction{
+		"floor":      mathFunc("floor", math.Floor),
+		"ceil":       mathFunc("ceil", math.Ceil),
+		"abs":        mathFunc("abs", math.Abs),
+		"sqrt":       mathFunc("sqrt", math.Sqrt),
+		"sin":        mathFunc("sin", math.Sin),
+		"cos":        mathFunc("cos", math.Cos),
+		"tan":        mathFunc("tan", math.Tan),
+		"exp":        mathFunc("exp", math.Exp),
+		"log":        mathLog,
+		"max":        mathMax,
+		"min":        mathMin,
+		"fmod":       mathFmod,
+		"random":     s.mathRandom,
+		"randomseed": s.mathRandomSeed,
+	})
+	m := s.Global("math").(*Table)
+	m.Set("huge", math.Inf(1))
+	m.Set("pi", math.Pi)
+
+	s.openLib("string", map[string]GoFunction{
+		"len":     strLen,
+		"sub":     strSub,
+		"upper":   strFunc("upper", strings.ToUpper),
+		"lower":   strFunc("lower", strings.ToLower),
+		"rep":     strRep,
+		"reverse": strFunc("reverse", reverse),
+		"format":  strFormat,
+		"byte":    strByte,
+		"char":    strChar,
+	})
+	s.stringLib = s.Global("string").(*Table)
+
+	s.openLib("table", map[string]GoFunction{
+		"insert": tableInsert,
+		"remove": tableRemove,
+		"concat": tableConcat,
+		"unpack": luaUnpack,
+	})
+
+	start := time.Now()
+	s.openLib("os", map[string]GoFunction{
+		"clock": func([]Value) ([]Value, error) {
+			return []Value{time.Since(start).Seconds()}, nil
+		},
+		"time": func([]Value) ([]Value, error) {
+			return []Value{float64(time.Now().Unix())}, nil
+		},
+	})
+}
+
+// openLib sets a global table of functions, each named name.field in error
+// messages
+func (s *State) openLib(name string, fns map[string]GoFunction) {
+	t := NewTable()
+	for field, fn := range fns {
+		t.Set(field, NewFunction(name+"."+field, fn))
+	}
+	s.SetGlobal(name, t)
+}
+
+// Base functions
+
+func (s *State) print(args []Value) ([]Value, error) {
+	parts := make([]string, len(args))
+	for i, v := range args {
+		parts[i] = ToString(v)
+	}
+	_, err := fmt.Fprintln(s.out, strings.Join(parts, "\t"))
+	return nil, err
+}
+
+func luaType(args []Value) ([]Value, error) {
+	if len(args) == 0 {
+		return nil, argError("type", 0, "value expected")
+	}
+	return []Value{TypeName(args[0])}, nil
+}
+
+func luaToString(args []Value) ([]Value, error) {
+	if len(args) == 0 {
+		return nil, argError("tostring", 0, "value expected")
+	}
+	return []Value{ToString(args[0])}, nil
+}
+
+func luaToNumber(args []Value) ([]Value, error) {
+	if len(args) == 0 {
+		return nil, argError("tonumber", 0, "value expected")
+	}
+	if len(args) < 2 || args[1] == nil {
+		if n, ok := toNumber(args[0]); ok {
+			return []Value{n}, nil
+		}
+		return []Value{nil}, nil
+	}
+	base, err := CheckInt("tonumber", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if base < 2 || base > 36 {
+		return nil, argError("tonumber", 1, "base out of range")
+	}
+	str, err := CheckString("tonumber", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(str), base, 64)
+	if err != nil {
+		return []Value{nil}, nil
+	}
+	return []Value{float64(n)}, nil
+}
+
+// luaPairs goes through the keys the table had when it was called, skipping
+// any that have been removed since
+func luaPairs(args []Value) ([]Value, error) {
+	t, err := CheckTable("pairs", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := t.keys()
+	i := 0
+	next := func([]Value) ([]Value, error) {
+		for i < len(keys) {
+			k := keys[i]
+			i++
+			if v := t.Get(k); v != nil {
+				return []Value{k, v}, nil
+			}
+		}
+		return []Value{nil}, nil
+	}
+	return []Value{NewFunction("next", next), t, nil}, nil
+}
+
+func luaIpairs(args []Value) ([]Value, error) {
+	t, err := CheckTable("ipairs", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	next := func(args []Value) ([]Value, error) {
+		i := args[1].(float64) + 1
+		v := t.Get(i)
+		if v == nil {
+			return []Value{nil}, nil
+		}
+		return []Value{i, v}, nil
+	}
+	return []Value{NewFunction("ipairs_next", next), t, 0.0}, nil
+}
+
+func luaSelect(args []Value) ([]Value, error) {
+	if len(args) > 0 && args[0] == "#" {
+		return []Value{float64(len(args) - 1)}, nil
+	}
+	n, err := CheckInt("select", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case n < 0:
+		n += len(args)
+		if n < 1 {
+			return nil, argError("select", 0, "index out of range")
+		}
+	case n == 0:
+		return nil, argError("select", 0, "index out of range")
+	case n >= len(args):
+		return nil, nil
+	}
+	return args[n:], nil
+}
+
+// luaError raises its argument, which messages have the line of the call to
+// error put before them
+func luaError(args []Value) ([]Value, error) {
+	var v Value
+	if len(args) > 0 {
+		v = args[0]
+	}
+	_, message := v.(string)
+	return nil, &Error{Value: v, bare: message}
+}
+
+func luaAssert(args []Value) ([]Value, error) {
+	if len(args) == 0 {
+		return nil, argError("assert", 0, "value expected")
+	}
+	if truthy(args[0]) {
+		return args, nil
+	}
+	if len(args) > 1 {
+		return nil, &Error{Value: args[1]}
+	}
+	return nil, Errorf("assertion failed!")
+}
+
+// pcall calls a function and returns true and its results, or false and the
+// error it raised
+func (s *State) pcall(args []Value) ([]Value, error) {
+	if len(args) == 0 {
+		return nil, argError("pcall", 0, "value expected")
+	}
+	results, err := s.call(args[0], args[1:])
+	if err == nil {
+		return append([]Value{true}, results...), nil
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return []Value{false, err.Error()}, nil
+	}
+	if e.stop {
+		return nil, err
+	}
+	if e.notCallable {
+		return []Value{false, "attempt to call a " + TypeName(args[0]) + " value"}, nil
+	}
+	return []Value{false, e.Value}, nil
+}
+
+func luaUnpack(args []Value) ([]Value, error) {
+	t, err := CheckTable("unpack", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	i, err := OptInt("unpack", args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	j, err := OptInt("unpack", args, 2, t.Len())
+	if err != nil {
+		return nil, err
+	}
+	if j-i >= 1<<20 {
+		return nil, Errorf("too many results to unpack")
+	}
+	var results []Value
+	for k := i; k <= j; k++ {
+		results = append(results, t.Get(float64(k)))
+	}
+	return results, nil
+}
+
+// Math
+
+// mathFunc makes a function of one number
+func mathFunc(name string, fn func(float64) float64) GoFunction {
+	return func(args []Value) ([]Value, error) {
+		x, err := CheckNumber(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []Value{fn(x)}, nil
+	}
+}
+
+func mathLog(args []Value) ([]Value, error) {
+	x, err := CheckNumber("log", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 2 || args[1] == nil {
+		return []Value{math.Log(x)}, nil
+	}
+	base, err := CheckNumber("log", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return []Value{math.Log(x) / math.Log(base)}, nil
+}
+
+func mathMax(args []Value) ([]Value, error) {
+	return mathPick("max", args, func(a, b float64) bool { return a > b })
+}
+
+func mathMin(args []Value) ([]Value, error) {
+	return mathPick("min", args, func(a, b float64) bool { return a < b })
+}
+
+// mathPick returns the argument that beats all the others
+func mathPick(name string, args []Value, beats func(a, b float64) bool) ([]Value, error) {
+	best, err := CheckNumber(name, args, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(args); i++ {
+		x, err := CheckNumber(name, args, i)
+		if err != nil {
+			return nil, err
+		}
+		if beats(x, best) {
+			best = x
+		}
+	}
+	return []Value{best}, nil
+}
+
+func mathFmod(args []Value) ([]Value, error) {
+	a, err := CheckNumber("fmod", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	b, err := CheckNumber("fmod", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return []Value{math.Mod(a, b)}, nil
+}
+
+// mathRandom returns a number in [0,1) with no arguments, in [1,m] with one
+// and in [m,n] with two
+func (s *State) mathRandom(args []Value) ([]Value, error) {
+	if len(args) == 0 {
+		return []Value{s.random.Float64()}, nil
+	}
+	lo, hi := 1, 0
+	var err error
+	if len(args) == 1 {
+		hi, err = CheckInt("random", args, 0)
+	} else {
+		lo, err = CheckInt("random", args, 0)
+		if err == nil {
+			hi, err = CheckInt("random", args, 1)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lo > hi {
+		return nil, argError("random", len(args)-1, "interval is empty")
+	}
+	return []Value{float64(lo + s.random.Intn(hi-lo+1))}, nil
+}
+
+func (s *State) mathRandomSeed(args []Value) ([]Value, error) {
+	seed, err := CheckNumber("randomseed", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.random.Seed(int64(seed))
+	return nil, nil
+}
+
+// Strings
+
+// strFunc makes a function of one string
+func strFunc(name string, fn func(string) string) GoFunction {
+	return func(args []Value) ([]Value, error) {
+		str, err := CheckString(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []Value{fn(str)}, nil
+	}
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+func strLen(args []Value) ([]Value, error) {
+	str, err := CheckString("len", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return []Value{float64(len(str))}, nil
+}
+
+// strRange turns the Lua positions i and j, counting from 1 or back from
+// the end when negative, into a slice of a string of length n
+func strRange(i, j, n int) (int, int) {
+	if i < 0 {
+		i = max(n+i+1, 1)
+	} else if i == 0 {
+		i = 1
+	}
+	if j < 0 {
+		j = n + j + 1
+	} else if j > n {
+		j = n
+	}
+	if i > j {
+		return 0, 0
+	}
+	return i - 1, j
+}
+
+func strSub(args []Value) ([]Value, error) {
+	str, err := CheckString("sub", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	i, err := OptInt("sub", args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	j, err := OptInt("sub", args, 2, -1)
+	if err != nil {
+		return nil, err
+	}
+	from, to := strRange(i, j, len(str))
+	return []Value{str[from:to]}, nil
+}
+
+func strRep(args []Value) ([]Value, error) {
+	str, err := CheckString("rep", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	n, err := CheckInt("rep", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	sep := ""
+	if len(args) > 2 && args[2] != nil {
+		if sep, err = CheckString("rep", args, 2); err != nil {
+			return nil, err
+		}
+	}
+	if n <= 0 {
+		return []Value{""}, nil
+	}
+	if (len(str)+len(sep))*n > 1<<28 {
+		return nil, Errorf("resulting string too large")
+	}
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = str
+	}
+	return []Value{strings.Join(parts, sep)}, nil
+}
+
+func strByte(args []Value) ([]Value, error) {
+	str, err := CheckString("byte", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	i, err := OptInt("byte", args, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	j, err := OptInt("byte", args, 2, i)
+	if err != nil {
+		return nil, err
+	}
+	from, to := strRange(i, j, len(str))
+	var results []Value
+	for _, c := range []byte(str[from:to]) {
+		results = append(results, float64(c))
+	}
+	return results, nil
+}
+
+func strChar(args []Value) ([]Value, error) {
+	b := make([]byte, len(args))
+	for i := range args {
+		c, err := CheckInt("char", args, i)
+		if err != nil {
+			return nil, err
+		}
+		if c < 0 || c > 255 {
+			return nil, argError("char", i, "value out of range")
+		}
+		b[i] = byte(c)
+	}
+	return []Value{string(b)}, nil
+}
+
+// strFormat formats its arguments as C's printf does, with the verbs
+// d i u c x X o e E f g G q s and %
+func strFormat(args []Value) ([]Value, error) {
+	format, err := CheckString("format", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	arg := 1
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+ #0123456789.", format[i]) >= 0 {
+			i++
+		}
+		if i >= len(format) {
+			return nil, Errorf("invalid conversion '%s' to 'format'", format[start:])
+		}
+		spec, verb := format[start:i], format[i]
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if arg >= len(args) {
+			return nil, argError("format", arg, "no value")
+		}
+		switch verb {
+		case 'd', 'i', 'u', 'c', 'x', 'X', 'o':
+			n, err := CheckInt("format", args, arg)
+			if err != nil {
+				return nil, err
+			}
+			switch verb {
+			case 'i', 'u':
+				verb = 'd'
+			case 'c':
+				spec = "%"
+			}
+			fmt.Fprintf(&b, spec+string(verb), n)
+		case 'e', 'E', 'f', 'g', 'G':
+			n, err := CheckNumber("format", args, arg)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&b, spec+string(verb), n)
+		case 'q':
+			str, err := CheckString("format", args, arg)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(strconv.Quote(str))
+		case 's':
+			fmt.Fprintf(&b, spec+"s", ToString(args[arg]))
+		default:
+			return nil, Errorf("invalid conversion '%s' to 'format'", format[start:i+1])
+		}
+		arg++
+	}
+	return []Value{b.String()}, nil
+}
+
+// Tables
+
+func tableInsert(args []Value) ([]Value, error) {
+	t, err := CheckTable("insert", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	n := t.Len()
+	switch len(args) {
+	case 2:
+		t.Set(float64(n+1), args[1])
+	case 3:
+		pos, err := CheckInt("insert", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if pos < 1 || pos > n+1 {
+			return nil, argError("insert", 1, "position out of bounds")
+		}
+		for i := n; i >= pos; i-- {
+			t.Set(float64(i+1), t.Get(float64(i)))
+		}
+		t.Set(float64(pos), args[2])
+	default:
+		return nil, Errorf("wrong number of arguments to 'insert'")
+	}
+	return nil, nil
+}
+
+func tableRemove(args []Value) ([]Value, error) {
+	t, err := CheckTable("remove", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	n := t.Len()
+	pos, err := OptInt("remove", args, 1, n)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 && (pos == 0 || pos == n) {
+		return []Value{t.Get(float64(pos))}, nil
+	}
+	if pos < 1 || pos > n+1 {
+		return nil, argError("remove", 1, "position out of bounds")
+	}
+	removed := t.Get(float64(pos))
+	if pos > n {
+		return []Value{removed}, nil
+	}
+	for i := pos; i < n; i++ {
+		t.Set(float64(i), t.Get(float64(i+1)))
+	}
+	t.Set(float64(n), nil)
+	return []Value{removed}, nil
+}
+
+func tableConcat(args []Value) ([]Value, error) {
+	t, err := CheckTable("concat", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep := ""
+	if len(args) > 1 && args[1] != nil {
+		if sep, err = CheckString("concat", args, 1); err != nil {
+			return nil, err
+		}
+	}
+	i, err := OptInt("concat", args, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	j, err := OptInt("concat", args, 3, t.Len())
+	if err != nil {
+		return nil, err
+	}
+	var parts []string
+	for k := i; k <= j; k++ {
+		s, ok := concatString(t.Get(float64(k)))
+		if !ok {
+			return nil, Errorf("invalid value (at index %d) in table for 'concat'", k)
+		}
+		parts = append(parts, s)
+	}
+	return []Value{strings.Join(parts, sep)}, nil
+}
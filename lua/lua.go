@@ -0,0 +1,208 @@
+// Package lua runs scripts written in a subset of Lua 5.3, enough for short
+// scripts that build patterns and edit the grid. Running Golly's own
+// scripts is not a goal: they need string patterns and Golly's g library,
+// and taking on a full Lua as a dependency for that isn't worth it. The
+// functions a program registers can be named after Golly's so short
+// scripts port by hand. It is not a full Lua:
+//
+//   - goto and labels, bitwise operators, metatables and coroutines are
+//     missing, and so are setmetatable, getmetatable and the coroutine
+//     library
+//   - numbers are all floats, with no integer subtype. math.type and
+//     math.tointeger are missing, 7 // 0 is inf rather than an error and
+//     whole numbers above 2^53 lose precision. Whole numbers print without
+//     a fraction.
+//   - the string library has no patterns, so find, match, gmatch and gsub
+//     are missing
+//   - the rest of the standard library is the base functions and the parts
+//     of math, string, table and os that New loads
+//
+// Errors read as Lua's do, with the line and the variable or field at
+// fault. SetLimit and SetInterrupt stop a script that runs too long, which
+// pcall can't catch.
+//
+// Programs give scripts functions of their own with Register, such as ones
+// that edit the grid:
+//
+//	s := lua.New(os.Stdout)
+//	s.Register("setcell", func(args []lua.Value) ([]lua.Value, error) { ... })
+//	err := s.DoFile("glider.lua")
+package lua
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+)
+
+// State runs scripts and keeps their global variables
+type State struct {
+	globals *Table
+	// stringLib is where methods called on strings are found
+	stringLib *Table
+	out       io.Writer
+	random    *rand.Rand
+	depth     int
+	// steps counts calls and turns of loops, which stop the script past
+	// limit unless it is 0
+	steps     int
+	limit     int
+	interrupt func() error
+}
+
+// New returns a state with the standard library loaded, whose print writes
+// to out
+func New(out io.Writer) *State {
+	s := &State{
+		globals:   NewTable(),
+		stringLib: NewTable(),
+		out:       out,
+		random:    rand.New(rand.NewSource(0)),
+	}
+	s.openLibs()
+	return s
+}
+
+// Register makes fn a global function called name
+func (s *State) Register(name string, fn GoFunction) {
+	s.globals.Set(name, NewFunction(name, fn))
+}
+
+// SetLimit stops scripts with an error once they have run n steps in all,
+// each a call or a turn of a loop. 0, the default, is no limit.
+func (s *State) SetLimit(n int) {
+	s.limit = n
+}
+
+// SetInterrupt sets a function called every so many steps while scripts
+// run. An error from it stops the script, for a deadline or a cancel.
+func (s *State) SetInterrupt(fn func() error) {
+	s.interrupt = fn
+}
+
+// SetGlobal sets a global variable
+func (s *State) SetGlobal(name string, v Value) {
+	s.globals.Set(name, v)
+}
+
+// Global returns a global variable, nil when it isn't set
+func (s *State) Global(name string) Value {
+	return s.globals.Get(name)
+}
+
+// DoString runs a chunk of Lua, named name in error messages
+func (s *State) DoString(name, src string) error {
+	proto, err := parse(name, src)
+	if err != nil {
+		return err
+	}
+	_, err = s.callLua(&Function{name: proto.name, proto: proto}, nil)
+	return err
+}
+
+// DoFile runs the Lua file at path
+func (s *State) DoFile(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return s.DoString(path, string(src))
+}
+
+// Call calls a function value with args and returns its results
+func (s *State) Call(fn Value, args ...Value) ([]Value, error) {
+	return s.call(fn, args)
+}
+
+func (s *State) call(fn Value, args []Value) ([]Value, error) {
+	f, ok := fn.(*Function)
+	if !ok {
+		return nil, &Error{Value: fmt.Sprintf("attempt to call a %s value", TypeName(fn)), notCallable: true}
+	}
+	if s.depth >= maxDepth {
+		return nil, Errorf("stack overflow")
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+	if f.native != nil {
+		return f.native(args)
+	}
+	return s.callLua(f, args)
+}
+
+// Argument checks for Go functions, which return errors naming the function
+// and the argument, counting from 0
+
+// CheckNumber returns argument i as a number
+func CheckNumber(fn string, args []Value, i int) (float64, error) {
+	var v Value
+	if i < len(args) {
+		v = args[i]
+	}
+	n, ok := toNumber(v)
+	if !ok {
+		return 0, argError(fn, i, fmt.Sprintf("number expected, got %s", typeNameOrNoValue(args, i)))
+	}
+	return n, nil
+}
+
+// OptNumber returns argument i as a number, or def when it is nil or
+// missing
+func OptNumber(fn string, args []Value, i int, def float64) (float64, error) {
+	if i >= len(args) || args[i] == nil {
+		return def, nil
+	}
+	return CheckNumber(fn, args, i)
+}
+
+// CheckInt returns argument i as a whole number
+func CheckInt(fn string, args []Value, i int) (int, error) {
+	n, err := CheckNumber(fn, args, i)
+	if err != nil {
+		return 0, err
+	}
+	if n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+		return 0, argError(fn, i, "number has no integer representation")
+	}
+	return int(n), nil
+}
+
+// OptInt returns argument i as a whole number, or def when it is nil or
+// missing
+func OptInt(fn string, args []Value, i int, def int) (int, error) {
+	if i >= len(args) || args[i] == nil {
+		return def, nil
+	}
+	return CheckInt(fn, args, i)
+}
+
+// CheckString returns argument i as a string, numbers included
+func CheckString(fn string, args []Value, i int) (string, error) {
+	if i < len(args) {
+		if s, ok := concatString(args[i]); ok {
+			return s, nil
+		}
+	}
+	return "", argError(fn, i, fmt.Sprintf("string expected, got %s", typeNameOrNoValue(args, i)))
+}
+
+// CheckTable returns argument i as a table
+func CheckTable(fn string, args []Value, i int) (*Table, error) {
+	if i < len(args) {
+		if t, ok := args[i].(*Table); ok {
+			return t, nil
+		}
+	}
+	return nil, argError(fn, i, fmt.Sprintf("table expected, got %s", typeNameOrNoValue(args, i)))
+}
+
+// typeNameOrNoValue is the type of argument i, or no value when there are
+// fewer arguments
+func typeNameOrNoValue(args []Value, i int) string {
+	if i >= len(args) {
+		return "no value"
+	}
+	return TypeName(args[i])
+}
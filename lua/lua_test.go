@@ -0,0 +1,189 @@
+package lua
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDoString(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"print", `print("a", 1, 2.5, nil, true, 7 // 2, 2 ^ 10, -7 % 3)`, "a\t1\t2.5\tnil\ttrue\t3\t1024\t2\n"},
+		{"recursion", `
+			local function fib(n)
+				if n < 2 then return n end
+				return fib(n - 1) + fib(n - 2)
+			end
+			print(fib(20))`, "6765\n"},
+		{"closures", `
+			local function counter()
+				local n = 0
+				return function() n = n + 1; return n end
+			end
+			local a, b = counter(), counter()
+			print(a(), a(), b())`, "1\t2\t1\n"},
+		{"loop variables are fresh each time", `
+			local fs = {}
+			for i = 1, 3 do fs[i] = function() return i end end
+			print(fs[1](), fs[2](), fs[3]())`, "1\t2\t3\n"},
+		{"loops", `
+			local s = 0
+			for i = 10, 1, -2 do s = s + i end
+			local n = 0
+			repeat local m = n; n = n + 1 until m >= 3
+			while true do n = n + 1; if n > 10 then break end end
+			print(s, n)`, "30\t11\n"},
+		{"tables", `
+			local t = {1, 2, 3, x = 4, [10] = 5}
+			t[4] = 6
+			local keys = {}
+			for k in pairs(t) do keys[#keys + 1] = tostring(k) end
+			print(#t, t.x, t[10], table.concat(keys, ","))`, "4\t4\t5\t1,2,3,4,10,x\n"},
+		{"length with holes", `
+			local t = {1, 2, nil, 4}
+			t[4] = nil
+			local u = {[1] = 5, [2] = 6, 7}
+			print(#{1, 2, nil, 4}, #{nil, nil, 3}, #{1, nil}, #t, #u, u[1], u[2])`, "4\t3\t1\t2\t2\t7\t6\n"},
+		{"not in the subset", `
+			print(setmetatable, getmetatable, coroutine, string.find, string.gsub, math.type)
+			print(7 // 0, 2^53 + 1 == 2^53, 3 / 2, 6 / 2)`, "nil\tnil\tnil\tnil\tnil\tnil\ninf\ttrue\t1.5\t3\n"},
+		{"methods", `
+			local cell = {x = 1}
+			function cell:move(dx) self.x = self.x + dx; return self end
+			print(cell:move(2):move(3).x, ("glider"):upper(), ("glider"):sub(2, -2))`, "6\tGLIDER\tlide\n"},
+		{"varargs", `
+			local function count(...) return select("#", ...), ... end
+			print(count(1, nil, 3))`, "3\t1\tnil\t3\n"},
+		{"format", `print(string.format("%d cells, %5.1f%% %s", 42, 12.25, "alive"))`, "42 cells,  12.2% alive\n"},
+		{"coercion", `print("10" + 1, 1 .. 2, tonumber("0x10"), tonumber("z"), 1 == 1.0, "1" == 1)`, "11\t12\t16\tnil\ttrue\tfalse\n"},
+		{"pcall", `
+			print(pcall(function() error("boom") end))
+			print(pcall(function() error({}) end) == false)
+			print(pcall(math.floor, "x"))`,
+			"false\ttest:2: boom\ntrue\nfalse\tbad argument #1 to 'floor' (number expected, got string)\n"},
+		{"stack overflow", `
+			local function f() return f() end
+			print(pcall(f))`, "false\ttest:2: stack overflow\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := New(&out).DoString("test", tt.script); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"x = = 1", "test:1: unexpected symbol near '='"},
+		{"if x then\nprint(1)\n", "test:3: 'end' expected (to close 'if' at line 1) near <eof>"},
+		{"local s = \"cells\n", "test:1: unfinished string"},
+		{"goto done", "test:1: goto and labels are not supported"},
+		{"\n\nundefined()", "test:3: attempt to call a nil value (global 'undefined')"},
+		{"return 1 & 2\n", "test:1: bitwise operators are not supported"},
+		{"return ~1", "test:1: bitwise operators are not supported"},
+		{"local t = nil\nprint(t.x)", "test:2: attempt to index a nil value (local 't')"},
+		{"return ({})[1].x", "test:1: attempt to index a nil value (field '?')"},
+		{"local t = {}\nt.a.b = 1", "test:2: attempt to index a nil value (field 'a')"},
+		{"local up\nlocal function f() return up.x end\nf()", "test:2: attempt to index a nil value (upvalue 'up')"},
+		{"return cells.x", "test:1: attempt to index a nil value (global 'cells')"},
+		{"local s\ns:move()", "test:2: attempt to index a nil value (local 's')"},
+		{"local t = {}\nt[1]()", "test:2: attempt to call a nil value (field '?')"},
+		{"local f\nf()", "test:2: attempt to call a nil value (local 'f')"},
+		{"setmetatable({}, {})", "test:1: attempt to call a nil value (global 'setmetatable')"},
+		{"return ('glider'):gsub('g', 'G')", "test:1: attempt to call a nil value (method 'gsub')"},
+		{"return {} .. 'x'", "test:1: attempt to concatenate a table value"},
+		{"return #nil", "test:1: attempt to get length of a nil value"},
+		{"local t = {}\nt[nil] = 1", "test:2: table index is nil"},
+		{"return 1 + {}", "test:1: attempt to perform arithmetic on a table value"},
+		{"return 1 < 'a'", "test:1: attempt to compare number with string"},
+		{"error('stop')", "test:1: stop"},
+		{"string.rep()", "test:1: bad argument #1 to 'rep' (string expected, got no value)"},
+	}
+	for _, tt := range tests {
+		err := New(&strings.Builder{}).DoString("test", tt.script)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: got error %v, want %s", tt.script, err, tt.want)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	s := New(&strings.Builder{})
+	cells := map[[2]int]bool{}
+	s.Register("setcell", func(args []Value) ([]Value, error) {
+		x, err := CheckInt("setcell", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		y, err := CheckInt("setcell", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		cells[[2]int{x, y}] = true
+		return nil, nil
+	})
+	if err := s.DoString("test", "for x = 1, 3 do setcell(x, 2) end"); err != nil {
+		t.Fatal(err)
+	}
+	if len(cells) != 3 || !cells[[2]int{1, 2}] || !cells[[2]int{3, 2}] {
+		t.Errorf("set %v, want a row of three at y 2", cells)
+	}
+	err := s.DoString("test", "\nsetcell(1.5, 2)")
+	if want := "test:2: bad argument #1 to 'setcell' (number has no integer representation)"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+
+	if err := s.DoString("test", "gen = 7"); err != nil {
+		t.Fatal(err)
+	}
+	if gen := s.Global("gen"); gen != 7.0 {
+		t.Errorf("gen is %v, want 7", gen)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"while true do end", "test:1: script ran for more than 1000 steps"},
+		{"\nrepeat until false", "test:2: script ran for more than 1000 steps"},
+		{"for i = 1, math.huge do end", "test:1: script ran for more than 1000 steps"},
+		{"local function f() end\nfor i = 1, 10 do end\nwhile true do f() end", "test:3: script ran for more than 1000 steps"},
+		{"while true do pcall(function() while true do end end) end", "test:1: script ran for more than 1000 steps"},
+	}
+	for _, tt := range tests {
+		s := New(&strings.Builder{})
+		s.SetLimit(1000)
+		err := s.DoString("test", tt.script)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: got error %v, want %s", tt.script, err, tt.want)
+		}
+	}
+
+	s := New(&strings.Builder{})
+	calls := 0
+	s.SetInterrupt(func() error {
+		if calls++; calls == 3 {
+			return errors.New("interrupted")
+		}
+		return nil
+	})
+	err := s.DoString("test", "while true do pcall(print) end")
+	if want := "test:1: interrupted"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %s", err, want)
+	}
+}
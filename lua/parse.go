@@ -0,0 +1,793 @@
+package lua
+
+import "fmt"
+
+// parser builds the statements of a chunk from its tokens, working out
+// for each name whether it is a local, an upvalue or a global
+type parser struct {
+	source string
+	tokens []token
+	pos    int
+	fs     *funcState
+}
+
+// funcState is the function being parsed
+type funcState struct {
+	parent *funcState
+	proto  *funcProto
+	// actives are the locals in scope, innermost last
+	actives []localVar
+}
+
+// localVar is a local variable in scope and the slot it is kept in
+type localVar struct {
+	name string
+	slot int
+}
+
+// binaryPriority is how tightly each binary operator binds on its left and
+// right, as in the Lua reference implementation
+var binaryPriority = map[string][2]int{
+	"or": {1, 1}, "and": {2, 2},
+	"<": {3, 3}, ">": {3, 3}, "<=": {3, 3}, ">=": {3, 3}, "~=": {3, 3}, "==": {3, 3},
+	"|": {4, 4}, "~": {5, 5}, "&": {6, 6}, "<<": {7, 7}, ">>": {7, 7},
+	"..": {9, 8}, "+": {10, 10}, "-": {10, 10},
+	"*": {11, 11}, "/": {11, 11}, "//": {11, 11}, "%": {11, 11},
+	"^": {14, 13},
+}
+
+// unaryPriority is how tightly not, - and # bind
+const unaryPriority = 12
+
+// parse parses a chunk into the function that runs it
+func parse(source, src string) (*funcProto, error) {
+	tokens, err := lex(source, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{source: source, tokens: tokens}
+	proto := &funcProto{name: "main chunk", source: source, line: 0, vararg: true}
+	p.fs = &funcState{proto: proto}
+	if proto.body, err = p.block(); err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("'<eof>' expected near %s", p.describe(p.peek()))
+	}
+	return proto, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the next token is the keyword or operator sym
+func (p *parser) is(sym string) bool {
+	t := p.peek()
+	return t.kind == tokSymbol && t.text == sym
+}
+
+// accept moves past the next token if it is sym and reports whether it was
+func (p *parser) accept(sym string) bool {
+	if p.is(sym) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+// expect moves past sym, which has to be next
+func (p *parser) expect(sym string) error {
+	if !p.accept(sym) {
+		return p.errorf("'%s' expected near %s", sym, p.describe(p.peek()))
+	}
+	return nil
+}
+
+// expectClosing moves past the sym that closes what opened on line
+func (p *parser) expectClosing(sym, opening string, line int) error {
+	if p.accept(sym) {
+		return nil
+	}
+	if line == p.peek().line {
+		return p.expect(sym)
+	}
+	return p.errorf("'%s' expected (to close '%s' at line %d) near %s", sym, opening, line, p.describe(p.peek()))
+}
+
+func (p *parser) name() (string, error) {
+	t := p.peek()
+	if t.kind != tokName {
+		return "", p.errorf("<name> expected near %s", p.describe(t))
+	}
+	p.advance()
+	return t.text, nil
+}
+
+// describe names a token for a syntax error
+func (p *parser) describe(t token) string {
+	if t.kind == tokEOF {
+		return "<eof>"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &Error{Value: fmt.Sprintf("%s:%d: %s", p.source, p.peek().line, fmt.Sprintf(format, args...))}
+}
+
+// Scopes
+
+// declare brings a local into scope in the next free slot
+func (p *parser) declare(name string) int {
+	fs := p.fs
+	slot := len(fs.actives)
+	fs.actives = append(fs.actives, localVar{name, slot})
+	fs.proto.slots = max(fs.proto.slots, len(fs.actives))
+	return slot
+}
+
+// openScope returns what closeScope needs to end the scope it starts
+func (p *parser) openScope() int {
+	return len(p.fs.actives)
+}
+
+func (p *parser) closeScope(scope int) {
+	p.fs.actives = p.fs.actives[:scope]
+}
+
+// resolve returns the variable a name refers to
+func (p *parser) resolve(name string) expr {
+	if slot, ok := p.fs.local(name); ok {
+		return &localExpr{slot, name}
+	}
+	if i, ok := p.fs.upval(name); ok {
+		return &upvalExpr{i, name}
+	}
+	return &globalExpr{name}
+}
+
+// local finds the innermost local named name
+func (fs *funcState) local(name string) (int, bool) {
+	for i := len(fs.actives) - 1; i >= 0; i-- {
+		if fs.actives[i].name == name {
+			return fs.actives[i].slot, true
+		}
+	}
+	return 0, false
+}
+
+// upval finds a variable of an enclosing function, adding it to this
+// function's upvalues the first time
+func (fs *funcState) upval(name string) (int, bool) {
+	for i, u := range fs.proto.upvals {
+		if u.name == name {
+			return i, true
+		}
+	}
+	if fs.parent == nil {
+		return 0, false
+	}
+	desc := upvalDesc{name: name}
+	if slot, ok := fs.parent.local(name); ok {
+		desc.fromLocal, desc.index = true, slot
+	} else if i, ok := fs.parent.upval(name); ok {
+		desc.index = i
+	} else {
+		return 0, false
+	}
+	fs.proto.upvals = append(fs.proto.upvals, desc)
+	return len(fs.proto.upvals) - 1, true
+}
+
+// Statements
+
+// blockEnds reports whether the next token ends a block
+func (p *parser) blockEnds() bool {
+	t := p.peek()
+	if t.kind == tokEOF {
+		return true
+	}
+	if t.kind != tokSymbol {
+		return false
+	}
+	switch t.text {
+	case "end", "else", "elseif", "until":
+		return true
+	}
+	return false
+}
+
+// block parses statements up to the end of a block, in a scope of its own
+func (p *parser) block() (block, error) {
+	scope := p.openScope()
+	defer p.closeScope(scope)
+	return p.statements()
+}
+
+// statements parses statements up to the end of a block in the current
+// scope
+func (p *parser) statements() (block, error) {
+	var b block
+	for !p.blockEnds() {
+		if p.is("return") {
+			s, err := p.returnStatement()
+			if err != nil {
+				return nil, err
+			}
+			return append(b, s), nil
+		}
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			b = append(b, s)
+		}
+	}
+	return b, nil
+}
+
+func (p *parser) statement() (stmt, error) {
+	t := p.peek()
+	line := t.line
+	if t.kind == tokSymbol {
+		switch t.text {
+		case ";":
+			p.advance()
+			return nil, nil
+		case "if":
+			return p.ifStatement()
+		case "while":
+			p.advance()
+			cond, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("do"); err != nil {
+				return nil, err
+			}
+			body, err := p.block()
+			if err != nil {
+				return nil, err
+			}
+			return &whileStmt{cond, body, line}, p.expectClosing("end", "while", line)
+		case "do":
+			p.advance()
+			body, err := p.block()
+			if err != nil {
+				return nil, err
+			}
+			return &doStmt{body}, p.expectClosing("end", "do", line)
+		case "for":
+			return p.forStatement()
+		case "repeat":
+			p.advance()
+			scope := p.openScope()
+			defer p.closeScope(scope)
+			body, err := p.statements()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectClosing("until", "repeat", line); err != nil {
+				return nil, err
+			}
+			cond, err := p.expr()
+			return &repeatStmt{body, cond, line}, err
+		case "function":
+			return p.functionStatement()
+		case "local":
+			p.advance()
+			if p.accept("function") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				slot := p.declare(name)
+				fn, err := p.functionBody(name, false, line)
+				return &localFunctionStmt{slot, fn}, err
+			}
+			return p.localStatement()
+		case "break":
+			p.advance()
+			return breakStmt{}, nil
+		case "goto", "::":
+			return nil, p.errorf("goto and labels are not supported")
+		}
+	}
+	return p.expressionStatement()
+}
+
+func (p *parser) returnStatement() (stmt, error) {
+	p.advance()
+	var exprs []expr
+	if !p.blockEnds() && !p.is(";") {
+		var err error
+		if exprs, err = p.exprList(); err != nil {
+			return nil, err
+		}
+	}
+	p.accept(";")
+	if !p.blockEnds() {
+		return nil, p.errorf("'<eof>' expected near %s", p.describe(p.peek()))
+	}
+	return &returnStmt{exprs}, nil
+}
+
+func (p *parser) ifStatement() (stmt, error) {
+	line := p.advance().line
+	s := &ifStmt{}
+	for {
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("then"); err != nil {
+			return nil, err
+		}
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		s.conds, s.blocks = append(s.conds, cond), append(s.blocks, body)
+		if !p.accept("elseif") {
+			break
+		}
+	}
+	if p.accept("else") {
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		s.orElse = body
+	}
+	return s, p.expectClosing("end", "if", line)
+}
+
+func (p *parser) forStatement() (stmt, error) {
+	line := p.advance().line
+	first, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept("=") {
+		s := &numericForStmt{line: line}
+		if s.start, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if s.limit, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if p.accept(",") {
+			if s.step, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("do"); err != nil {
+			return nil, err
+		}
+		scope := p.openScope()
+		s.slot = p.declare(first)
+		s.body, err = p.block()
+		p.closeScope(scope)
+		if err != nil {
+			return nil, err
+		}
+		return s, p.expectClosing("end", "for", line)
+	}
+
+	names := []string{first}
+	for p.accept(",") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	s := &genericForStmt{line: line}
+	if s.exprs, err = p.exprList(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("do"); err != nil {
+		return nil, err
+	}
+	scope := p.openScope()
+	for _, name := range names {
+		s.slots = append(s.slots, p.declare(name))
+	}
+	s.body, err = p.block()
+	p.closeScope(scope)
+	if err != nil {
+		return nil, err
+	}
+	return s, p.expectClosing("end", "for", line)
+}
+
+// functionStatement parses function a.b.c:m() ... end, an assignment of
+// the function to the name
+func (p *parser) functionStatement() (stmt, error) {
+	line := p.advance().line
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	full := name
+	target := p.resolve(name)
+	method := false
+	for !method && (p.is(".") || p.is(":")) {
+		sep := p.advance().text
+		field, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		full += sep + field
+		target = &indexExpr{obj: target, key: &constExpr{field}, line: line}
+		method = sep == ":"
+	}
+	fn, err := p.functionBody(full, method, line)
+	if err != nil {
+		return nil, err
+	}
+	return &assignStmt{targets: []expr{target}, exprs: []expr{fn}, line: line}, nil
+}
+
+func (p *parser) localStatement() (stmt, error) {
+	var names []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if p.is("<") {
+			return nil, p.errorf("local attributes are not supported")
+		}
+		names = append(names, name)
+		if !p.accept(",") {
+			break
+		}
+	}
+	s := &localStmt{}
+	if p.accept("=") {
+		var err error
+		if s.exprs, err = p.exprList(); err != nil {
+			return nil, err
+		}
+	}
+	// The values are worked out before the new locals come into scope
+	for _, name := range names {
+		s.slots = append(s.slots, p.declare(name))
+	}
+	return s, nil
+}
+
+// expressionStatement parses a call or an assignment
+func (p *parser) expressionStatement() (stmt, error) {
+	line := p.peek().line
+	e, err := p.suffixedExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is("=") && !p.is(",") {
+		call, ok := e.(multiExpr)
+		if _, vararg := e.(*varargExpr); !ok || vararg {
+			return nil, p.errorf("syntax error near %s", p.describe(p.peek()))
+		}
+		return &callStmt{call}, nil
+	}
+	targets := []expr{e}
+	for p.accept(",") {
+		t, err := p.suffixedExpr()
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	for _, t := range targets {
+		switch t.(type) {
+		case *localExpr, *upvalExpr, *globalExpr, *indexExpr:
+		default:
+			return nil, p.errorf("syntax error near %s", p.describe(p.peek()))
+		}
+	}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	exprs, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	return &assignStmt{targets: targets, exprs: exprs, line: line}, nil
+}
+
+// Expressions
+
+func (p *parser) exprList() ([]expr, error) {
+	var exprs []expr
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e)
+		if !p.accept(",") {
+			return exprs, nil
+		}
+	}
+}
+
+func (p *parser) expr() (expr, error) {
+	return p.subExpr(0)
+}
+
+// subExpr parses operators that bind more tightly than limit
+func (p *parser) subExpr(limit int) (expr, error) {
+	var e expr
+	var err error
+	t := p.peek()
+	if t.kind == tokSymbol && t.text == "~" {
+		return nil, p.errorf("bitwise operators are not supported")
+	}
+	if t.kind == tokSymbol && (t.text == "not" || t.text == "-" || t.text == "#") {
+		p.advance()
+		x, err := p.subExpr(unaryPriority)
+		if err != nil {
+			return nil, err
+		}
+		switch t.text {
+		case "not":
+			e = &notExpr{x}
+		case "-":
+			e = &negExpr{x, t.line}
+			if c, ok := x.(*constExpr); ok {
+				if n, ok := c.value.(float64); ok {
+					e = &constExpr{-n}
+				}
+			}
+		case "#":
+			e = &lenExpr{x, t.line}
+		}
+	} else if e, err = p.simpleExpr(); err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		prio, ok := binaryPriority[op.text]
+		if op.kind != tokSymbol || !ok || prio[0] <= limit {
+			return e, nil
+		}
+		switch op.text {
+		case "|", "~", "&", "<<", ">>":
+			return nil, p.errorf("bitwise operators are not supported")
+		}
+		p.advance()
+		r, err := p.subExpr(prio[1])
+		if err != nil {
+			return nil, err
+		}
+		switch op.text {
+		case "and":
+			e = &andExpr{e, r}
+		case "or":
+			e = &orExpr{e, r}
+		default:
+			e = &binExpr{op: op.text, l: e, r: r, line: op.line}
+		}
+	}
+}
+
+func (p *parser) simpleExpr() (expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		p.advance()
+		return &constExpr{t.num}, nil
+	case tokString:
+		p.advance()
+		return &constExpr{t.text}, nil
+	case tokSymbol:
+		switch t.text {
+		case "nil":
+			p.advance()
+			return &constExpr{nil}, nil
+		case "true", "false":
+			p.advance()
+			return &constExpr{t.text == "true"}, nil
+		case "...":
+			if !p.fs.proto.vararg {
+				return nil, p.errorf("cannot use '...' outside a vararg function near '...'")
+			}
+			p.advance()
+			return &varargExpr{}, nil
+		case "{":
+			return p.table()
+		case "function":
+			p.advance()
+			return p.functionBody("anonymous", false, t.line)
+		}
+	}
+	return p.suffixedExpr()
+}
+
+func (p *parser) primaryExpr() (expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokName:
+		p.advance()
+		return p.resolve(t.text), nil
+	case p.accept("("):
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectClosing(")", "(", t.line); err != nil {
+			return nil, err
+		}
+		if _, multi := e.(multiExpr); multi {
+			e = &parenExpr{e}
+		}
+		return e, nil
+	}
+	return nil, p.errorf("unexpected symbol near %s", p.describe(t))
+}
+
+// suffixedExpr parses a primary expression followed by fields, indexes
+// and calls
+func (p *parser) suffixedExpr() (expr, error) {
+	e, err := p.primaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.accept("."):
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			e = &indexExpr{obj: e, key: &constExpr{name}, line: t.line}
+		case p.accept("["):
+			key, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			e = &indexExpr{obj: e, key: key, line: t.line}
+		case p.accept(":"):
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			e = &methodExpr{obj: e, name: name, args: args, line: t.line}
+		case p.is("(") || p.is("{") || t.kind == tokString:
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			e = &callExpr{fn: e, args: args, line: t.line}
+		default:
+			return e, nil
+		}
+	}
+}
+
+// callArgs parses the arguments of a call, in brackets or a single table
+// or string
+func (p *parser) callArgs() ([]expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokString:
+		p.advance()
+		return []expr{&constExpr{t.text}}, nil
+	case p.is("{"):
+		tbl, err := p.table()
+		return []expr{tbl}, err
+	case p.accept("("):
+		if p.accept(")") {
+			return nil, nil
+		}
+		args, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		return args, p.expectClosing(")", "(", t.line)
+	}
+	return nil, p.errorf("function arguments expected near %s", p.describe(t))
+}
+
+// table parses a table constructor
+func (p *parser) table() (expr, error) {
+	line := p.advance().line
+	e := &tableExpr{line: line}
+	for !p.is("}") {
+		var field tableField
+		var err error
+		switch {
+		case p.is("["):
+			p.advance()
+			if field.key, err = p.expr(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+		case p.peek().kind == tokName && p.tokens[p.pos+1].kind == tokSymbol && p.tokens[p.pos+1].text == "=":
+			field.key = &constExpr{p.advance().text}
+			p.advance()
+		}
+		if field.value, err = p.expr(); err != nil {
+			return nil, err
+		}
+		e.fields = append(e.fields, field)
+		if !p.accept(",") && !p.accept(";") {
+			break
+		}
+	}
+	return e, p.expectClosing("}", "{", line)
+}
+
+// functionBody parses the parameters and body of a function, method
+// functions getting self first
+func (p *parser) functionBody(name string, method bool, line int) (*functionExpr, error) {
+	proto := &funcProto{name: name, source: p.source, line: line}
+	p.fs = &funcState{parent: p.fs, proto: proto}
+	defer func() { p.fs = p.fs.parent }()
+
+	if method {
+		p.declare("self")
+		proto.params++
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for !p.is(")") {
+		if p.accept("...") {
+			proto.vararg = true
+			break
+		}
+		param, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		p.declare(param)
+		proto.params++
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	body, err := p.statements()
+	if err != nil {
+		return nil, err
+	}
+	proto.body = body
+	if err := p.expectClosing("end", "function", line); err != nil {
+		return nil, err
+	}
+	return &functionExpr{proto}, nil
+}
@@ -0,0 +1,252 @@
+package lua
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Value is a Lua value: nil, a bool, a float64 for numbers, a string, a
+// *Table or a *Function
+type Value any
+
+// GoFunction is a function written in Go that scripts can call. It is
+// given the arguments and returns the results, or an error that the script
+// sees as raised where it made the call.
+type GoFunction func(args []Value) ([]Value, error)
+
+// Function is a function value, written in Lua or in Go
+type Function struct {
+	name string
+	// proto and upvals make a Lua function, native a Go one
+	proto  *funcProto
+	upvals []*Value
+	native GoFunction
+}
+
+// NewFunction returns a Go function as a value, named name in error
+// messages
+func NewFunction(name string, fn GoFunction) *Function {
+	return &Function{name: name, native: fn}
+}
+
+// Table is a Lua table. Keys 1 to n with values are kept in order in
+// array, any others in hash.
+type Table struct {
+	array []Value
+	hash  map[Value]Value
+}
+
+// NewTable returns an empty table
+func NewTable() *Table {
+	return &Table{}
+}
+
+// Get returns the value stored under key, nil if there is none
+func (t *Table) Get(key Value) Value {
+	if n, ok := key.(float64); ok {
+		if i := int(n); float64(i) == n && i >= 1 && i <= len(t.array) {
+			return t.array[i-1]
+		}
+	}
+	return t.hash[key]
+}
+
+// Set stores value under key, or removes the key when value is nil. Keys
+// can't be nil or NaN.
+func (t *Table) Set(key, value Value) error {
+	switch k := key.(type) {
+	case nil:
+		return fmt.Errorf("table index is nil")
+	case float64:
+		if math.IsNaN(k) {
+			return fmt.Errorf("table index is NaN")
+		}
+		if i := int(k); float64(i) == k && i >= 1 && i <= len(t.array)+1 {
+			t.setIndex(i, value)
+			return nil
+		}
+	}
+	if value == nil {
+		delete(t.hash, key)
+		return nil
+	}
+	if t.hash == nil {
+		t.hash = make(map[Value]Value)
+	}
+	t.hash[key] = value
+	return nil
+}
+
+// setIndex stores a value at index i of the array part, which is at most
+// one past its end, moving keys that follow on from the hash into it
+func (t *Table) setIndex(i int, value Value) {
+	if i <= len(t.array) {
+		t.array[i-1] = value
+		// Trailing nils are dropped, so the length is a border
+		for len(t.array) > 0 && t.array[len(t.array)-1] == nil {
+			t.array = t.array[:len(t.array)-1]
+		}
+		return
+	}
+	if value == nil {
+		delete(t.hash, float64(i))
+		return
+	}
+	delete(t.hash, float64(i))
+	t.array = append(t.array, value)
+	t.extend()
+}
+
+// setList stores the values of a table constructor's list at 1 onward.
+// They all go in the array part, holes too, so that the length of {1, nil,
+// 3} is 3 as in Lua.
+func (t *Table) setList(values []Value) {
+	for i, v := range values {
+		delete(t.hash, float64(i+1))
+		if i < len(t.array) {
+			t.array[i] = v
+		} else {
+			t.array = append(t.array, v)
+		}
+	}
+	for len(t.array) > 0 && t.array[len(t.array)-1] == nil {
+		t.array = t.array[:len(t.array)-1]
+	}
+	t.extend()
+}
+
+// extend moves keys that follow on from the end of the array part out of
+// the hash and into it
+func (t *Table) extend() {
+	for {
+		next := float64(len(t.array) + 1)
+		v, ok := t.hash[next]
+		if !ok {
+			return
+		}
+		delete(t.hash, next)
+		t.array = append(t.array, v)
+	}
+}
+
+// Len returns the length of the table as the # operator does
+func (t *Table) Len() int {
+	return len(t.array)
+}
+
+// keys returns the keys of the table, the array part first in order, then
+// numbers and strings in order and anything else after them
+func (t *Table) keys() []Value {
+	keys := make([]Value, 0, len(t.array)+len(t.hash))
+	for i, v := range t.array {
+		if v != nil {
+			keys = append(keys, float64(i+1))
+		}
+	}
+	rest := make([]Value, 0, len(t.hash))
+	for k := range t.hash {
+		rest = append(rest, k)
+	}
+	rank := func(v Value) int {
+		switch v.(type) {
+		case float64:
+			return 0
+		case string:
+			return 1
+		}
+		return 2
+	}
+	slices.SortStableFunc(rest, func(a, b Value) int {
+		if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+			return c
+		}
+		switch a := a.(type) {
+		case float64:
+			return cmp.Compare(a, b.(float64))
+		case string:
+			return cmp.Compare(a, b.(string))
+		}
+		return 0
+	})
+	return append(keys, rest...)
+}
+
+// TypeName returns the Lua type of a value, as the type function does
+func TypeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *Table:
+		return "table"
+	case *Function:
+		return "function"
+	}
+	return "userdata"
+}
+
+// ToString converts a value to a string as tostring does
+func ToString(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case *Table:
+		return fmt.Sprintf("table: %p", v)
+	case *Function:
+		return fmt.Sprintf("function: %p", v)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatNumber writes whole numbers without a fraction and others as %.14g
+// does, as Lua does
+func formatNumber(n float64) string {
+	switch {
+	case math.IsInf(n, 1):
+		return "inf"
+	case math.IsInf(n, -1):
+		return "-inf"
+	case math.IsNaN(n):
+		return "nan"
+	case n == math.Trunc(n) && math.Abs(n) < 1e15:
+		return strconv.FormatFloat(n, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(n, 'g', 14, 64)
+}
+
+// toNumber converts a number or a string holding one to a number
+func toNumber(v Value) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		return parseNumber(v)
+	}
+	return 0, false
+}
+
+// truthy reports whether a value counts as true, which is anything but nil
+// and false
+func truthy(v Value) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	}
+	return true
+}
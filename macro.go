@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// macroStep is one cell set by a macro, relative to the cell the recording
+// started at
+type macroStep struct {
+	offset tile
+	alive  bool
+}
+
+// macro is a named sequence of edits that can be played again anywhere
+type macro struct {
+	name  string
+	steps []macroStep
+}
+
+// macros records edits while a recording is running and keeps the finished
+// macros for the rest of the session
+type macros struct {
+	recording *macro
+	origin    tile
+	saved     map[string]*macro
+	last      string
+}
+
+// startMacro begins recording the edits made from now on under name, with
+// offsets from the pointed cell
+func (w *World) startMacro(name string) {
+	w.macros.recording = &macro{name: name}
+	w.macros.origin = w.pointedOrCentre()
+}
+
+// finishMacro stops recording and keeps the macro, returning it or nil if
+// nothing was recorded
+func (w *World) finishMacro() *macro {
+	m := w.macros.recording
+	w.macros.recording = nil
+	if m == nil || len(m.steps) == 0 {
+		return nil
+	}
+	if w.macros.saved == nil {
+		w.macros.saved = make(map[string]*macro)
+	}
+	w.macros.saved[m.name] = m
+	w.macros.last = m.name
+	return m
+}
+
+// finishMacro stops recording and says what was recorded
+func (g *Game) finishMacro() {
+	if m := g.world.finishMacro(); m != nil {
+		g.command.show(fmt.Sprintf("recorded macro %s, %d cells", m.name, len(m.steps)))
+	} else {
+		g.command.show("nothing recorded")
+	}
+}
+
+// recordMacroEdit adds the cells of an edit to the macro being recorded,
+// undone edits record the cells going back to how they were
+func (w *World) recordMacroEdit(e edit, undone bool) {
+	m := w.macros.recording
+	if m == nil {
+		return
+	}
+	cells := make([]tile, 0, len(e))
+	for cell := range e {
+		cells = append(cells, cell)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Y != cells[j].Y {
+			return cells[i].Y < cells[j].Y
+		}
+		return cells[i].X < cells[j].X
+	})
+	for _, cell := range cells {
+		alive := e[cell].after
+		if undone {
+			alive = e[cell].before
+		}
+		offset := tile{X: cell.X - w.macros.origin.X, Y: cell.Y - w.macros.origin.Y}
+		m.steps = append(m.steps, macroStep{offset: offset, alive: alive})
+	}
+}
+
+// playMacro makes the edits of a macro again from the pointed cell, as one
+// edit that can be undone
+func (w *World) playMacro(name string) error {
+	m, ok := w.macros.saved[name]
+	if !ok {
+		return fmt.Errorf("no macro %q, macros: %s", name, strings.Join(w.macroNames(), ", "))
+	}
+	origin := w.pointedOrCentre()
+	w.beginEdit()
+	for _, s := range m.steps {
+		w.setCell(tile{X: origin.X + s.offset.X, Y: origin.Y + s.offset.Y}, s.alive)
+	}
+	w.commitEdit()
+	w.macros.last = name
+	return nil
+}
+
+// macroNames returns the names of the recorded macros in order
+func (w *World) macroNames() []string {
+	names := make([]string, 0, len(w.macros.saved))
+	for name := range w.macros.saved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pointedOrCentre returns the cell under the mouse or keyboard cursor, or
+// the middle of the view when neither points at the grid
+func (w *World) pointedOrCentre() tile {
+	if cell, ok := w.pointedCell(); ok {
+		return cell
+	}
+	return w.viewCentre()
+}
@@ -1,308 +1,808 @@
 package main
 
 import (
+	"fmt"
 	"image/color"
 	"log"
 	"math/rand"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
 )
 
+// Percentage of cells alive in a random soup, the settings menu steps it
+// between the limits
 const (
-	screenWidth  = 800
-	screenHeight = 800
-	tileSize     = 20
-	gridTop      = 20
-	gridWidth    = screenWidth / tileSize
-	gridHeight   = screenHeight / tileSize
-	gridSize     = screenWidth / tileSize
+	defaultDensity = 25
+	minDensity     = 5
+	maxDensity     = 60
+	densityStep    = 5
 )
 
-var (
-	yellow = color.RGBA{255, 255, 0, 255}
-	grey   = color.RGBA{128, 128, 128, 255}
-	black  = color.RGBA{0, 0, 0, 255}
-	//black  = [4]float64{0, 0, 0, 1}
+const (
+	tileSize   = 20
+	gridTop    = 20
+	gridWidth  = 40
+	gridHeight = 40
 )
 
 type World struct {
 	screenWidth  int
 	screenHeight int
 	tileSize     int
-	gridWidth    int
-	gridHeight   int
-	gridSize     int
 	gridTop      int
 	alive        bool
-	liveCells    map[tile]struct{}
+	grid         *life.Grid
 	isSimulating bool
 	lastUpdate   time.Time
+	speed        time.Duration
+	density      int
+	soupSymmetry soupSymmetry
+	random       *rand.Rand
+	diedOut      int
+	totalSteps   int
+	checkpoints  *checkpointRing
+	camera       camera
+	stroke       stroke
+	selecting    bool
+	selection    selection
+	clipboard    *pattern
+	stamp        *pattern
+	recent       recentPatterns
+	macros       macros
+	symmetry     symmetry
+	history      history
+	ages         map[tile]int
+	colorMode    colorMode
+	heatmap      heatmap
+	cycle        cycleDetector
+	// census is taken when the world settles, nil until then. populations
+	// are the latest populations, enough to tell when they repeat.
+	census      *census
+	populations []int
+	// autoStop pauses the simulation when the world dies out or settles,
+	// stopped says why it last did
+	autoStop bool
+	stopped  string
+	// stats describe the step to the current generation
+	stats  life.GenerationStats
+	bounds boundingBox
+	// diff is shown instead of the cells while it is set
+	diff *cellDiff
+	// puzzle is the puzzle being played and versus the two player game,
+	// nil outside of them
+	puzzle *puzzleRun
+	versus *versusGame
+	// populationLog is the file each generation is logged to, nil unless
+	// -log-population is given
+	populationLog *populationLog
+	still         stillCells
+	trails        trails
+	theme         *Theme
+	lightTheme    *Theme
+	background    *ebiten.Image
+	showGrid      bool
+	sparkline     sparkline
+	metrics       metrics
+	previous      map[tile]struct{}
+	smooth        bool
+	steppedAt     time.Time
+	sprite        cellSprite
+	layers        worldLayers
+	antialias     bool
+	crosshair     bool
+	ruler         bool
+	cursor        keyCursor
+	touching      bool
+
+	// session is the shared world when hosting or joining one, owners is
+	// who placed each live cell by hand and player the name this player's
+	// edits are placed under
+	session *session
+	owners  map[tile]string
+	player  string
 }
 
-type tile struct {
-	x, y int
-}
+// tile is a cell position on the grid
+type tile = life.Cell
 
-// NewWorld creates a new world
-func NewWorld(screenWidth, screenHeight, tileSize int) *World {
+// NewWorld creates a new world of gridWidth x gridHeight cells
+func NewWorld(gridWidth, gridHeight, tileSize int, rule life.Rule) *World {
 	return &World{
-		screenWidth:  screenWidth,
-		screenHeight: screenHeight,
+		screenWidth:  gridWidth * tileSize,
+		screenHeight: gridTop + gridHeight*tileSize,
 		tileSize:     tileSize,
-		gridWidth:    gridWidth,
-		gridHeight:   gridHeight,
-		gridSize:     gridSize,
 		gridTop:      gridTop,
-		liveCells:    make(map[tile]struct{}),
+		grid:         life.New(gridWidth, gridHeight, rule),
+		ages:         make(map[tile]int),
 		isSimulating: false,
 		alive:        false,
 		lastUpdate:   time.Now(),
+		speed:        300 * time.Millisecond,
+		density:      defaultDensity,
+		still:        stillCells{after: defaultStillAfter},
+		random:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		checkpoints:  newCheckpointRing(10, 50),
+		theme:        themes[0],
+		showGrid:     true,
+		autoStop:     true,
 	}
 }
 
 // DrawWorld draws the world
 func (w *World) DrawWorld(screen *ebiten.Image) {
+	if !w.showGrid {
+		return
+	}
 
-	// Draw the lines of the grid
-	for i := 0; i <= w.gridSize; i++ {
-		thickness := float32(1.0)
+	// The cell boundaries visible with the camera offset
+	firstX, firstY, lastX, lastY := w.visibleCells()
 
-		// Vertical lines
-		x := float32(i * w.tileSize)
+	// Vertical lines
+	for i := firstX; i <= lastX; i++ {
+		thickness, c, ok := w.gridLineStyle(i)
+		if !ok {
+			continue
+		}
+		x, _ := w.cellToScreen(i, 0)
 		vector.StrokeLine(
 			screen,
 			x,
-			float32(0),
+			float32(w.gridTop),
 			x,
-			float32(w.gridTop+(w.gridSize*w.tileSize)), // Fix grid height calculation
+			float32(w.screenHeight),
 			thickness,
-			black,
-			false,
+			c,
+			w.antialias,
 		)
+	}
 
-		// Horizontal lines
-		y := float32(w.gridTop + i*w.tileSize)
+	// Horizontal lines
+	for i := firstY; i <= lastY; i++ {
+		thickness, c, ok := w.gridLineStyle(i)
+		if !ok {
+			continue
+		}
+		_, y := w.cellToScreen(0, i)
 		vector.StrokeLine(
 			screen,
 			0,
 			y,
-			float32(w.gridSize*w.tileSize), // Fix grid width calculation
+			float32(w.screenWidth),
 			y,
 			thickness,
-			black,
-			false,
+			c,
+			w.antialias,
 		)
 	}
-
 }
 
-func (w *World) handleMouseClick(x, y int) {
-	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		return
-	}
-
-	// Calculate the cell clicked
-	cellX := x / w.tileSize
-	cellY := (y - w.gridTop) / w.tileSize
+// Zoom levels at which the grid lines between cells fade out, below
+// minorLinesHidden only every tenth line is drawn
+const (
+	minorLinesHidden = 4
+	minorLinesSolid  = 10
+)
 
-	if cellX < 0 || cellX >= w.gridWidth || cellY < 0 || cellY >= w.gridHeight {
-		return
+// gridLineStyle returns how to draw the grid line at cell boundary i. Like
+// graph paper every tenth line is heavier, and the lines in between fade
+// out as the cells get small.
+func (w *World) gridLineStyle(i int) (float32, color.Color, bool) {
+	if i%10 == 0 {
+		if w.tileSize < minorLinesSolid {
+			return 1, w.theme.GridLines, true
+		}
+		return 2, w.theme.GridLines, true
 	}
-	clickedCell := tile{x: cellX, y: cellY}
-	if _, isAlive := w.liveCells[clickedCell]; isAlive {
-		delete(w.liveCells, clickedCell)
-	} else {
-		w.liveCells[clickedCell] = struct{}{}
+	if w.tileSize < minorLinesHidden {
+		return 0, nil, false
 	}
-
+	alpha := 255 * min(w.tileSize-minorLinesHidden+1, minorLinesSolid-minorLinesHidden) / (minorLinesSolid - minorLinesHidden)
+	return 1, fade(w.theme.GridLines, uint8(alpha)), true
 }
 
 // fillCell draws a cell filled with a color
 func (w *World) fillCell(screen *ebiten.Image, x, y int, color color.Color) {
-	vector.DrawFilledRect(screen, float32(x*w.tileSize), float32(w.gridTop+y*w.tileSize), float32(w.tileSize), float32(w.tileSize), yellow, false)
+	sx, sy := w.cellToScreen(x, y)
+	vector.DrawFilledRect(screen, sx, sy, float32(w.tileSize), float32(w.tileSize), color, w.antialias)
 }
 
-// drawliveCells draws all the live cells
+// drawliveCells draws all the live cells, fading in newly born cells and
+// fading out the ones that just died while a transition is running
 func (w *World) drawLiveCells(screen *ebiten.Image) {
-	for cell := range w.liveCells {
-		w.fillCell(screen, cell.x, cell.y, yellow)
+	t := w.transitionProgress()
+	w.drawDying(screen, t)
+	for cell := range w.grid.Cells() {
+		if !w.onScreen(cell) {
+			continue
+		}
+		c := w.cellColor(cell)
+		if _, wasAlive := w.previous[cell]; t < 1 && !wasAlive {
+			c = fade(c, uint8(255*t))
+		}
+		w.drawCell(screen, cell.X, cell.Y, c)
 	}
 
 }
 
-// generateRandomCells generates random cells
+// setCells replaces the live cells with a new starting state
+func (w *World) setCells(cells map[tile]struct{}) {
+	// Record the difference if this is part of an edit, otherwise this is a
+	// new world and the old edits no longer apply
+	if w.history.pending == nil {
+		w.history = history{}
+	} else {
+		for cell := range w.grid.Cells() {
+			if _, ok := cells[cell]; !ok {
+				w.recordChange(cell, false)
+			}
+		}
+		for cell := range cells {
+			w.recordChange(cell, true)
+		}
+	}
+	w.grid.Replace(cells)
+	w.previous = nil
+	w.ages = make(map[tile]int)
+	if w.owners != nil {
+		w.owners = make(map[tile]string)
+	}
+	w.heatmap.clear()
+	w.cycle.clear()
+	w.census = nil
+	w.populations = nil
+	w.stopped = ""
+	w.puzzle.reset()
+	w.versus.reset()
+	w.still.reset(0)
+	w.trails.clear()
+	w.sparkline.clear()
+	w.metrics.clear()
+	w.grid.Generation = 0
+	w.diedOut = 0
+	w.checkpoints.clear()
+}
+
+// generateRandomCells fills the grid with a random soup, each cell is alive
+// with a chance of the density setting. With a soup symmetry each cell is
+// decided once along with its images.
 func (w *World) generateRandomCells() {
 	// Clear the current cells
-	w.liveCells = make(map[tile]struct{})
+	w.setCells(make(map[tile]struct{}))
 
-	//time as seed
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-	totalCells := w.gridWidth * w.gridHeight
-	numCells := rand.Intn((totalCells / 5) + totalCells/5)
-
-	for i := 0; i < numCells; i++ {
-		x := rand.Intn(w.gridWidth)
-		y := rand.Intn(w.gridHeight)
-		w.liveCells[tile{x: x, y: y}] = struct{}{}
+	x0, y0, width, height := w.soupArea()
+	decided := make(map[tile]bool)
+	for y := y0; y < y0+height; y++ {
+		for x := x0; x < x0+width; x++ {
+			if decided[tile{X: x, Y: y}] {
+				continue
+			}
+			alive := w.random.Intn(100) < w.density
+			for _, cell := range w.soupOrbit(tile{X: x, Y: y}) {
+				decided[cell] = true
+				if alive {
+					w.grid.Set(cell, true)
+				}
+			}
+		}
 	}
-
 }
 
-// SimulateWorld simulates the world following the rules of the game of life.
+// SimulateWorld advances the grid a generation and updates everything that
+// follows the cells from one generation to the next
 func (w *World) SimulateWorld() {
-	// Create a new map to store the next generation of cells
-	nextGeneration := make(map[tile]struct{})
-	// Iterate over all the cells
-	for cell := range w.liveCells {
-		// Count the number of live neighbors
-		liveNeighbors := w.countLiveNeighbors(cell.x, cell.y)
-		// If the cell has 2 or 3 live neighbors, it survives
-		if liveNeighbors == 2 || liveNeighbors == 3 {
-			nextGeneration[cell] = struct{}{}
-		}
-		// Check the neighbors of the cell
-		for i := -1; i <= 1; i++ {
-			for j := -1; j <= 1; j++ {
-				// Skip the cell itself
-				if i == 0 && j == 0 {
-					continue
-				}
-				// Calculate the coordinates of the neighbor
-				neighborX := cell.x + i
-				neighborY := cell.y + j
-				// Count the number of live neighbors
-				liveNeighbors := w.countLiveNeighbors(neighborX, neighborY)
-				// If the neighbor has exactly 3 live neighbors, it becomes alive
-				if liveNeighbors == 3 {
-					nextGeneration[tile{x: neighborX, y: neighborY}] = struct{}{}
-				}
-			}
-		}
+	if !w.startAttempt() || !w.versusReady() {
+		return
 	}
-	// Update the live cells
-	w.isSimulating = true
-	w.liveCells = nextGeneration
+	// Snapshot the generation being left if a checkpoint is due
+	w.checkpoints.record(w.grid.Generation, w.grid.Cells())
+	// Step swaps in a new map, so this keeps the last generation
+	previous := w.grid.Cells()
+	w.grid.Step()
+	w.recordStep(previous)
 }
 
-// countLiveNeighbors counts the number of live neighbors of a cell
-func (w *World) countLiveNeighbors(x, y int) int {
-	// Initialize the counter
-	liveNeighbors := 0
-	// Check the neighbors of the cell
-	for i := -1; i <= 1; i++ {
-		for j := -1; j <= 1; j++ {
-			// Skip the cell itself
-			if i == 0 && j == 0 {
-				continue
-			}
-			// Calculate the coordinates of the neighbor
-			neighborX := x + i
-			neighborY := y + j
-			// Check if the neighbor is alive
-			if _, isAlive := w.liveCells[tile{x: neighborX, y: neighborY}]; isAlive {
-				liveNeighbors++
-			}
+// recordStep updates the ages and statistics after the cells have moved on
+// from previous to the next generation
+func (w *World) recordStep(previous map[tile]struct{}) {
+	next := w.grid.Cells()
+	w.stats = w.grid.Stats()
+	if w.stats.Generation != w.grid.Generation {
+		// A joined world is sent its generations instead of stepping them
+		w.stats = life.Measure(previous, next)
+		w.stats.Generation = w.grid.Generation
+	}
+
+	// Survivors get a generation older, births start at age zero
+	ages := make(map[tile]int, len(next))
+	for cell := range next {
+		if _, wasAlive := previous[cell]; wasAlive {
+			ages[cell] = w.ages[cell] + 1
+		}
+	}
+	w.ages = ages
+	for cell := range w.owners {
+		if _, ok := next[cell]; !ok {
+			delete(w.owners, cell)
+		}
+	}
+	w.heatmap.record(previous, next)
+	settled := w.cycle.period > 0
+	w.cycle.record(next)
+	w.populations = append(w.populations, len(next))
+	if n := len(w.populations) - (searchWindow + maxPeriod); n > 0 {
+		w.populations = w.populations[n:]
+	}
+	// Gliders leaving a soup keep the cells from ever repeating, but the
+	// population still settles as it does in a search
+	if w.cycle.period == 0 && populationPeriod(w.populations) == 0 {
+		w.census = nil
+	} else if w.census == nil {
+		// Settled worlds never fail to census unless the rule has more
+		// states, and then there is nothing to show
+		w.census, _ = takeCensus(w.grid)
+	}
+	w.still.record(previous, next, w.grid.Generation)
+	w.trails.record(previous, next)
+	w.sparkline.record(w.stats)
+	if w.metrics.visible {
+		w.metrics.record(w.grid, w.stats)
+	}
+	w.populationLog.record(w.stats)
+
+	// Keep the last generation to blend from
+	w.previous = previous
+	w.steppedAt = time.Now()
+	if len(previous) > 0 && len(next) == 0 {
+		w.diedOut = w.grid.Generation
+	}
+	w.totalSteps++
+
+	if w.checkPuzzle() || w.checkVersus() {
+		return
+	}
+	// Only the step that dies out or settles stops, so playing on afterwards
+	// keeps going
+	if w.autoStop && w.isSimulating {
+		switch {
+		case len(previous) > 0 && len(next) == 0:
+			w.stop(fmt.Sprintf("Died out at generation %d", w.grid.Generation))
+		case !settled && w.cycle.period == 1:
+			w.stop(fmt.Sprintf("Settled at generation %d, population %d", w.grid.Generation, len(next)))
+		case !settled && w.cycle.period > 1:
+			w.stop(fmt.Sprintf("Period %d at generation %d, population %d", w.cycle.period, w.grid.Generation, len(next)))
 		}
 	}
-	// Return the number of live neighbors
-	return liveNeighbors
 }
 
-// generateGosperGliderGun generates a Gosper Glider Gun
-func (w *World) generateGosperGliderGun() {
-	// Clear the current cells
-	w.liveCells = make(map[tile]struct{})
-	// Gosper Glider Gun
-	gliderGun := []tile{
-		{1, 5}, {1, 6}, {2, 5}, {2, 6},
-		{11, 5}, {11, 6}, {11, 7},
-		{12, 4}, {12, 8},
-		{13, 3}, {13, 9},
-		{14, 3}, {14, 9},
-		{15, 6},
-		{16, 4}, {16, 8},
-		{17, 5}, {17, 6}, {17, 7},
-		{18, 6},
-		{21, 3}, {21, 4}, {21, 5},
-		{22, 3}, {22, 4}, {22, 5},
-		{23, 2}, {23, 6},
-		{25, 1}, {25, 2}, {25, 6}, {25, 7},
-		{35, 3}, {35, 4},
-		{36, 3}, {36, 4},
-	}
-	for _, cell := range gliderGun {
-		w.liveCells[cell] = struct{}{}
+// setRunning starts or pauses the simulation. A puzzle or versus game that
+// isn't ready to leave generation 0 stays paused and says why.
+func (w *World) setRunning(running bool) {
+	if running && (!w.startAttempt() || !w.versusReady()) {
+		return
 	}
+	w.isSimulating = running
 }
 
-type Game struct {
-	world *World
+// leaveGameModes ends the puzzle or versus game being played when a world
+// of its own is loaded, putting back the rule the game switched from. Games
+// start over with setCells, so it can't do this itself.
+func (w *World) leaveGameModes() {
+	rule := ""
+	switch {
+	case w.puzzle != nil:
+		rule = w.puzzle.rule
+	case w.versus != nil:
+		rule = w.versus.rule
+	}
+	if rule != "" {
+		// The rule was in use before the game, so it still parses
+		_ = w.grid.SetRule(rule)
+	}
+	w.puzzle, w.versus = nil, nil
 }
 
-func (g *Game) Update() error {
-	// exit game on escape or q key
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) || ebiten.IsKeyPressed(ebiten.KeyQ) {
-		return ebiten.Termination
+// stop pauses the simulation and keeps the reason to show
+func (w *World) stop(reason string) {
+	w.isSimulating = false
+	w.stopped = reason
+}
+
+// rewindToCheckpoint restores the newest checkpoint before the current
+// generation
+func (w *World) rewindToCheckpoint() bool {
+	cp, ok := w.checkpoints.rewind(w.grid.Generation)
+	if !ok {
+		return false
+	}
+	// Copy the cells so editing doesn't change the checkpoint
+	cells := make(map[tile]struct{}, len(cp.cells))
+	for cell := range cp.cells {
+		cells[cell] = struct{}{}
 	}
-	// handle start on g key. generate random cells
-	if ebiten.IsKeyPressed(ebiten.KeyG) {
-		g.world.generateRandomCells()
+	w.grid.Replace(cells)
+	// What was recorded after the checkpoint didn't happen any more
+	w.previous = nil
+	w.ages = make(map[tile]int)
+	w.heatmap.clear()
+	w.cycle.clear()
+	w.census = nil
+	w.populations = nil
+	w.stopped = ""
+	w.trails.clear()
+	w.sparkline.clear()
+	w.metrics.clear()
+	w.grid.Generation = cp.generation
+	w.puzzle.rewound(cp.generation)
+	w.versus.reset()
+	w.still.reset(cp.generation)
+	return true
+}
 
+// placePattern replaces the current cells with a pattern centred on the grid
+func (w *World) placePattern(p *pattern) {
+	w.setCells(make(map[tile]struct{}))
+	origin := w.patternOrigin(p)
+	for _, cell := range p.cells {
+		// States the rule doesn't have are plain live cells
+		s := p.state(cell)
+		if int(s) >= w.grid.States() {
+			s = 1
+		}
+		w.grid.SetState(tile{X: cell.X + origin.X, Y: cell.Y + origin.Y}, s)
 	}
-	// handle reset on r key
-	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
-		g.world.liveCells = make(map[tile]struct{})
-		g.world.isSimulating = false
+}
+
+// patternOrigin returns where placePattern puts the top left of a pattern
+func (w *World) patternOrigin(p *pattern) tile {
+	return tile{X: (w.grid.Width - p.width) / 2, Y: (w.grid.Height - p.height) / 2}
+}
+
+// placeInView replaces the current cells with a pattern centred in the view,
+// as an edit that can be undone
+func (w *World) placeInView(p *pattern) {
+	centre := w.viewCentre()
+	offsetX := centre.X - p.width/2
+	offsetY := centre.Y - p.height/2
+	w.beginEdit()
+	w.setCells(make(map[tile]struct{}))
+	for _, cell := range p.cells {
+		w.setCellState(tile{X: cell.X + offsetX, Y: cell.Y + offsetY}, p.state(cell))
 	}
+	w.commitEdit()
+}
 
-	// handle space key or s to start simulation
-	if ebiten.IsKeyPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyS) {
-		g.world.SimulateWorld()
+type Game struct {
+	world       *World
+	debug       debugOverlay
+	menu        settingsMenu
+	picker      patternPicker
+	catalog     catalog
+	methuselahs methuselahMenu
+	puzzles     puzzleMenu
+	recent      recentMenu
+	census      censusView
+	help        bool
+	quitting    bool
+	configPath  string
+	patternDir  string
+	keys        map[string][]string
+	profile     string
+	stateColors []string
+
+	// command is the : command line, count the count typed before a key in
+	// the vim profile
+	command commandLine
+	count   int
+
+	// cleanPresses records whether the keys of release bindings were
+	// pressed without other modifiers
+	cleanPresses map[keyCombo]bool
+
+	// server hands the work of the HTTP and gRPC APIs to the game loop, nil
+	// unless -http or -grpc is given
+	server *apiServer
+
+	// scene is the title screen or the game, terminal is set when playing
+	// in the terminal, which has no title screen
+	scene    scene
+	title    titleScreen
+	terminal bool
+}
+
+func (g *Game) Update() error {
+	g.server.runPending(g)
+	g.world.session.update(g.world)
+
+	// Overlays take all input while they are open, the other modes have
+	// their own bindings
+	m := g.mode()
+	if m == modeMenu {
+		g.handleOverlay()
+		if g.quitting {
+			return ebiten.Termination
+		}
+		return nil
 	}
 
-	// handle pause on p key
-	if ebiten.IsKeyPressed(ebiten.KeyP) {
-		g.world.isSimulating = false
+	if !g.handleCount() {
+		g.handleBindings(m)
+	}
+	if g.quitting {
+		return ebiten.Termination
 	}
 
-	// handle glider gun on 1 key
-	if inpututil.IsKeyJustPressed(ebiten.Key1) {
-		g.world.generateGosperGliderGun()
+	// The bindings may have changed the mode, such as opening an overlay
+	m = g.mode()
+	if m == modeMenu {
+		return nil
 	}
 
-	// Run the simulation every 200ms if the simulation is running
-	if g.world.isSimulating && time.Since(g.world.lastUpdate) > 300*time.Millisecond {
+	// Run the simulation at the configured speed if the simulation is
+	// running, it carries on under the stamp and the selection
+	if g.world.isSimulating && g.world.session.simulates() && time.Since(g.world.lastUpdate) > g.world.speed {
 		g.world.SimulateWorld()
 		g.world.lastUpdate = time.Now()
 	}
-
-	// handle mouse click
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		x, y := ebiten.CursorPosition()
-		g.world.handleMouseClick(x, y)
+	g.debug.update(g.world.totalSteps)
+
+	// handle panning with middle mouse drag and zooming with the wheel or
+	// two finger gestures
+	g.world.handlePan()
+	g.world.handleZoom()
+	touching := g.world.handleTouch()
+	g.world.followCells()
+
+	// The mouse does nothing else while it is panning or the screen is
+	// touched
+	if !g.world.camera.dragging && !touching {
+		g.handlePointer(m)
 	}
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	screen.Fill(grey)
-	g.world.DrawWorld(screen)
-	g.world.drawLiveCells(screen)
-
+	screen.Fill(g.world.theme.Background)
+	r := ebitenRenderer{screen: screen}
+	g.world.render(r)
+
+	// Editing aids are only shown in the window, over the cells. The title
+	// screen shows the world behind its menu.
+	if g.scene == sceneTitle {
+		g.drawTitle(screen)
+	} else {
+		grid := r.grid(g.world)
+		g.world.drawSymmetryAxes(grid)
+		g.world.drawOscillators(grid)
+		g.world.drawBounds(grid)
+		g.world.drawSelection(grid)
+		g.world.drawStamp(grid)
+		g.world.drawCrosshair(grid)
+		g.world.drawHover(grid)
+		g.world.drawCursor(grid)
+		g.world.drawRuler(grid)
+		g.world.drawMetrics(screen)
+	}
+	g.debug.draw(screen, g.world.gridTop, g.mode())
+	g.menu.draw(screen, g.world)
+	g.picker.draw(screen, g.world)
+	g.catalog.draw(screen, g.world)
+	g.methuselahs.draw(screen, g.world)
+	g.puzzles.draw(screen, g.world)
+	g.recent.draw(screen, g.world)
+	g.census.draw(screen, g.world)
+	g.drawHelp(screen)
+	g.drawCommand(screen)
 }
 
+// Layout uses the whole window for the view, so fullscreen and resized
+// windows show more of the grid rather than stretching it
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return 800, 800
+	g.world.screenWidth, g.world.screenHeight = outsideWidth, outsideHeight
+	return outsideWidth, outsideHeight
 }
 
 func main() {
-	// Initialize the world
-	world := NewWorld(screenWidth, screenHeight, tileSize)
-	game := &Game{world: world}
-	ebiten.SetWindowSize(840, 840)
+	cfg, err := parseFlags()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.width <= 0 || cfg.height <= 0 || cfg.tile <= 0 {
+		log.Fatal("width, height and tile must be positive")
+	}
+	if cfg.density < minDensity || cfg.density > maxDensity {
+		log.Fatalf("density must be between %d and %d", minDensity, maxDensity)
+	}
+	if cfg.stillAfter < minStillAfter || cfg.stillAfter > maxStillAfter {
+		log.Fatalf("still-after must be between %d and %d", minStillAfter, maxStillAfter)
+	}
+	// Profiling starts first so batch runs can be profiled too
+	if cfg.pprof != "" {
+		startProfiling(cfg.pprof)
+	}
+	if cfg.bench {
+		if err := runBench(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Load the starting pattern, its rule is used unless -rule is given
+	var p *pattern
+	if cfg.pattern != "" {
+		if p, err = loadPattern(cfg.pattern); err != nil {
+			log.Fatal(err)
+		}
+		if cfg.rule == "" {
+			cfg.rule = p.rule
+		}
+	}
+	if cfg.rule == "" {
+		cfg.rule = cfg.savedRule
+	}
+	if cfg.rule == "" {
+		cfg.rule = life.Conway
+	}
+
+	// Initialize the world, the rule may be a registered custom rule
+	world := NewWorld(cfg.width, cfg.height, cfg.tile, life.Rule{})
+	if err := world.grid.SetRule(cfg.rule); err != nil {
+		log.Fatal(err)
+	}
+	world.speed = cfg.speed
+	world.density = cfg.density
+	world.still.after = cfg.stillAfter
+	if cfg.seed != 0 {
+		world.random = rand.New(rand.NewSource(cfg.seed))
+	}
+	world.antialias = cfg.antialias
+	world.autoStop = !cfg.keepRunning
+	world.checkpoints = newCheckpointRing(cfg.checkpoints, cfg.checkpointEvery)
+	if world.theme, err = themeByName(cfg.theme); err != nil {
+		log.Fatal(err)
+	}
+	if len(cfg.stateColors) > 0 {
+		palette, err := parsePalette(cfg.stateColors)
+		if err != nil {
+			log.Fatalf("state colors: %v", err)
+		}
+		setStatePalette(palette)
+	}
+	if world.grid.Boundary, err = life.ParseBoundary(cfg.boundary); err != nil {
+		log.Fatal(err)
+	}
+	if world.soupSymmetry, err = parseSoupSymmetry(cfg.soup); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.background != "" {
+		if world.background, err = loadBackground(cfg.background); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.image != "" {
+		if cfg.threshold > 255 {
+			log.Fatal("threshold must be between 0 and 255")
+		}
+		if p, err = loadImagePattern(cfg.image, cfg.width, cfg.height, uint8(cfg.threshold)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.populationLog != "" {
+		if world.populationLog, err = openPopulationLog(cfg.populationLog); err != nil {
+			log.Fatal(err)
+		}
+		defer world.populationLog.close()
+	}
+	switch {
+	case p != nil:
+		world.placePattern(p)
+	case cfg.random:
+		world.generateRandomCells()
+	}
+	if cfg.script != "" {
+		if err := world.runScript(cfg.script, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Batch export and headless runs don't open a window
+	if cfg.exportFrames != "" {
+		if err := world.exportFrames(cfg.exportFrames, cfg.frames); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.headless {
+		if cfg.generations < 0 {
+			log.Fatal("generations must not be negative")
+		}
+		if err := world.runHeadless(cfg.generations, cfg.out); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if cfg.lifespans {
+		if p == nil {
+			log.Fatal("-lifespans needs a -pattern to vary")
+		}
+		perturb, err := parsePerturbations(cfg.perturb)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := world.runLifespans(p, perturb, cfg.variants, cfg.out); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.search > 0 {
+		first := cfg.seed
+		if first == 0 {
+			first = 1
+		}
+		if err := world.runSearch(cfg.search, first, cfg.out, cfg.ash); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := applyProfile(cfg.profile); err != nil {
+		log.Fatal(err)
+	}
+	if err := applyKeymap(cfg.keys); err != nil {
+		log.Fatal(err)
+	}
+
+	game := &Game{
+		world:       world,
+		configPath:  cfg.configPath,
+		patternDir:  cfg.patternDir,
+		keys:        cfg.keys,
+		profile:     strings.ToLower(cfg.profile),
+		stateColors: cfg.stateColors,
+	}
+	if cfg.http != "" {
+		if game.server, err = startAPIServer(cfg.http); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.grpc != "" {
+		if game.server, err = startGRPCServer(cfg.grpc, game.server); err != nil {
+			log.Fatal(err)
+		}
+	}
+	world.player = cfg.name
+	switch {
+	case cfg.host != "" && cfg.join != "":
+		log.Fatal("-host and -join can't be used together")
+	case cfg.host != "":
+		err = world.hostSession(cfg.host)
+	case cfg.join != "":
+		err = world.joinSession(cfg.join)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.terminal {
+		game.terminal = true
+		if err := game.runTerminal(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	// Start on the title screen unless there is already something to play
+	if p == nil && !cfg.random && cfg.join == "" {
+		game.scene = sceneTitle
+	} else {
+		game.title.started = true
+	}
+	ebiten.SetWindowSize(world.screenWidth, world.screenHeight)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	ebiten.SetWindowTitle("Game Of Life!")
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
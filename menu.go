@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// rulePresets are the rules offered in the settings menu, followed by the
+// registered custom rules
+var rulePresets = []string{
+	life.Conway,
+	"B36/S23",       // HighLife
+	"B3678/S34678",  // Day & Night
+	"B2/S",          // Seeds
+	"B1357/S1357",   // Replicator
+	"B368/S245",     // Morley
+	"B3/S012345678", // Life without death
+}
+
+// speedPresets are the generation intervals offered in the settings menu
+var speedPresets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	300 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+}
+
+// Limits for the grid size set in the settings menu
+const (
+	minGridSize  = 5
+	maxGridSize  = 1000
+	gridSizeStep = 5
+)
+
+// menuItem is one row of the settings menu, change is called with -1 or +1
+// from the left and right arrow keys
+type menuItem struct {
+	label  string
+	value  func(w *World) string
+	change func(w *World, dir int)
+}
+
+var menuItems = []menuItem{
+	{
+		label: "Rule",
+		value: func(w *World) string { return w.grid.RuleName() },
+		change: func(w *World, dir int) {
+			rules := append(slices.Clone(rulePresets), life.RuleNames()...)
+			w.grid.SetRule(rules[cycle(indexOf(rules, w.grid.RuleName()), len(rules), dir)])
+		},
+	},
+	{
+		label: "Speed",
+		value: func(w *World) string { return w.speed.String() },
+		change: func(w *World, dir int) {
+			w.speed = speedPresets[cycle(indexOf(speedPresets, w.speed), len(speedPresets), dir)]
+		},
+	},
+	{
+		label: "Boundary",
+		value: func(w *World) string { return w.grid.Boundary.String() },
+		change: func(w *World, dir int) {
+			w.grid.Boundary = life.Boundary(cycle(int(w.grid.Boundary), int(life.Boundaries), dir))
+		},
+	},
+	{
+		label: "Theme",
+		value: func(w *World) string { return w.theme.Name },
+		change: func(w *World, dir int) {
+			w.theme = themes[cycle(indexOf(themes, w.theme), len(themes), dir)]
+		},
+	},
+	{
+		label: "Soup density",
+		value: func(w *World) string { return fmt.Sprintf("%d%%", w.density) },
+		change: func(w *World, dir int) {
+			w.density = min(max(w.density+dir*densityStep, minDensity), maxDensity)
+		},
+	},
+	{
+		label: "Soup symmetry",
+		value: func(w *World) string { return w.soupSymmetry.String() },
+		change: func(w *World, dir int) {
+			w.soupSymmetry = soupSymmetry(cycle(int(w.soupSymmetry), int(soupSymmetries), dir))
+		},
+	},
+	{
+		label: "Anti-aliasing",
+		value: func(w *World) string { return onOff(w.antialias) },
+		change: func(w *World, dir int) {
+			w.antialias = !w.antialias
+		},
+	},
+	{
+		label: "Auto-stop",
+		value: func(w *World) string { return onOff(w.autoStop) },
+		change: func(w *World, dir int) {
+			w.autoStop = !w.autoStop
+		},
+	},
+	{
+		label: "Still after",
+		value: func(w *World) string { return fmt.Sprintf("%d gens", w.still.after) },
+		change: func(w *World, dir int) {
+			w.still.after = min(max(w.still.after+dir*stillAfterStep, minStillAfter), maxStillAfter)
+		},
+	},
+	{
+		label: "Grid width",
+		value: func(w *World) string { return fmt.Sprint(w.grid.Width) },
+		change: func(w *World, dir int) {
+			w.grid.Width = min(max(w.grid.Width+dir*gridSizeStep, minGridSize), maxGridSize)
+		},
+	},
+	{
+		label: "Grid height",
+		value: func(w *World) string { return fmt.Sprint(w.grid.Height) },
+		change: func(w *World, dir int) {
+			w.grid.Height = min(max(w.grid.Height+dir*gridSizeStep, minGridSize), maxGridSize)
+		},
+	},
+}
+
+// onOff describes a setting that is either on or off
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// indexOf returns the position of v in values, or -1
+func indexOf[T comparable](values []T, v T) int {
+	for i, value := range values {
+		if value == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// cycle steps i by dir through n values, wrapping round at either end. A
+// value that isn't in the list (-1) steps to the first or last one.
+func cycle(i, n, dir int) int {
+	if i < 0 {
+		if dir > 0 {
+			return 0
+		}
+		return n - 1
+	}
+	return ((i+dir)%n + n) % n
+}
+
+// settingsMenu is an overlay for changing settings while the game runs
+type settingsMenu struct {
+	open     bool
+	selected int
+}
+
+// handleMenu moves through the menu with up and down and changes the
+// selected setting with left and right, saving it to the config file
+func (g *Game) handleMenu() {
+	m := &g.menu
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape) || actionPressed("settings"):
+		m.open = false
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		m.selected = cycle(m.selected, len(menuItems), -1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		m.selected = cycle(m.selected, len(menuItems), 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		menuItems[m.selected].change(g.world, -1)
+		g.saveSettings()
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowRight):
+		menuItems[m.selected].change(g.world, 1)
+		g.saveSettings()
+	}
+}
+
+// saveSettings writes the current settings to the config file
+func (g *Game) saveSettings() {
+	s := g.world.settings()
+	s.Keys = g.keys
+	s.Profile = g.profile
+	s.StateColors = g.stateColors
+	if err := s.save(g.configPath); err != nil {
+		log.Printf("saving settings: %v", err)
+	}
+}
+
+// draw shows the menu in the middle of the screen
+func (m *settingsMenu) draw(screen *ebiten.Image, w *World) {
+	if !m.open {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("Settings\n\n")
+	for i, item := range menuItems {
+		cursor := "  "
+		if i == m.selected {
+			cursor = "> "
+		}
+		fmt.Fprintf(&b, "%s%-13s < %s >\n", cursor, item.label, item.value(w))
+	}
+	b.WriteString("\nUp/Down select, Left/Right change\nTab or Esc to close")
+
+	drawCentredBox(screen, w, b.String())
+}
+
+// drawCentredBox prints text over a box in the middle of the screen
+func drawCentredBox(screen *ebiten.Image, w *World, text string) {
+	lines := strings.Split(text, "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line))
+	}
+	boxWidth := width*charWidth + 16
+	boxHeight := len(lines)*charHeight + 16
+	x := (w.screenWidth - boxWidth) / 2
+	y := (w.screenHeight - boxHeight) / 2
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(boxWidth), float32(boxHeight), overlayBackground, false)
+	ebitenutil.DebugPrintAt(screen, text, x+8, y+8)
+}
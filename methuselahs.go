@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// methuselah is a small pattern that takes a long time to settle down
+type methuselah struct {
+	label    string
+	pattern  string
+	lifespan int
+}
+
+// methuselahs are the built-in methuselahs offered in their menu, with the
+// number of generations each one takes to stabilise
+var methuselahs = []methuselah{
+	{label: "Diehard", pattern: "diehard", lifespan: 130},
+	{label: "R-pentomino", pattern: "r-pentomino", lifespan: 1103},
+	{label: "Acorn", pattern: "acorn", lifespan: 5206},
+	{label: "Bunnies", pattern: "bunnies", lifespan: 17332},
+	{label: "Lidka", pattern: "lidka", lifespan: 29055},
+}
+
+// methuselahMenu is an overlay for starting again from a methuselah
+type methuselahMenu struct {
+	open     bool
+	selected int
+}
+
+// handleMethuselahs moves through the menu with up and down and starts
+// again from the selected methuselah on enter
+func (g *Game) handleMethuselahs() {
+	m := &g.methuselahs
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		m.open = false
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		m.selected = cycle(m.selected, len(methuselahs), -1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		m.selected = cycle(m.selected, len(methuselahs), 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		m.open = false
+		placePreset(methuselahs[m.selected].pattern)(g)
+	}
+}
+
+// draw shows the menu in the middle of the screen
+func (m *methuselahMenu) draw(screen *ebiten.Image, w *World) {
+	if !m.open {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("Methuselahs\n\n")
+	for i, item := range methuselahs {
+		cursor := "  "
+		if i == m.selected {
+			cursor = "> "
+		}
+		fmt.Fprintf(&b, "%s%-12s settles after %5d generations\n", cursor, item.label, item.lifespan)
+	}
+	b.WriteString("\nUp/Down select, Enter to start\nEsc to close")
+
+	drawCentredBox(screen, w, b.String())
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// metricSamples is how many generations the metrics chart shows
+const metricSamples = 120
+
+// metricChartHeight is the height in pixels of the metrics chart
+const metricChartHeight = 80
+
+// metricSample is the measures of one generation
+type metricSample struct {
+	population int
+	// heat is how many cells changed in the step
+	heat int
+	// density is the share of the area that is alive and entropy how
+	// disordered the cells are, both from 0 to 1
+	density, entropy float64
+}
+
+// metrics keeps the density, entropy and heat of the most recent
+// generations to chart beside the population
+type metrics struct {
+	visible bool
+	samples []metricSample
+}
+
+// record measures a generation, dropping the oldest sample once the chart is
+// full
+func (m *metrics) record(g *life.Grid, stats life.GenerationStats) {
+	minX, minY, width, height := metricArea(g, stats)
+	m.samples = append(m.samples, metricSample{
+		population: stats.Population,
+		heat:       stats.Heat(),
+		density:    float64(stats.Population) / float64(max(width*height, 1)),
+		entropy:    blockEntropy(g.Cells(), minX, minY, width, height),
+	})
+	if len(m.samples) > metricSamples {
+		m.samples = m.samples[1:]
+	}
+}
+
+// clear forgets all recorded generations
+func (m *metrics) clear() {
+	m.samples = nil
+}
+
+// metricArea is the part of the grid measured, the whole grid when its edges
+// are fixed and the bounding box of the live cells when it is unbounded
+func metricArea(g *life.Grid, stats life.GenerationStats) (minX, minY, width, height int) {
+	if g.Boundary != life.Unbounded {
+		return 0, 0, g.Width, g.Height
+	}
+	return stats.MinX, stats.MinY, stats.Width(), stats.Height()
+}
+
+// blockEntropy splits the area into 2x2 blocks and returns the Shannon
+// entropy of how often each of the 16 arrangements of a block turns up,
+// scaled so 1 is every arrangement equally likely. Empty and evenly filled
+// areas score 0, noise close to 1.
+func blockEntropy(cells map[tile]struct{}, minX, minY, width, height int) float64 {
+	blocks := ((width + 1) / 2) * ((height + 1) / 2)
+	if blocks == 0 {
+		return 0
+	}
+	// Only blocks with live cells are visited, the rest are empty
+	arrangement := make(map[tile]int)
+	for cell := range cells {
+		x, y := cell.X-minX, cell.Y-minY
+		if x < 0 || y < 0 || x >= width || y >= height {
+			continue
+		}
+		arrangement[tile{X: x / 2, Y: y / 2}] |= 1 << (x%2 + 2*(y%2))
+	}
+	var counts [16]int
+	counts[0] = blocks - len(arrangement)
+	for _, a := range arrangement {
+		counts[a]++
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / float64(blocks)
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy / 4
+}
+
+// drawMetrics charts the population, heat, density and entropy of the
+// recent generations in the bottom right corner, with the latest values
+// above
+func (w *World) drawMetrics(screen *ebiten.Image) {
+	samples := w.metrics.samples
+	if !w.metrics.visible || len(samples) == 0 {
+		return
+	}
+	last := samples[len(samples)-1]
+	label := fmt.Sprintf("Population %d  Heat %d  Density %.1f%%  Entropy %.2f", last.population, last.heat, 100*last.density, last.entropy)
+
+	width := float32(max(metricSamples*2, len(label)*charWidth+8))
+	left := float32(w.screenWidth) - width - 4
+	top := float32(w.screenHeight - metricChartHeight - charHeight - 12)
+	vector.DrawFilledRect(screen, left, top, width, metricChartHeight+charHeight+8, overlayBackground, false)
+	ebitenutil.DebugPrintAt(screen, label, int(left)+4, int(top)+2)
+
+	// The population and heat are scaled to the largest shown, the others
+	// are shares
+	busiest, hottest := 1, 1
+	for _, s := range samples {
+		busiest, hottest = max(busiest, s.population), max(hottest, s.heat)
+	}
+	bottom := top + charHeight + 4 + metricChartHeight
+	step := width / metricSamples
+	line := func(value func(s metricSample) float64, c color.RGBA) {
+		for i := 1; i < len(samples); i++ {
+			x0, x1 := left+float32(i-1)*step, left+float32(i)*step
+			y0 := bottom - metricChartHeight*float32(value(samples[i-1]))
+			y1 := bottom - metricChartHeight*float32(value(samples[i]))
+			vector.StrokeLine(screen, x0, y0, x1, y1, 1, c, w.antialias)
+		}
+	}
+	line(func(s metricSample) float64 { return float64(s.population) / float64(busiest) }, w.theme.Cell)
+	line(func(s metricSample) float64 { return float64(s.heat) / float64(hottest) }, w.theme.Warning)
+	line(func(s metricSample) float64 { return s.density }, w.theme.Accent)
+	line(func(s metricSample) float64 { return s.entropy }, w.theme.Heat)
+}
@@ -0,0 +1,120 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// mode is what the game is doing, which decides where the input goes. It is
+// worked out from the world each frame rather than stored, so it can't
+// disagree with what is on screen.
+type mode int
+
+const (
+	// modeMenu is when an overlay such as the settings menu, a pattern
+	// picker, the help or the command line has all the input, or the title
+	// screen is shown
+	modeMenu mode = iota
+	// modeStamping places the stamp under the mouse
+	modeStamping
+	// modeSelecting drags out a selection
+	modeSelecting
+	// modeEditing is when the keyboard cursor is shown, so the arrow keys
+	// move it rather than pan and space toggles the cell under it
+	modeEditing
+	// modeRunning and modePaused paint and erase cells, the simulation
+	// only advances while running
+	modeRunning
+	modePaused
+)
+
+// String returns the name of the mode
+func (m mode) String() string {
+	switch m {
+	case modeMenu:
+		return "menu"
+	case modeStamping:
+		return "stamping"
+	case modeSelecting:
+		return "selecting"
+	case modeEditing:
+		return "editing"
+	case modeRunning:
+		return "running"
+	}
+	return "paused"
+}
+
+// modes is a set of modes, the ones a binding applies in
+type modes uint8
+
+// modesOf returns the set of the modes given
+func modesOf(ms ...mode) modes {
+	var s modes
+	for _, m := range ms {
+		s |= 1 << m
+	}
+	return s
+}
+
+// has reports whether m is in the set
+func (s modes) has(m mode) bool {
+	return s&(1<<m) != 0
+}
+
+// mode returns the current mode, overlays come before the editing modes,
+// the stamp before the selection and both before the keyboard cursor
+func (g *Game) mode() mode {
+	switch {
+	case g.menu.open, g.picker.open, g.catalog.open, g.methuselahs.open, g.puzzles.open, g.recent.open, g.census.open, g.help, g.command.open,
+		g.scene == sceneTitle:
+		return modeMenu
+	case g.world.stamp != nil:
+		return modeStamping
+	case g.world.selecting:
+		return modeSelecting
+	case g.world.cursor.active:
+		return modeEditing
+	case g.world.isSimulating:
+		return modeRunning
+	}
+	return modePaused
+}
+
+// handleOverlay gives the input to the open overlay, then to the title
+// screen, whose menu opens some of them
+func (g *Game) handleOverlay() {
+	switch {
+	case g.menu.open:
+		g.handleMenu()
+	case g.picker.open:
+		g.handlePicker()
+	case g.catalog.open:
+		g.handleCatalog()
+	case g.methuselahs.open:
+		g.handleMethuselahs()
+	case g.puzzles.open:
+		g.handlePuzzles()
+	case g.recent.open:
+		g.handleRecent()
+	case g.census.open:
+		g.handleCensus()
+	case g.help:
+		g.handleHelp()
+	case g.command.open:
+		g.handleCommand()
+	case g.scene == sceneTitle:
+		g.handleTitle()
+	}
+}
+
+// handlePointer gives the mouse to the mode. It is also called on release
+// to end a stroke or drag.
+func (g *Game) handlePointer(m mode) {
+	x, y := ebiten.CursorPosition()
+	switch m {
+	case modeStamping:
+		g.world.handleStamp(x, y)
+	case modeSelecting:
+		g.world.handleSelection(x, y)
+	case modeEditing, modeRunning, modePaused:
+		g.world.handleMouseClick(x, y)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// stroke tracks a mouse drag across the grid, painting with the left button
+// and erasing with the right
+type stroke struct {
+	active bool
+	alive  bool
+	last   tile
+}
+
+// handleMouseClick paints cells while the left button is held and erases
+// them while the right button is held
+func (w *World) handleMouseClick(x, y int) {
+	paint := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
+	erase := ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
+	pressed := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight)
+	w.paintStroke(x, y, paint, erase, pressed)
+}
+
+// paintStroke paints or erases the cell at x, y as part of a stroke, and
+// ends the stroke when neither is held. pressed is set on the first frame
+// of a press.
+func (w *World) paintStroke(x, y int, paint, erase, pressed bool) {
+	if !paint && !erase {
+		if w.stroke.active {
+			w.stroke.active = false
+			w.commitEdit()
+		}
+		return
+	}
+
+	// Players of a versus game place one cell a click and can't erase
+	if w.versus != nil {
+		if _, placing := w.versusPlacing(); placing && paint && pressed {
+			w.placeVersusCell(x, y)
+		}
+		return
+	}
+
+	// Calculate the cell clicked
+	clickedCell, ok := w.screenToCell(x, y)
+	if !ok {
+		return
+	}
+	if !w.stroke.active || w.stroke.alive != paint {
+		// Strokes only start on a fresh press, so a click used for
+		// something else doesn't start painting while still held
+		if !pressed {
+			return
+		}
+		// Start a new stroke, switching buttons mid drag starts over
+		w.commitEdit()
+		w.beginEdit()
+		w.stroke = stroke{active: true, alive: paint, last: clickedCell}
+		w.paintCell(clickedCell, w.stroke.alive)
+		return
+	}
+	if clickedCell == w.stroke.last {
+		return
+	}
+
+	// Fill the gap from the previous cell so fast sweeps draw solid lines
+	for _, cell := range cellLine(w.stroke.last, clickedCell) {
+		w.paintCell(cell, w.stroke.alive)
+	}
+	w.stroke.last = clickedCell
+}
+
+// setCellState makes a cell alive in a state as part of an edit. Only
+// multi-state rules have states past 1.
+func (w *World) setCellState(cell tile, s life.State) {
+	w.setCell(cell, true)
+	if s > 1 && int(s) < w.grid.States() {
+		w.grid.SetState(cell, s)
+	}
+}
+
+// cellStates returns the states of the live cells for writeRLE, nil when
+// the rule only has live and dead cells
+func (w *World) cellStates() func(tile) life.State {
+	if w.grid.States() <= 2 {
+		return nil
+	}
+	return w.grid.State
+}
+
+// setCell makes a cell alive or dead
+func (w *World) setCell(cell tile, alive bool) {
+	w.recordChange(cell, alive)
+	w.cycle.clear()
+	w.census = nil
+	w.populations = nil
+	w.stopped = ""
+	if w.puzzle != nil && w.grid.Generation > 0 {
+		w.puzzle.edited = true
+	}
+	w.still.touch(cell, w.grid.Generation)
+	if alive {
+		turn, placing := w.versusPlacing()
+		w.grid.Set(cell, true)
+		if placing {
+			w.grid.SetState(cell, life.State(turn+1))
+		}
+		if w.owners != nil {
+			w.owners[cell] = w.player
+		}
+	} else {
+		w.grid.Set(cell, false)
+		delete(w.ages, cell)
+		delete(w.owners, cell)
+	}
+	// Edits show straight away rather than fading like births and deaths
+	if w.previous != nil {
+		if alive {
+			w.previous[cell] = struct{}{}
+		} else {
+			delete(w.previous, cell)
+		}
+	}
+}
+
+// cellLine returns the cells on a straight line from a to b, including both
+// ends, using Bresenham's algorithm
+func cellLine(a, b tile) []tile {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+
+	var cells []tile
+	err := dx + dy
+	for {
+		cells = append(cells, a)
+		if a == b {
+			return cells
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			a.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			a.Y += sy
+		}
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// patternEntry is a pattern listed in the picker, user patterns have a path
+type patternEntry struct {
+	name string
+	path string
+}
+
+// load parses the pattern the entry refers to
+func (e patternEntry) load() (*pattern, error) {
+	if e.path == "" {
+		return loadBuiltinPattern(e.name)
+	}
+	return loadPattern(e.path)
+}
+
+// label is how the entry is shown in the picker
+func (e patternEntry) label() string {
+	if e.path == "" {
+		return e.name
+	}
+	return e.name + " (user)"
+}
+
+// defaultPatternDir returns the user pattern directory next to the config
+// file, or an empty string when there is no config directory
+func defaultPatternDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gameoflife", "patterns")
+}
+
+// listPatterns returns the built-in patterns followed by the RLE files in
+// the user pattern directory
+func listPatterns(dir string) []patternEntry {
+	var entries []patternEntry
+	for _, name := range builtinPatternNames() {
+		entries = append(entries, patternEntry{name: name})
+	}
+	if dir == "" {
+		return entries
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.rle"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		entries = append(entries, patternEntry{
+			name: strings.TrimSuffix(filepath.Base(path), ".rle"),
+			path: path,
+		})
+	}
+	return entries
+}
+
+// findDuplicate returns the pattern in the library with the same shape as
+// cells, however either is turned or mirrored. The user pattern called
+// name is skipped so it can be saved over.
+func findDuplicate(dir, name string, cells map[tile]struct{}) (patternEntry, bool) {
+	fp := life.Fingerprint(cells)
+	for _, e := range listPatterns(dir) {
+		if e.path != "" && e.name == name {
+			continue
+		}
+		p, err := e.load()
+		if err != nil || len(p.cells) != len(cells) {
+			continue
+		}
+		if life.Fingerprint(p.cellSet()) == fp {
+			return e, true
+		}
+	}
+	return patternEntry{}, false
+}
+
+// patternPicker is a scrollable overlay listing the patterns that can be
+// stamped onto the grid
+type patternPicker struct {
+	open     bool
+	entries  []patternEntry
+	selected int
+	scroll   int
+}
+
+// show opens the picker with a fresh list of patterns
+func (p *patternPicker) show(dir string) {
+	p.open = true
+	p.entries = listPatterns(dir)
+	p.selected = min(p.selected, max(len(p.entries)-1, 0))
+}
+
+// pickerLayout returns the position of the picker box and how many rows fit
+func pickerLayout(w *World) (x, y, rows int) {
+	x, y = 40, w.gridTop+20
+	rows = max((w.screenHeight-y-20-16)/charHeight-3, 1)
+	return x, y, rows
+}
+
+// handlePicker moves through the list with the arrow keys, page keys or
+// mouse wheel and enters stamp mode with the chosen pattern on enter or click
+func (g *Game) handlePicker() {
+	p := &g.picker
+	_, y, rows := pickerLayout(g.world)
+	_, wheel := ebiten.Wheel()
+	n := len(p.entries)
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		p.open = false
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		p.selected--
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		p.selected++
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageUp):
+		p.selected -= rows
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageDown):
+		p.selected += rows
+	case wheel > 0:
+		p.scroll--
+	case wheel < 0:
+		p.scroll++
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		g.pickPattern()
+		return
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		// Rows start after the title and a blank line
+		_, cy := ebiten.CursorPosition()
+		row := (cy-y-8)/charHeight - 2
+		if row >= 0 && row < rows && p.scroll+row < n {
+			p.selected = p.scroll + row
+			g.pickPattern()
+		}
+		return
+	}
+
+	// Keep the selection in range and scrolled into view
+	p.selected = min(max(p.selected, 0), max(n-1, 0))
+	if wheel == 0 {
+		p.scroll = min(p.scroll, p.selected)
+		p.scroll = max(p.scroll, p.selected-rows+1)
+	}
+	p.scroll = min(max(p.scroll, 0), max(n-rows, 0))
+}
+
+// pickPattern loads the selected pattern into stamp mode
+func (g *Game) pickPattern() {
+	p := &g.picker
+	if p.selected >= len(p.entries) {
+		return
+	}
+	pat, err := p.entries[p.selected].load()
+	if err != nil {
+		log.Printf("loading pattern: %v", err)
+		return
+	}
+	g.world.stamp = pat
+	p.open = false
+}
+
+// draw shows the visible part of the list
+func (p *patternPicker) draw(screen *ebiten.Image, w *World) {
+	if !p.open {
+		return
+	}
+	x, y, rows := pickerLayout(w)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Patterns (%d)\n\n", len(p.entries))
+	for i := p.scroll; i < min(p.scroll+rows, len(p.entries)); i++ {
+		cursor := "  "
+		if i == p.selected {
+			cursor = "> "
+		}
+		fmt.Fprintf(&b, "%s%s\n", cursor, p.entries[i].label())
+	}
+	b.WriteString("\nEnter or click to stamp, Esc to close")
+
+	boxWidth := w.screenWidth - 2*x
+	boxHeight := (rows+4)*charHeight + 16
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(boxWidth), float32(boxHeight), overlayBackground, false)
+	ebitenutil.DebugPrintAt(screen, b.String(), x+8, y+8)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// populationLog appends the population, births and deaths of every
+// generation to a CSV file, so long runs can be looked at afterwards
+type populationLog struct {
+	f *os.File
+}
+
+// openPopulationLog opens path for appending, writing the column names
+// first when the file is new or empty
+func openPopulationLog(path string) (*populationLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.Size() == 0 {
+		_, err = fmt.Fprintln(f, "generation,population,births,deaths")
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &populationLog{f: f}, nil
+}
+
+// record appends a generation. Lines are written straight away so nothing
+// is lost when a run is interrupted. A nil log records nothing.
+func (l *populationLog) record(s life.GenerationStats) {
+	if l == nil || l.f == nil {
+		return
+	}
+	if _, err := fmt.Fprintf(l.f, "%d,%d,%d,%d\n", s.Generation, s.Population, s.Births, s.Deaths); err != nil {
+		// Logging stops rather than failing the run
+		log.Printf("population log: %v", err)
+		l.f.Close()
+		l.f = nil
+	}
+}
+
+// close closes the file
+func (l *populationLog) close() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
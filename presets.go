@@ -0,0 +1,56 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// builtinPatterns holds the preset patterns shipped with the game
+//
+//go:embed patterns/*.rle
+var builtinPatterns embed.FS
+
+// builtinPatternNames lists the names of all embedded patterns
+func builtinPatternNames() []string {
+	entries, err := builtinPatterns.ReadDir("patterns")
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".rle"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadBuiltinPattern parses an embedded pattern by name, e.g. "gosper-glider-gun"
+func loadBuiltinPattern(name string) (*pattern, error) {
+	f, err := builtinPatterns.Open(path.Join("patterns", name+".rle"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown built-in pattern %q", name)
+	}
+	defer f.Close()
+
+	p, err := parseRLE(f)
+	if err != nil {
+		return nil, fmt.Errorf("built-in pattern %s: %w", name, err)
+	}
+	if p.name == "" {
+		p.name = name
+	}
+	return p, nil
+}
+
+// mustLoadBuiltinPattern is like loadBuiltinPattern but panics on error, the
+// embedded assets are fixed at build time so an error is a programming bug.
+func mustLoadBuiltinPattern(name string) *pattern {
+	p, err := loadBuiltinPattern(name)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
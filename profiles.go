@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// profiles are sets of key remappings chosen by name in the config file.
+// Keys from the config file are applied on top of the profile.
+var profiles = map[string]map[string][]string{
+	"default": nil,
+	// vim moves with hjkl and takes a count before stepping or moving the
+	// cursor, such as 10n or 5l. The keys hjkl replace are moved out of the
+	// way and the digits are kept for counts.
+	"vim": {
+		"pan-left":       {"H", "ArrowLeft"},
+		"pan-down":       {"J", "ArrowDown"},
+		"pan-up":         {"K", "ArrowUp"},
+		"pan-right":      {"L", "ArrowRight"},
+		"cursor-left":    {"H", "ArrowLeft"},
+		"cursor-down":    {"J", "ArrowDown"},
+		"cursor-up":      {"K", "ArrowUp"},
+		"cursor-right":   {"L", "ArrowRight"},
+		"help":           {"Shift+Slash", "F1"},
+		"symmetry":       {"Shift+K"},
+		"grid":           {"Shift+L"},
+		"undo":           {"U", "Ctrl+Z"},
+		"redo":           {"Ctrl+R", "Ctrl+Y"},
+		"record-macro":   {"Shift+Q"},
+		"census":         {"Shift+U"},
+		"gun":            {"Shift+1"},
+		"pulsar":         {"Shift+2"},
+		"pentadecathlon": {"Shift+3"},
+		"beacon":         {"Shift+4"},
+		"toad":           {"Shift+5"},
+		"blinker":        {"Shift+6"},
+		"glider":         {"Shift+7"},
+		"lwss":           {"Shift+8"},
+		"mwss":           {"Shift+9"},
+		"hwss":           {"Shift+0"},
+		"r-pentomino":    {"Alt+1"},
+		"acorn":          {"Alt+2"},
+		"diehard":        {"Alt+3"},
+		"puffer-train":   {"Alt+4"},
+		"max":            {"Alt+5"},
+	},
+}
+
+// profileNames lists the names of the key profiles
+func profileNames() []string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile remaps the keys for a profile
+func applyProfile(name string) error {
+	keymap, ok := profiles[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown key profile %q, available profiles: %s", name, strings.Join(profileNames(), ", "))
+	}
+	return applyKeymap(keymap)
+}
+
+// maxCount stops a long count from running the simulation for too long
+const maxCount = 10000
+
+// digitKeys are the keys typed to give a count, in order of their value
+var digitKeys = []ebiten.Key{
+	ebiten.Key0, ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4,
+	ebiten.Key5, ebiten.Key6, ebiten.Key7, ebiten.Key8, ebiten.Key9,
+}
+
+// handleCount collects the digits typed before a command in the vim
+// profile. A leading 0 isn't a count, and Esc cancels the count, returning
+// true so that Esc doesn't quit as well.
+func (g *Game) handleCount() bool {
+	if g.profile != "vim" {
+		return false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && g.count > 0 {
+		g.count = 0
+		return true
+	}
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		d := slices.Index(digitKeys, k)
+		if d < 0 || d == 0 && g.count == 0 || !key(k).modifiersHeld() {
+			continue
+		}
+		g.count = min(g.count*10+d, maxCount)
+	}
+	return false
+}
+
+// takeCount returns the typed count, 1 without one, and starts a new count
+func (g *Game) takeCount() int {
+	n := max(g.count, 1)
+	g.count = 0
+	return n
+}
@@ -0,0 +1,198 @@
+// Package gameoflifepb holds the messages of the control service defined in
+// gameoflife.proto and their protobuf encoding.
+//
+// The messages are written by hand rather than generated, so the module
+// doesn't need the protobuf runtime. Their field numbers and types are those
+// of gameoflife.proto and have to be kept in step with it. Clients in other
+// languages generate their code from gameoflife.proto as usual.
+package gameoflifepb
+
+// Cell is a cell in grid coordinates
+type Cell struct {
+	X, Y int64
+}
+
+// Marshal returns the encoded cell
+func (m *Cell) Marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, m.X)
+	b = appendInt(b, 2, m.Y)
+	return b
+}
+
+// Unmarshal decodes a cell
+func (m *Cell) Unmarshal(b []byte) error {
+	*m = Cell{}
+	return readFields(b, func(f field) error {
+		switch {
+		case f.num == 1 && f.wire == wireVarint:
+			m.X = int64(f.value)
+		case f.num == 2 && f.wire == wireVarint:
+			m.Y = int64(f.value)
+		}
+		return nil
+	})
+}
+
+// StepRequest asks to advance the simulation a number of generations
+type StepRequest struct {
+	Generations int32
+}
+
+// Marshal returns the encoded request
+func (m *StepRequest) Marshal() []byte {
+	return appendInt(nil, 1, int64(m.Generations))
+}
+
+// Unmarshal decodes a request
+func (m *StepRequest) Unmarshal(b []byte) error {
+	*m = StepRequest{}
+	return readFields(b, func(f field) error {
+		if f.num == 1 && f.wire == wireVarint {
+			m.Generations = int32(f.value)
+		}
+		return nil
+	})
+}
+
+// LoadPatternRequest asks to replace the grid with a pattern, which is RLE
+// text or the name of a built-in pattern
+type LoadPatternRequest struct {
+	Pattern string
+}
+
+// Marshal returns the encoded request
+func (m *LoadPatternRequest) Marshal() []byte {
+	return appendString(nil, 1, m.Pattern)
+}
+
+// Unmarshal decodes a request
+func (m *LoadPatternRequest) Unmarshal(b []byte) error {
+	*m = LoadPatternRequest{}
+	return readFields(b, func(f field) error {
+		if f.num == 1 && f.wire == wireBytes {
+			m.Pattern = string(f.data)
+		}
+		return nil
+	})
+}
+
+// GetStateRequest asks for the current generation, it has no fields
+type GetStateRequest struct{}
+
+// Marshal returns the encoded request
+func (m *GetStateRequest) Marshal() []byte {
+	return nil
+}
+
+// Unmarshal decodes a request, skipping any fields
+func (m *GetStateRequest) Unmarshal(b []byte) error {
+	return readFields(b, func(field) error { return nil })
+}
+
+// SubscribeRequest asks for the changes of every generation, it has no
+// fields
+type SubscribeRequest struct{}
+
+// Marshal returns the encoded request
+func (m *SubscribeRequest) Marshal() []byte {
+	return nil
+}
+
+// Unmarshal decodes a request, skipping any fields
+func (m *SubscribeRequest) Unmarshal(b []byte) error {
+	return readFields(b, func(field) error { return nil })
+}
+
+// State is a generation and its live cells
+type State struct {
+	Generation int64
+	Population int64
+	Rule       string
+	Running    bool
+	Cells      []Cell
+}
+
+// Marshal returns the encoded state
+func (m *State) Marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, m.Generation)
+	b = appendInt(b, 2, m.Population)
+	b = appendString(b, 3, m.Rule)
+	b = appendBool(b, 4, m.Running)
+	for i := range m.Cells {
+		b = appendMessage(b, 5, m.Cells[i].Marshal())
+	}
+	return b
+}
+
+// Unmarshal decodes a state
+func (m *State) Unmarshal(b []byte) error {
+	*m = State{}
+	return readFields(b, func(f field) error {
+		switch {
+		case f.num == 1 && f.wire == wireVarint:
+			m.Generation = int64(f.value)
+		case f.num == 2 && f.wire == wireVarint:
+			m.Population = int64(f.value)
+		case f.num == 3 && f.wire == wireBytes:
+			m.Rule = string(f.data)
+		case f.num == 4 && f.wire == wireVarint:
+			m.Running = f.value != 0
+		case f.num == 5 && f.wire == wireBytes:
+			var c Cell
+			if err := c.Unmarshal(f.data); err != nil {
+				return err
+			}
+			m.Cells = append(m.Cells, c)
+		}
+		return nil
+	})
+}
+
+// Change is the cells born and died in a generation. Reset is set on the
+// first change sent to a subscriber, whose born cells are the whole grid.
+type Change struct {
+	Generation int64
+	Reset      bool
+	Born       []Cell
+	Died       []Cell
+}
+
+// Marshal returns the encoded change
+func (m *Change) Marshal() []byte {
+	var b []byte
+	b = appendInt(b, 1, m.Generation)
+	b = appendBool(b, 2, m.Reset)
+	for i := range m.Born {
+		b = appendMessage(b, 3, m.Born[i].Marshal())
+	}
+	for i := range m.Died {
+		b = appendMessage(b, 4, m.Died[i].Marshal())
+	}
+	return b
+}
+
+// Unmarshal decodes a change
+func (m *Change) Unmarshal(b []byte) error {
+	*m = Change{}
+	return readFields(b, func(f field) error {
+		switch {
+		case f.num == 1 && f.wire == wireVarint:
+			m.Generation = int64(f.value)
+		case f.num == 2 && f.wire == wireVarint:
+			m.Reset = f.value != 0
+		case (f.num == 3 || f.num == 4) && f.wire == wireBytes:
+			var c Cell
+			if err := c.Unmarshal(f.data); err != nil {
+				return err
+			}
+			if f.num == 3 {
+				m.Born = append(m.Born, c)
+			} else {
+				m.Died = append(m.Died, c)
+			}
+		}
+		return nil
+	})
+}
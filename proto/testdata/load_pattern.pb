@@ -0,0 +1,2 @@
+
+acorn
\ No newline at end of file
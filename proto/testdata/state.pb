@@ -0,0 +1 @@
+B36/S23 ** 
\ No newline at end of file
@@ -0,0 +1,136 @@
+package gameoflifepb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Wire types of the protobuf encoding
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("gameoflifepb: message is cut short")
+
+// appendVarint appends v in base 128, low groups of seven bits first
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// appendTag appends the key of a field
+func appendTag(b []byte, num, wire int) []byte {
+	return appendVarint(b, uint64(num)<<3|uint64(wire))
+}
+
+// appendInt appends an integer field, which proto3 leaves out when zero.
+// Negative numbers take ten bytes, as int32 and int64 fields aren't zigzag
+// encoded.
+func appendInt(b []byte, num int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	return appendVarint(appendTag(b, num, wireVarint), uint64(v))
+}
+
+// appendBool appends a bool field, left out when false
+func appendBool(b []byte, num int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendVarint(appendTag(b, num, wireVarint), 1)
+}
+
+// appendString appends a string field, left out when empty
+func appendString(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendVarint(appendTag(b, num, wireBytes), uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendMessage appends an embedded message. It is kept when empty, so
+// each element of a repeated field is there.
+func appendMessage(b []byte, num int, m []byte) []byte {
+	b = appendVarint(appendTag(b, num, wireBytes), uint64(len(m)))
+	return append(b, m...)
+}
+
+// consumeVarint reads a varint from the start of b and returns it with the
+// number of bytes it took
+func consumeVarint(b []byte) (uint64, int, error) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1, nil
+		}
+	}
+	if len(b) >= 10 {
+		return 0, 0, errors.New("gameoflifepb: varint is too long")
+	}
+	return 0, 0, errTruncated
+}
+
+// field is a field read from a message. Varints are in value, the bytes of
+// strings and embedded messages in data.
+type field struct {
+	num   int
+	wire  int
+	value uint64
+	data  []byte
+}
+
+// readFields calls f with each field of a message in order. Fixed width
+// fields aren't used by these messages, so they're read past like unknown
+// fields are by f.
+func readFields(b []byte, f func(field) error) error {
+	for len(b) > 0 {
+		key, n, err := consumeVarint(b)
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+		fd := field{num: int(key >> 3), wire: int(key & 7)}
+		if fd.num == 0 {
+			return errors.New("gameoflifepb: field number 0")
+		}
+		switch fd.wire {
+		case wireVarint:
+			if fd.value, n, err = consumeVarint(b); err != nil {
+				return err
+			}
+		case wireFixed64:
+			n = 8
+		case wireFixed32:
+			n = 4
+		case wireBytes:
+			length, m, err := consumeVarint(b)
+			if err != nil {
+				return err
+			}
+			if length > uint64(len(b)-m) {
+				return errTruncated
+			}
+			fd.data = b[m : m+int(length)]
+			n = m + int(length)
+		default:
+			return fmt.Errorf("gameoflifepb: field %d has unsupported wire type %d", fd.num, fd.wire)
+		}
+		if n > len(b) {
+			return errTruncated
+		}
+		b = b[n:]
+		if err := f(fd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
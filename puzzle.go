@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// puzzle is a challenge played under Conway's rule: starting from at most
+// cells live cells, reach the target within generations, or with no target
+// keep the world alive and changing until then
+type puzzle struct {
+	name string
+	// target is the name of a built-in pattern or the cells in RLE
+	target      string
+	cells       int
+	generations int
+}
+
+// puzzles are the challenges offered in the puzzle menu, easiest first
+var puzzles = []puzzle{
+	{name: "Block party", target: "2o$2o!", cells: 3, generations: 1},
+	{name: "First flight", target: "glider", cells: 5, generations: 4},
+	{name: "Hive mind", target: "b2o$o2bo$b2o!", cells: 4, generations: 2},
+	{name: "Traffic light", target: "4bo$4bo$4bo2$3o3b3o2$4bo$4bo$4bo!", cells: 4, generations: 10},
+	{name: "Long fuse", cells: 5, generations: 1000},
+	{name: "Slow burn", cells: 7, generations: 5000},
+}
+
+// goal describes what has to be done to solve the puzzle
+func (p *puzzle) goal() string {
+	if p.target == "" {
+		return fmt.Sprintf("keep changing for %d generations from at most %d cells", p.generations, p.cells)
+	}
+	return fmt.Sprintf("become the target within %d generations from at most %d cells", p.generations, p.cells)
+}
+
+// puzzleRun is the puzzle being played in the world
+type puzzleRun struct {
+	puzzle *puzzle
+	// rule is the rule in use before the puzzle, put back when leaving it
+	rule string
+	// target is the target's cells normalized, nil for puzzles without one
+	target map[tile]struct{}
+	// started is how many cells the attempt started from, edited is set
+	// when cells were changed after it started
+	started int
+	edited  bool
+	// result is set once the attempt is over, solved says how it went
+	result string
+	solved bool
+}
+
+// loadPuzzleTarget returns the normalized cells of a puzzle's target
+func loadPuzzleTarget(p *puzzle) (map[tile]struct{}, error) {
+	if p.target == "" {
+		return nil, nil
+	}
+	pat, err := loadObjectPattern(p.target)
+	if err != nil {
+		return nil, fmt.Errorf("puzzle %s: %v", p.name, err)
+	}
+	return life.Normalize(pat.cellSet()), nil
+}
+
+// startPuzzle clears the world and switches to Conway's rule for a puzzle
+func (w *World) startPuzzle(p *puzzle) error {
+	target, err := loadPuzzleTarget(p)
+	if err != nil {
+		return err
+	}
+	w.leaveGameModes()
+	rule := w.grid.RuleName()
+	if err := w.grid.SetRule(life.Conway); err != nil {
+		return err
+	}
+	w.beginEdit()
+	w.setCells(make(map[tile]struct{}))
+	w.commitEdit()
+	w.isSimulating = false
+	w.puzzle = &puzzleRun{puzzle: p, rule: rule, target: target}
+	return nil
+}
+
+// reset starts the attempt over, the cells are back at generation 0
+func (r *puzzleRun) reset() {
+	if r == nil {
+		return
+	}
+	r.started, r.edited = 0, false
+	r.result, r.solved = "", false
+}
+
+// rewound forgets the result after rewinding to gen, and the attempt too
+// when that is the start
+func (r *puzzleRun) rewound(gen int) {
+	if r == nil {
+		return
+	}
+	if gen == 0 {
+		r.reset()
+		return
+	}
+	r.result, r.solved = "", false
+}
+
+// startAttempt is called before stepping and refuses to leave generation 0
+// with too many cells or none at all
+func (w *World) startAttempt() bool {
+	r := w.puzzle
+	if r == nil || w.grid.Generation != 0 {
+		return true
+	}
+	r.reset()
+	switch n := w.grid.Population(); {
+	case n == 0:
+		w.stop("Place some cells to start the puzzle")
+		return false
+	case n > r.puzzle.cells:
+		w.stop(fmt.Sprintf("Too many cells, %d of at most %d", n, r.puzzle.cells))
+		return false
+	default:
+		r.started = n
+	}
+	return true
+}
+
+// checkPuzzle works out whether the attempt is over after a step, stopping
+// the simulation with the result when it is. It returns whether it stopped.
+func (w *World) checkPuzzle() bool {
+	r := w.puzzle
+	if r == nil || r.result != "" || r.edited || r.started == 0 {
+		return false
+	}
+	gen, cells := w.grid.Generation, w.grid.Cells()
+	p := r.puzzle
+	settled := w.cycle.period > 0 || w.cycle.moving > 0
+	switch {
+	case r.target != nil && sameObject(cells, r.target):
+		r.result, r.solved = fmt.Sprintf("Solved %s at generation %d from %d cells", p.name, gen, r.started), true
+	case len(cells) == 0:
+		r.result = fmt.Sprintf("Died out at generation %d, clear or rewind to try again", gen)
+	case r.target != nil && settled:
+		r.result = fmt.Sprintf("Settled at generation %d without becoming the target, try again", gen)
+	case r.target == nil && settled:
+		r.result = fmt.Sprintf("Settled at generation %d, before %d, try again", gen, p.generations)
+	case r.target == nil && gen >= p.generations:
+		r.result, r.solved = fmt.Sprintf("Solved %s, still changing at generation %d from %d cells", p.name, gen, r.started), true
+	case gen >= p.generations:
+		r.result = fmt.Sprintf("Not the target by generation %d, clear or rewind to try again", p.generations)
+	default:
+		return false
+	}
+	// The result is shown by the puzzle rather than as a stop reason
+	w.isSimulating = false
+	return true
+}
+
+// sameObject reports whether cells are the same shape as normalized cells,
+// anywhere and turned or mirrored in any way
+func sameObject(cells, normal map[tile]struct{}) bool {
+	if len(cells) != len(normal) || life.Fingerprint(cells) != life.Fingerprint(normal) {
+		return false
+	}
+	dx, dy, ok := sameShape(life.Normalize(cells), normal)
+	return ok && dx == 0 && dy == 0
+}
+
+// puzzleText describes the puzzle being played for the HUD, how many cells
+// are placed before it starts and how it went after
+func (w *World) puzzleText() string {
+	r := w.puzzle
+	switch {
+	case r.result != "":
+		return r.result
+	case r.edited:
+		return fmt.Sprintf("Puzzle %s: cells changed after the start, clear or rewind to try again", r.puzzle.name)
+	case w.grid.Generation == 0:
+		return fmt.Sprintf("Puzzle %s: %d of %d cells placed", r.puzzle.name, w.grid.Population(), r.puzzle.cells)
+	}
+	return fmt.Sprintf("Puzzle %s: generation %d of %d", r.puzzle.name, w.grid.Generation, r.puzzle.generations)
+}
+
+// puzzleHighlight is the HUD color of the puzzle, the warning color when
+// the attempt failed or has too many cells
+func (w *World) puzzleHighlight() color.Color {
+	r := w.puzzle
+	switch {
+	case r.solved:
+		return w.theme.Accent
+	case r.result != "" || r.edited:
+		return w.theme.Warning
+	case w.grid.Generation == 0 && w.grid.Population() > r.puzzle.cells:
+		return w.theme.Warning
+	}
+	return nil
+}
+
+// puzzleMenu is an overlay for choosing a puzzle to play
+type puzzleMenu struct {
+	open     bool
+	selected int
+}
+
+// handlePuzzles moves through the menu with up and down and starts the
+// selected puzzle on enter. The entry after the puzzles leaves the one
+// being played.
+func (g *Game) handlePuzzles() {
+	m := &g.puzzles
+	entries := len(puzzles) + 1
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		m.open = false
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		m.selected = cycle(m.selected, entries, -1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		m.selected = cycle(m.selected, entries, 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		m.open = false
+		if m.selected == len(puzzles) {
+			g.world.leaveGameModes()
+			return
+		}
+		if err := g.world.startPuzzle(&puzzles[m.selected]); err != nil {
+			g.command.show(err.Error())
+		}
+	}
+}
+
+// draw lists the puzzles in the middle of the screen with the goal and
+// target of the selected one
+func (m *puzzleMenu) draw(screen *ebiten.Image, w *World) {
+	if !m.open {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("Puzzles\n\n")
+	for i, p := range puzzles {
+		cursor := "  "
+		if i == m.selected {
+			cursor = "> "
+		}
+		solved := ""
+		if r := w.puzzle; r != nil && r.puzzle == &puzzles[i] && r.solved {
+			solved = " (solved)"
+		}
+		fmt.Fprintf(&b, "%s%s%s\n", cursor, p.name, solved)
+	}
+	if m.selected == len(puzzles) {
+		b.WriteString("> Stop playing\n")
+	} else {
+		b.WriteString("  Stop playing\n")
+		p := &puzzles[m.selected]
+		fmt.Fprintf(&b, "\n%s: %s\n", p.name, p.goal())
+		if target, err := loadPuzzleTarget(p); err == nil && target != nil {
+			b.WriteString("\n" + cellPicture(target))
+		}
+	}
+	b.WriteString("\nUp/Down select, Enter to start\nEsc to close")
+
+	drawCentredBox(screen, w, b.String())
+}
+
+// cellPicture draws cells starting at 0,0 as text, O for live cells
+func cellPicture(cells map[tile]struct{}) string {
+	width, height := 0, 0
+	for cell := range cells {
+		width, height = max(width, cell.X+1), max(height, cell.Y+1)
+	}
+	var b strings.Builder
+	for y := range height {
+		for x := range width {
+			if _, alive := cells[tile{X: x, Y: y}]; alive {
+				b.WriteByte('O')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// maxRecent is how many recently placed patterns are remembered, one for
+// each digit key in the menu
+const maxRecent = 9
+
+// recentPatterns are the named stamps placed most recently, newest first
+type recentPatterns []*pattern
+
+// add moves p to the front of the list, replacing an earlier placement of
+// the same pattern so each name is listed once. Unnamed stamps such as the
+// clipboard are not remembered.
+func (r *recentPatterns) add(p *pattern) {
+	if p.name == "" {
+		return
+	}
+	list := recentPatterns{p}
+	for _, q := range *r {
+		if q.name != p.name && len(list) < maxRecent {
+			list = append(list, q)
+		}
+	}
+	*r = list
+}
+
+// recentMenu is an overlay for stamping a recently placed pattern again
+// without going through the picker
+type recentMenu struct {
+	open     bool
+	selected int
+}
+
+// handleRecent moves through the menu with up and down and stamps the
+// selected pattern on enter, or the pattern with that number on a digit key
+func (g *Game) handleRecent() {
+	m := &g.recent
+	list := g.world.recent
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		m.open = false
+		return
+	case len(list) == 0:
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		m.selected = cycle(m.selected, len(list), -1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		m.selected = cycle(m.selected, len(list), 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		g.pickRecent(m.selected)
+	}
+	for i := range list {
+		if inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(i)) {
+			g.pickRecent(i)
+		}
+	}
+}
+
+// pickRecent enters stamp mode with the i'th recent pattern
+func (g *Game) pickRecent(i int) {
+	g.world.stamp = g.world.recent[i]
+	g.recent = recentMenu{}
+}
+
+// draw shows the menu in the middle of the screen
+func (m *recentMenu) draw(screen *ebiten.Image, w *World) {
+	if !m.open {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("Recent patterns\n\n")
+	for i, p := range w.recent {
+		cursor := "  "
+		if i == m.selected {
+			cursor = "> "
+		}
+		fmt.Fprintf(&b, "%s%d %s\n", cursor, i+1, p.name)
+	}
+	if len(w.recent) == 0 {
+		b.WriteString("  No patterns placed yet\n")
+	}
+	b.WriteString("\nUp/Down select, Enter or 1-9 to stamp\nEsc to close")
+
+	drawCentredBox(screen, w, b.String())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Renderer draws the world to some output. The window uses ebitenRenderer,
+// other outputs such as a terminal or an image sequence implement the same
+// methods and need nothing from the engine beyond the world they are given.
+type Renderer interface {
+	// DrawGrid draws what is behind the cells, such as grid lines
+	DrawGrid(w *World)
+	// DrawCells draws the live cells and anything shown with them
+	DrawCells(w *World)
+	// DrawHUD draws the status line
+	DrawHUD(w *World)
+}
+
+// render draws a frame with r, grid first so the cells and HUD go over it
+func (w *World) render(r Renderer) {
+	r.DrawGrid(w)
+	r.DrawCells(w)
+	r.DrawHUD(w)
+}
+
+// ebitenRenderer is the Renderer for the window, drawing into an Ebiten
+// screen image
+type ebitenRenderer struct {
+	screen *ebiten.Image
+}
+
+// grid returns the part of the screen below the top bar, so that panned
+// cells are clipped rather than drawn over the HUD
+func (r ebitenRenderer) grid(w *World) *ebiten.Image {
+	return r.screen.SubImage(image.Rect(0, w.gridTop, w.screenWidth, w.screenHeight)).(*ebiten.Image)
+}
+
+// DrawGrid draws the background image and the grid lines
+func (r ebitenRenderer) DrawGrid(w *World) {
+	grid := r.grid(w)
+	w.drawBackground(grid)
+	w.drawGridLayer(grid)
+}
+
+// DrawCells draws the trails, the live cells and the heatmap over them, or
+// the diff instead while one is shown
+func (r ebitenRenderer) DrawCells(w *World) {
+	grid := r.grid(w)
+	if w.diff != nil {
+		w.drawDiff(grid)
+		return
+	}
+	w.drawTrails(grid)
+	w.drawCellLayer(grid)
+	w.drawHeatmap(grid)
+}
+
+// DrawHUD draws the top bar
+func (r ebitenRenderer) DrawHUD(w *World) {
+	w.drawHUD(r.screen)
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// pattern is a set of live cells loaded from a pattern file
+type pattern struct {
+	name   string
+	rule   string
+	width  int
+	height int
+	cells  []tile
+	// states holds the cells of multi-state rules that are past state 1
+	states map[tile]life.State
+}
+
+// state returns the state of one of the pattern's cells
+func (p *pattern) state(cell tile) life.State {
+	if s, ok := p.states[cell]; ok {
+		return s
+	}
+	return 1
+}
+
+// add adds a live cell to the pattern in a state
+func (p *pattern) add(cell tile, s life.State) {
+	p.cells = append(p.cells, cell)
+	if s > 1 {
+		if p.states == nil {
+			p.states = make(map[tile]life.State)
+		}
+		p.states[cell] = s
+	}
+}
+
+// Multi-state RLE writes states 1 to 24 as A to X, and higher states as A to
+// X after a prefix from p to y that adds 24 for each letter past o
+const (
+	rleStateLetters = 24
+	maxRLEState     = 255
+)
+
+// cellSet returns the pattern's cells as a set
+func (p *pattern) cellSet() map[tile]struct{} {
+	cells := make(map[tile]struct{}, len(p.cells))
+	for _, cell := range p.cells {
+		cells[cell] = struct{}{}
+	}
+	return cells
+}
+
+// loadPattern reads an RLE pattern from a file, falling back to the built-in
+// pattern of that name when no such file exists
+func loadPattern(path string) (*pattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if p, builtinErr := loadBuiltinPattern(path); builtinErr == nil {
+			return p, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := parseRLE(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// parseRLE parses a pattern in the run length encoded format used by Golly
+// and the LifeWiki.
+func parseRLE(r io.Reader) (*pattern, error) {
+	p := &pattern{}
+	scanner := bufio.NewScanner(r)
+	headerSeen := false
+	x, y := 0, 0
+	count := 0
+	// prefix is the p to y before the letter of a high state
+	var prefix rune
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// Comment lines, only the name is kept
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "#N") {
+				p.name = strings.TrimSpace(line[2:])
+			}
+			continue
+		}
+		if !headerSeen {
+			if err := p.parseHeader(line); err != nil {
+				return nil, err
+			}
+			headerSeen = true
+			continue
+		}
+
+		for _, c := range line {
+			if prefix != 0 && (c < 'A' || c > 'X') {
+				return nil, fmt.Errorf("unexpected character %q after state prefix %q in pattern", c, prefix)
+			}
+			switch {
+			case c >= '0' && c <= '9':
+				count = count*10 + int(c-'0')
+				continue
+			case c >= 'p' && c <= 'y':
+				prefix = c
+				continue
+			case c == 'b' || c == '.':
+				x += max(count, 1)
+			case c == '$':
+				y += max(count, 1)
+				x = 0
+			case c == '!':
+				return p, nil
+			case c == 'o' || (c >= 'A' && c <= 'X'):
+				state := 1
+				if c != 'o' {
+					state = int(c-'A') + 1
+				}
+				if prefix != 0 {
+					state += int(prefix-'p'+1) * rleStateLetters
+					prefix = 0
+				}
+				if state > maxRLEState {
+					return nil, fmt.Errorf("state %d in pattern is over %d", state, maxRLEState)
+				}
+				for i := 0; i < max(count, 1); i++ {
+					p.add(tile{X: x, Y: y}, life.State(state))
+					x++
+				}
+			case c == ' ' || c == '\t':
+				continue
+			default:
+				return nil, fmt.Errorf("unexpected character %q in pattern", c)
+			}
+			count = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !headerSeen {
+		return nil, fmt.Errorf("missing RLE header line")
+	}
+	return p, nil
+}
+
+// parseHeader reads the "x = 3, y = 3, rule = B3/S23" line of an RLE file
+func (p *pattern) parseHeader(line string) error {
+	for _, field := range strings.Split(line, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return fmt.Errorf("invalid RLE header %q", line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "x", "y":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid RLE header %q", line)
+			}
+			if key == "x" {
+				p.width = n
+			} else {
+				p.height = n
+			}
+		case "rule":
+			p.rule = value
+		}
+	}
+	return nil
+}
+
+// writeRLE encodes cells in the RLE format, translated so the top left of
+// their bounding box is at the origin. state gives the states of the cells
+// of a multi-state rule, which are written as letters, and is nil for rules
+// with only live and dead cells.
+func writeRLE(w io.Writer, name, rule string, cells map[tile]struct{}, state func(tile) life.State) error {
+	bw := bufio.NewWriter(w)
+	if name != "" {
+		fmt.Fprintf(bw, "#N %s\n", name)
+	}
+
+	if len(cells) == 0 {
+		fmt.Fprintf(bw, "x = 0, y = 0, rule = %s\n!\n", rule)
+		return bw.Flush()
+	}
+	minX, minY, maxX, maxY := life.Bounds(cells)
+	fmt.Fprintf(bw, "x = %d, y = %d, rule = %s\n", maxX-minX+1, maxY-minY+1, rule)
+
+	// Collect the runs, trailing dead cells of a row are never written and
+	// consecutive row ends are merged into one run
+	type run struct {
+		count int
+		tag   string
+	}
+	var runs []run
+	add := func(count int, tag string) {
+		if n := len(runs); n > 0 && runs[n-1].tag == tag {
+			runs[n-1].count += count
+			return
+		}
+		runs = append(runs, run{count, tag})
+	}
+	alive, dead := "o", "b"
+	if state != nil {
+		dead = "."
+	}
+	for y := minY; y <= maxY; y++ {
+		if y > minY {
+			add(1, "$")
+		}
+		gap := 0
+		for x := minX; x <= maxX; x++ {
+			cell := tile{X: x, Y: y}
+			if _, isAlive := cells[cell]; !isAlive {
+				gap++
+				continue
+			}
+			if gap > 0 {
+				add(gap, dead)
+				gap = 0
+			}
+			if state != nil {
+				alive = rleState(state(cell))
+			}
+			add(1, alive)
+		}
+	}
+	add(1, "!")
+
+	// Keep lines under 70 characters as the format recommends
+	lineLen := 0
+	for _, rn := range runs {
+		token := rn.tag
+		if rn.count > 1 {
+			token = strconv.Itoa(rn.count) + token
+		}
+		if lineLen+len(token) > 70 {
+			bw.WriteString("\n")
+			lineLen = 0
+		}
+		bw.WriteString(token)
+		lineLen += len(token)
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// rleState returns the letters a live state is written as in multi-state
+// RLE, such as "A" for state 1 and "pA" for state 25
+func rleState(s life.State) string {
+	n := int(max(s, 1)) - 1
+	letter := string(rune('A' + n%rleStateLetters))
+	if n < rleStateLetters {
+		return letter
+	}
+	return string(rune('p'+n/rleStateLetters-1)) + letter
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+func TestRLEStates(t *testing.T) {
+	const src = "x = 5, y = 2, rule = brians-brain\nA.B$2.pAyC!\n"
+	p, err := parseRLE(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[tile]life.State{{X: 0, Y: 0}: 1, {X: 2, Y: 0}: 2, {X: 2, Y: 1}: 25, {X: 3, Y: 1}: 243}
+	if len(p.cells) != len(want) {
+		t.Fatalf("got %d cells, want %d", len(p.cells), len(want))
+	}
+	for cell, s := range want {
+		if got := p.state(cell); got != s {
+			t.Errorf("cell %v is in state %d, want %d", cell, got, s)
+		}
+	}
+
+	// Turning the pattern turns its states with it
+	r := p.rotate()
+	if got := r.state(tile{X: 0, Y: 3}); got != 243 {
+		t.Errorf("turned cell is in state %d, want 243", got)
+	}
+
+	// Writing the states and reading them back gives the same pattern
+	var b bytes.Buffer
+	if err := writeRLE(&b, "", p.rule, p.cellSet(), p.state); err != nil {
+		t.Fatal(err)
+	}
+	back, err := parseRLE(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cell, s := range want {
+		if got := back.state(cell); got != s {
+			t.Errorf("after writing, cell %v is in state %d, want %d", cell, got, s)
+		}
+	}
+
+	for _, bad := range []string{"pb!", "p!", "yX!"} {
+		if _, err := parseRLE(strings.NewReader("x = 1, y = 1\n" + bad)); err == nil {
+			t.Errorf("%q parsed without an error", bad)
+		}
+	}
+}
+
+func TestRLETwoStates(t *testing.T) {
+	cells := map[tile]struct{}{{X: 0, Y: 0}: {}, {X: 2, Y: 0}: {}, {X: 1, Y: 1}: {}}
+	var b bytes.Buffer
+	if err := writeRLE(&b, "", life.Conway, cells, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "x = 3, y = 2, rule = B3/S23\nobo$bo!\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import "github.com/afroash/gameoflife/life"
+
+// Rules beyond B/S notation are registered with the engine here, and are
+// chosen by name with -rule, the rule command or the settings menu
+func init() {
+	life.RegisterRule("brians-brain", briansBrain{})
+	life.RegisterRule(immigrationRule, immigration{})
+}
+
+// briansBrain is Brian's Brain: a dead cell fires when exactly two of its
+// neighbors are firing, a firing cell starts dying and a dying cell dies
+type briansBrain struct{}
+
+// Brian's Brain states
+const (
+	brainFiring life.State = 1
+	brainDying  life.State = 2
+)
+
+func (briansBrain) States() int { return 3 }
+
+func (briansBrain) Next(state life.State, neighbors [8]life.State) life.State {
+	switch state {
+	case brainFiring:
+		return brainDying
+	case brainDying:
+		return 0
+	}
+	firing := 0
+	for _, n := range neighbors {
+		if n == brainFiring {
+			firing++
+		}
+	}
+	if firing == 2 {
+		return brainFiring
+	}
+	return 0
+}
+
+// immigrationRule is the name Immigration is registered under
+const immigrationRule = "immigration"
+
+// immigration is Conway's rule with live cells in one of two colors,
+// states 1 and 2. Survivors keep their color and a cell is born in the
+// color of most of its three live neighbors.
+type immigration struct{}
+
+func (immigration) States() int { return 3 }
+
+func (immigration) Next(state life.State, neighbors [8]life.State) life.State {
+	var count [3]int
+	for _, n := range neighbors {
+		count[n]++
+	}
+	switch live := count[1] + count[2]; {
+	case state != 0 && (live == 2 || live == 3):
+		return state
+	case state == 0 && live == 3:
+		if count[2] > count[1] {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/afroash/gameoflife/life"
+	"github.com/afroash/gameoflife/lua"
+)
+
+// Scripts run on the game loop, so these keep one from holding up the
+// window for long
+const (
+	// scriptSteps is how many calls and turns of loops a script can run
+	scriptSteps = 50_000_000
+	// scriptTimeout is how long a script can run
+	scriptTimeout = 10 * time.Second
+	// scriptMaxRun is the most generations one call of run advances, as
+	// for a Step call over gRPC
+	scriptMaxRun = grpcMaxSteps
+	// scriptMaxFill is the largest area randfill fills, the cells of the
+	// largest grid the menu makes
+	scriptMaxFill = maxGridSize * maxGridSize
+)
+
+// runScript runs a Lua script against the world, printing to out. The cells
+// it sets between steps are one edit, so undo takes back a construction
+// but not the generations it ran.
+func (w *World) runScript(path string, out io.Writer) error {
+	s := lua.New(out)
+	s.SetLimit(scriptSteps)
+	deadline := time.Now().Add(scriptTimeout)
+	s.SetInterrupt(func() error {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("script ran for more than %v", scriptTimeout)
+		}
+		return nil
+	})
+	w.registerScriptFunctions(s)
+	w.beginEdit()
+	defer w.commitEdit()
+	return s.DoFile(path)
+}
+
+// registerScriptFunctions gives a script the functions that read and change
+// the world, named after Golly's
+func (w *World) registerScriptFunctions(s *lua.State) {
+	// cellArg reads the cell given as arguments i and i+1, wrapped onto a
+	// torus
+	cellArg := func(fn string, args []lua.Value, i int) (tile, error) {
+		x, err := lua.CheckInt(fn, args, i)
+		if err != nil {
+			return tile{}, err
+		}
+		y, err := lua.CheckInt(fn, args, i+1)
+		if err != nil {
+			return tile{}, err
+		}
+		return w.grid.Wrap(tile{X: x, Y: y}), nil
+	}
+	// step runs generations outside the pending edit, which the cells set
+	// after them start again
+	step := func(n int) {
+		w.commitEdit()
+		for range n {
+			w.SimulateWorld()
+		}
+		w.beginEdit()
+	}
+
+	// setcell(x, y[, state]) makes a cell alive, in state 1 unless another
+	// is given, or dead with state 0
+	s.Register("setcell", func(args []lua.Value) ([]lua.Value, error) {
+		cell, err := cellArg("setcell", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		state, err := lua.OptInt("setcell", args, 2, 1)
+		if err != nil {
+			return nil, err
+		}
+		if state < 0 || state >= w.grid.States() {
+			return nil, lua.Errorf("bad argument #3 to 'setcell' (state must be from 0 to %d)", w.grid.States()-1)
+		}
+		w.setCell(cell, state != 0)
+		if state > 1 {
+			w.grid.SetState(cell, life.State(state))
+		}
+		return nil, nil
+	})
+	// getcell(x, y) returns the state of a cell, 0 when it is dead
+	s.Register("getcell", func(args []lua.Value) ([]lua.Value, error) {
+		cell, err := cellArg("getcell", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []lua.Value{float64(w.grid.State(cell))}, nil
+	})
+	// step() advances a generation
+	s.Register("step", func(args []lua.Value) ([]lua.Value, error) {
+		step(1)
+		return nil, nil
+	})
+	// run(n) advances n generations, up to scriptMaxRun
+	s.Register("run", func(args []lua.Value) ([]lua.Value, error) {
+		n, err := lua.CheckInt("run", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n > scriptMaxRun {
+			return nil, lua.Errorf("bad argument #1 to 'run' (generations must be from 0 to %d)", scriptMaxRun)
+		}
+		step(n)
+		return nil, nil
+	})
+	// randfill(percent[, x, y, width, height]) makes each cell of the grid, or
+	// of the rectangle, alive with a chance of percent and dead otherwise
+	s.Register("randfill", func(args []lua.Value) ([]lua.Value, error) {
+		percent, err := lua.CheckInt("randfill", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if percent < 0 || percent > 100 {
+			return nil, lua.Errorf("bad argument #1 to 'randfill' (percent must be from 0 to 100)")
+		}
+		x0, y0, width, height := 0, 0, w.grid.Width, w.grid.Height
+		if len(args) > 1 {
+			area := make([]int, 4)
+			for i := range area {
+				if area[i], err = lua.CheckInt("randfill", args, i+1); err != nil {
+					return nil, err
+				}
+			}
+			x0, y0, width, height = area[0], area[1], area[2], area[3]
+			if width < 0 || height < 0 {
+				return nil, lua.Errorf("bad argument #4 to 'randfill' (width and height must not be negative)")
+			}
+			if width > scriptMaxFill || height > scriptMaxFill || width*height > scriptMaxFill {
+				return nil, lua.Errorf("bad argument #4 to 'randfill' (area must be at most %d cells)", scriptMaxFill)
+			}
+		}
+		for y := y0; y < y0+height; y++ {
+			for x := x0; x < x0+width; x++ {
+				w.setCell(w.grid.Wrap(tile{X: x, Y: y}), w.random.Intn(100) < percent)
+			}
+		}
+		return nil, nil
+	})
+	// getgen() and getpop() return the generation and the population
+	s.Register("getgen", func(args []lua.Value) ([]lua.Value, error) {
+		return []lua.Value{float64(w.grid.Generation)}, nil
+	})
+	s.Register("getpop", func(args []lua.Value) ([]lua.Value, error) {
+		return []lua.Value{float64(w.grid.Population())}, nil
+	})
+	// setrule(rule) changes the rule, as the rule command does
+	s.Register("setrule", func(args []lua.Value) ([]lua.Value, error) {
+		rule, err := lua.CheckString("setrule", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := w.grid.SetRule(rule); err != nil {
+			return nil, lua.Errorf("%v", err)
+		}
+		return nil, nil
+	})
+}
+
+// scriptMessage is what the script command shows when a script has run,
+// the last line it printed
+func scriptMessage(path string, out *bytes.Buffer) string {
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if last := lines[len(lines)-1]; last != "" {
+		return last
+	}
+	return fmt.Sprintf("ran %s", path)
+}
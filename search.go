@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// searchLimit is the most generations a soup is run for before it is
+// censused anyway, its unfinished objects are counted as unsettled
+const searchLimit = 20000
+
+// searchWindow is how many generations the population must have been
+// repeating for a soup to count as settled. Gliders leaving a soup keep the
+// cells from ever repeating but not the population.
+const searchWindow = 2 * maxPeriod
+
+// runSearch runs soups from the seeds first to first+n-1 until each has
+// settled, censuses the ash of each and writes how often every object
+// turned up to path, or to standard output when path is empty. The soups
+// use the world's size, rule, density and symmetry. With ashPath set, the
+// objects of every soup and where they are are written there too.
+func (w *World) runSearch(n int, first int64, path, ashPath string) error {
+	var ash *bufio.Writer
+	var ashFile *os.File
+	if ashPath != "" {
+		f, err := os.Create(ashPath)
+		if err != nil {
+			return err
+		}
+		// Closed again below to report its error, this covers the early
+		// returns
+		defer f.Close()
+		ashFile, ash = f, bufio.NewWriter(f)
+	} else {
+		ash = bufio.NewWriter(io.Discard)
+	}
+
+	totals := make(map[string]int)
+	unsettled := 0
+	for i := range n {
+		seed := first + int64(i)
+		w.random = rand.New(rand.NewSource(seed))
+		w.generateRandomCells()
+		lifespan, settled := runUntilSettled(w.grid)
+		if !settled {
+			unsettled++
+		}
+		c, err := takeCensus(w.grid)
+		if err != nil {
+			return err
+		}
+		if settled {
+			fmt.Fprintf(ash, "# soup %d settled at generation %d, %d objects\n", seed, lifespan, len(c.objects))
+		} else {
+			fmt.Fprintf(ash, "# soup %d not settled after %d generations, %d objects\n", seed, searchLimit, len(c.objects))
+		}
+		if err := c.writeAsh(ash); err != nil {
+			return err
+		}
+		for _, count := range c.counts() {
+			totals[count.name] += count.count
+		}
+		if (i+1)%1000 == 0 {
+			log.Printf("search: %d of %d soups", i+1, n)
+		}
+	}
+	if err := ash.Flush(); err != nil {
+		return err
+	}
+	if ashFile != nil {
+		if err := ashFile.Close(); err != nil {
+			return err
+		}
+	}
+
+	if path == "" {
+		return w.writeSearch(os.Stdout, n, first, unsettled, totals)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := w.writeSearch(f, n, first, unsettled, totals); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runUntilSettled steps the grid until its population has been repeating
+// for searchWindow generations, or gives up after searchLimit. The lifespan
+// is the generation the repeating started at.
+func runUntilSettled(g *life.Grid) (lifespan int, settled bool) {
+	start := g.Generation
+	populations := make([]int, 0, searchLimit)
+	for range searchLimit {
+		g.Step()
+		populations = append(populations, g.Population())
+		// Checking now and then is enough, a settled soup stays settled
+		if len(populations)%maxPeriod != 0 {
+			continue
+		}
+		if p := populationPeriod(populations); p > 0 {
+			i := len(populations) - 1 - p
+			for i > 0 && populations[i-1] == populations[i-1+p] {
+				i--
+			}
+			return start + i + 1, true
+		}
+	}
+	return g.Generation, false
+}
+
+// populationPeriod returns the shortest period of at most maxPeriod that
+// the last searchWindow populations repeat with, or 0 when they don't
+func populationPeriod(populations []int) int {
+	n := len(populations)
+	if n < searchWindow+maxPeriod {
+		return 0
+	}
+	for p := 1; p <= maxPeriod; p++ {
+		repeats := true
+		for i := n - searchWindow; i < n && repeats; i++ {
+			repeats = populations[i] == populations[i-p]
+		}
+		if repeats {
+			return p
+		}
+	}
+	return 0
+}
+
+// writeSearch writes the object counts of a search, most common first,
+// after comment lines describing the soups
+func (w *World) writeSearch(out io.Writer, n int, first int64, unsettled int, totals map[string]int) error {
+	counts := make([]censusCount, 0, len(totals))
+	objects := 0
+	for name, count := range totals {
+		counts = append(counts, censusCount{name, count})
+		objects += count
+	}
+	sortCounts(counts)
+
+	fmt.Fprintf(out, "# %d soups from seed %d to %d, rule %s, %dx%d at %d%%, symmetry %s\n",
+		n, first, first+int64(n)-1, w.grid.RuleName(), w.grid.Width, w.grid.Height, w.density, w.soupSymmetry)
+	fmt.Fprintf(out, "# %d objects, %d soups not settled after %d generations\n", objects, unsettled, searchLimit)
+	for _, count := range counts {
+		if _, err := fmt.Fprintf(out, "%s\t%d\n", count.name, count.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// selection is a rectangular region of cells picked by dragging in
+// selection mode
+type selection struct {
+	active   bool
+	dragging bool
+	start    tile
+	end      tile
+
+	// Contents lifted off the grid while the selection is dragged, and the
+	// cursor position relative to the top left corner
+	moving  *pattern
+	grabbed tile
+}
+
+// bounds returns the inclusive corners of the selected rectangle
+func (s selection) bounds() (minX, minY, maxX, maxY int) {
+	return min(s.start.X, s.end.X), min(s.start.Y, s.end.Y),
+		max(s.start.X, s.end.X), max(s.start.Y, s.end.Y)
+}
+
+// contains reports whether a cell is inside the selection
+func (s selection) contains(cell tile) bool {
+	minX, minY, maxX, maxY := s.bounds()
+	return s.active && cell.X >= minX && cell.X <= maxX && cell.Y >= minY && cell.Y <= maxY
+}
+
+// handleSelection lets a left drag define the selection or move its
+// contents
+func (w *World) handleSelection(x, y int) {
+	if w.selection.moving != nil {
+		w.moveSelection(x, y)
+		return
+	}
+
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		w.selection.dragging = false
+	} else if cell, ok := w.screenToCell(x, y); ok {
+		if !w.selection.dragging && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && w.selection.contains(cell) {
+			// Pressing inside the selection picks up its contents
+			minX, minY, _, _ := w.selection.bounds()
+			w.selection.moving = w.copySelection()
+			w.selection.grabbed = tile{X: cell.X - minX, Y: cell.Y - minY}
+			// The move is one edit from pick up to drop
+			w.beginEdit()
+			w.fillSelection(false)
+			return
+		}
+		if !w.selection.dragging {
+			// A new drag starts a new selection
+			w.selection = selection{active: true, dragging: true, start: cell}
+		}
+		w.selection.end = cell
+	}
+}
+
+// toggleSelecting switches selection mode on or off, dropping anything
+// being moved
+func (w *World) toggleSelecting() {
+	w.dropSelection()
+	w.selecting = !w.selecting
+	w.selection = selection{}
+	w.stroke.active = false
+}
+
+// editSelection fills or clears the selection as one undoable edit
+func (w *World) editSelection(alive bool) {
+	w.beginEdit()
+	w.fillSelection(alive)
+	w.commitEdit()
+}
+
+// moveSelection drags the lifted contents with the cursor and drops them
+// when the button is released
+func (w *World) moveSelection(x, y int) {
+	if cell, ok := w.screenToCell(x, y); ok {
+		p := w.selection.moving
+		w.selection.start = tile{X: cell.X - w.selection.grabbed.X, Y: cell.Y - w.selection.grabbed.Y}
+		w.selection.end = tile{X: w.selection.start.X + p.width - 1, Y: w.selection.start.Y + p.height - 1}
+	}
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		w.dropSelection()
+	}
+}
+
+// dropSelection puts contents being moved back on the grid
+func (w *World) dropSelection() {
+	if w.selection.moving == nil {
+		return
+	}
+	for _, cell := range w.selection.moving.cells {
+		w.setCell(tile{X: w.selection.start.X + cell.X, Y: w.selection.start.Y + cell.Y}, true)
+	}
+	w.selection.moving = nil
+	w.commitEdit()
+}
+
+// fillSelection makes every cell in the selection alive or dead
+func (w *World) fillSelection(alive bool) {
+	minX, minY, maxX, maxY := w.selection.bounds()
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			w.setCell(tile{X: x, Y: y}, alive)
+		}
+	}
+}
+
+// drawSelection highlights the selected rectangle
+func (w *World) drawSelection(screen *ebiten.Image) {
+	if !w.selecting || !w.selection.active {
+		return
+	}
+	minX, minY, maxX, maxY := w.selection.bounds()
+	sx, sy := w.cellToScreen(minX, minY)
+	width := float32((maxX - minX + 1) * w.tileSize)
+	height := float32((maxY - minY + 1) * w.tileSize)
+	vector.DrawFilledRect(screen, sx, sy, width, height, fade(w.theme.Selection, 60), w.antialias)
+	vector.StrokeRect(screen, sx, sy, width, height, 2, w.theme.Selection, w.antialias)
+
+	// Contents being moved follow the selection
+	if w.selection.moving != nil {
+		for _, cell := range w.selection.moving.cells {
+			w.fillCell(screen, minX+cell.X, minY+cell.Y, w.ghostColor())
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"log"
+	"net"
+	"os"
+)
+
+// playerColors are the colors cells placed by each player are drawn in
+var playerColors = []color.RGBA{
+	{255, 90, 90, 255},
+	{90, 200, 90, 255},
+	{90, 150, 255, 255},
+	{255, 190, 40, 255},
+	{200, 100, 255, 255},
+	{40, 220, 220, 255},
+	{255, 120, 200, 255},
+	{170, 230, 60, 255},
+}
+
+// playerColor returns the color of a player's cells, picked from their name
+// so every player sees the same colors
+func playerColor(name string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return playerColors[h.Sum32()%uint32(len(playerColors))]
+}
+
+// defaultPlayerName is the login name, which is who placed cells unless
+// -name is given
+func defaultPlayerName() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "player"
+}
+
+// sessionMessage is one line of JSON sent between the host of a shared world
+// and the players who joined it. The host sends the changes to the cells,
+// players send their edits the same way and the host applies them.
+type sessionMessage struct {
+	Generation int `json:"generation,omitempty"`
+	// Reset is set on the first message to a player, whose born cells are
+	// every live cell
+	Reset bool     `json:"reset,omitempty"`
+	Born  [][2]int `json:"born,omitempty"`
+	Died  [][2]int `json:"died,omitempty"`
+	// Owners lists the live cells that have been placed by hand since the
+	// last message, by the player who placed them
+	Owners map[string][][2]int `json:"owners,omitempty"`
+	// Running is sent when the simulation is started or paused
+	Running *bool `json:"running,omitempty"`
+	// Player is the name a player joins under, sent first, and the name
+	// the host settled on, in the reset message it answers with. Their
+	// edits are placed under it.
+	Player string `json:"player,omitempty"`
+}
+
+// Limits on an edit from a player, so a player can't make the host allocate
+// without bound. Clearing or filling a large world is still well inside
+// them.
+const (
+	maxEditLine  = 8 << 20
+	maxEditCells = 1 << 18
+)
+
+// cellCount returns how many cells a message lists
+func (m sessionMessage) cellCount() int {
+	n := len(m.Born) + len(m.Died)
+	for _, cells := range m.Owners {
+		n += len(cells)
+	}
+	return n
+}
+
+// session is a world shared over the network. The host runs the simulation
+// and the players mirror it, any of them can edit the cells. Connections
+// run on their own goroutines and hand their work to the game loop, like
+// the HTTP API.
+type session struct {
+	host   bool
+	addr   string
+	events chan func(w *World)
+
+	// peers are the players connected to the host by name, hostPeer the
+	// connection from a player to the host
+	peers    map[*streamClient]string
+	hostPeer *streamClient
+
+	// last, owners, generation and running are the world as every peer
+	// last saw it
+	last       map[tile]struct{}
+	owners     map[tile]string
+	generation int
+	running    bool
+}
+
+// hostSession shares the world with the players who join on addr
+func (w *World) hostSession(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s := &session{
+		host:   true,
+		addr:   addr,
+		events: make(chan func(w *World), streamBuffer),
+		peers:  make(map[*streamClient]string),
+		last:   copyCells(w.grid.Cells()),
+		owners: make(map[tile]string),
+	}
+	w.session = s
+	w.owners = make(map[tile]string)
+	go func() {
+		log.Printf("hosting a shared world on %s", addr)
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				log.Printf("session: %v", err)
+				return
+			}
+			go s.servePlayer(conn)
+		}
+	}()
+	return nil
+}
+
+// joinSession replaces the world with the one hosted on addr
+func (w *World) joinSession(addr string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s := &session{
+		addr:     addr,
+		events:   make(chan func(w *World), streamBuffer),
+		hostPeer: &streamClient{send: make(chan []byte, streamBuffer), gone: make(chan struct{})},
+		// The host's world replaces this one, nothing here is an edit
+		last: copyCells(w.grid.Cells()),
+	}
+	w.session = s
+	w.owners = make(map[tile]string)
+	w.isSimulating = false
+	s.send(s.hostPeer, sessionMessage{Player: w.player})
+	go writePeer(conn, s.hostPeer)
+	go func() {
+		readPeer(conn, s.events, func(w *World, msg sessionMessage) { s.applyChanges(w, msg) })
+		s.events <- func(w *World) {
+			// Carry on alone with the world as it was
+			log.Printf("lost the connection to %s", s.addr)
+			s.hostPeer.drop()
+			w.session = nil
+		}
+	}()
+	return nil
+}
+
+// servePlayer sends a player the world and applies their edits until they
+// leave
+func (s *session) servePlayer(conn net.Conn) {
+	p := &streamClient{send: make(chan []byte, streamBuffer), gone: make(chan struct{})}
+	go writePeer(conn, p)
+	// The first message names the player, and their edits after it are
+	// placed under that name whatever they say. joined is only used on the
+	// game loop.
+	joined := false
+	readEdits(conn, s.events, func(w *World, msg sessionMessage) {
+		if !joined {
+			joined = true
+			s.addPeer(p, msg.Player, w)
+			return
+		}
+		if name, ok := s.peers[p]; ok {
+			s.applyEdit(w, name, msg)
+		}
+	})
+	s.events <- func(w *World) {
+		delete(s.peers, p)
+		p.drop()
+	}
+}
+
+// addPeer sends a new player the whole world, after bringing the others up
+// to date so they all share last. The player is known by name, numbered if
+// the host or another player has it.
+func (s *session) addPeer(p *streamClient, name string, w *World) {
+	if len(s.peers) == 0 {
+		// No one has been sent the changes, so only catch last up
+		s.changes(w)
+	} else {
+		s.broadcast(w)
+	}
+	name = s.uniqueName(name, w)
+	s.peers[p] = name
+	owners := make(map[string][][2]int)
+	for _, cell := range sortedCells(s.last) {
+		if owner, ok := s.owners[cell]; ok {
+			owners[owner] = append(owners[owner], [2]int{cell.X, cell.Y})
+		}
+	}
+	running := s.running
+	s.send(p, sessionMessage{
+		Generation: w.grid.Generation,
+		Reset:      true,
+		Born:       cellPairs(sortedCells(s.last)),
+		Owners:     owners,
+		Running:    &running,
+		Player:     name,
+	})
+}
+
+// uniqueName returns name, or name and a number if the host or a player
+// already has it
+func (s *session) uniqueName(name string, w *World) string {
+	if name == "" {
+		name = "player"
+	}
+	taken := func(n string) bool {
+		if n == w.player {
+			return true
+		}
+		for _, other := range s.peers {
+			if other == n {
+				return true
+			}
+		}
+		return false
+	}
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+	return unique
+}
+
+// update runs the work sent by the connections since the last frame, then
+// sends the changes made in this world to the peers
+func (s *session) update(w *World) {
+	if s == nil {
+		return
+	}
+	if !s.host {
+		// Send this player's edits before the host's changes are applied
+		s.sendEdits(w)
+	}
+	for {
+		select {
+		case f := <-s.events:
+			f(w)
+		default:
+			if s.host {
+				s.broadcast(w)
+			}
+			return
+		}
+	}
+}
+
+// simulates reports whether this world runs the simulation itself, players
+// who joined a shared world wait for the host to send each generation
+func (s *session) simulates() bool {
+	return s == nil || s.host
+}
+
+// changes returns what has happened to the cells and who placed them since
+// the peers last saw the world, and makes that the new last world
+func (s *session) changes(w *World) (msg sessionMessage, changed bool) {
+	msg = sessionMessage{Generation: w.grid.Generation}
+	cells := w.grid.Cells()
+	for _, cell := range sortedCells(cells) {
+		if _, ok := s.last[cell]; !ok {
+			msg.Born = append(msg.Born, [2]int{cell.X, cell.Y})
+		}
+		if owner, ok := w.owners[cell]; ok && s.host && s.owners[cell] != owner {
+			if msg.Owners == nil {
+				msg.Owners = make(map[string][][2]int)
+			}
+			msg.Owners[owner] = append(msg.Owners[owner], [2]int{cell.X, cell.Y})
+		}
+	}
+	for _, cell := range sortedCells(s.last) {
+		if _, ok := cells[cell]; !ok {
+			msg.Died = append(msg.Died, [2]int{cell.X, cell.Y})
+		}
+	}
+	if w.isSimulating != s.running {
+		running := w.isSimulating
+		msg.Running = &running
+	}
+	// Players only hear about the generation from the host, even a step
+	// that changes nothing moves it on
+	changed = len(msg.Born) > 0 || len(msg.Died) > 0 || msg.Owners != nil || msg.Running != nil ||
+		s.host && w.grid.Generation != s.generation
+	if changed {
+		s.last = copyCells(cells)
+		s.generation = w.grid.Generation
+		s.running = w.isSimulating
+		if s.host {
+			s.owners = make(map[tile]string, len(w.owners))
+			for cell, owner := range w.owners {
+				s.owners[cell] = owner
+			}
+		}
+	}
+	return msg, changed
+}
+
+// broadcast sends the host's changes to every player
+func (s *session) broadcast(w *World) {
+	// Working out the changes looks at every live cell, which no one needs
+	// until someone joins
+	if len(s.peers) == 0 {
+		return
+	}
+	msg, changed := s.changes(w)
+	if !changed {
+		return
+	}
+	for p := range s.peers {
+		s.send(p, msg)
+	}
+}
+
+// sendEdits sends a player's edits, and starting or pausing, to the host
+func (s *session) sendEdits(w *World) {
+	msg, changed := s.changes(w)
+	if !changed {
+		return
+	}
+	msg.Generation = 0
+	s.send(s.hostPeer, msg)
+}
+
+// applyEdit makes a player's edit on the host, placing the cells under
+// their name
+func (s *session) applyEdit(w *World, name string, msg sessionMessage) {
+	// Other players' edits aren't undone by this player's undo
+	pending, player := w.history.pending, w.player
+	w.history.pending, w.player = nil, name
+	for _, c := range msg.Born {
+		w.setCell(tile{X: c[0], Y: c[1]}, true)
+	}
+	for _, c := range msg.Died {
+		w.setCell(tile{X: c[0], Y: c[1]}, false)
+	}
+	w.history.pending, w.player = pending, player
+	if msg.Running != nil {
+		w.setRunning(*msg.Running)
+	}
+}
+
+// applyChanges mirrors the host's changes on a player's world
+func (s *session) applyChanges(w *World, msg sessionMessage) {
+	if msg.Reset {
+		w.setCells(make(map[tile]struct{}))
+		s.last = make(map[tile]struct{})
+		if msg.Player != "" {
+			w.player = msg.Player
+		}
+	}
+	previous := copyCells(w.grid.Cells())
+	for _, c := range msg.Born {
+		cell := tile{X: c[0], Y: c[1]}
+		w.grid.Set(cell, true)
+		s.last[cell] = struct{}{}
+	}
+	for _, c := range msg.Died {
+		cell := tile{X: c[0], Y: c[1]}
+		w.grid.Set(cell, false)
+		delete(w.ages, cell)
+		delete(w.owners, cell)
+		delete(s.last, cell)
+	}
+	for owner, cells := range msg.Owners {
+		for _, c := range cells {
+			w.owners[tile{X: c[0], Y: c[1]}] = owner
+		}
+	}
+	if msg.Running != nil {
+		w.isSimulating = *msg.Running
+		s.running = *msg.Running
+	}
+
+	// A new generation ages the cells and moves the statistics on as if
+	// this world had stepped itself
+	stepped := !msg.Reset && msg.Generation > w.grid.Generation
+	w.grid.Generation = msg.Generation
+	if stepped {
+		w.recordStep(previous)
+	}
+}
+
+// send queues a message for a peer without waiting, dropping the peer if it
+// has fallen too far behind
+func (s *session) send(p *streamClient, msg sessionMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("session: %v", err)
+		return
+	}
+	select {
+	case p.send <- append(data, '\n'):
+	default:
+		delete(s.peers, p)
+		p.drop()
+	}
+}
+
+// status describes the session for the HUD
+func (s *session) status() string {
+	if s.host {
+		return fmt.Sprintf("Hosting on %s: %d joined", s.addr, len(s.peers))
+	}
+	return fmt.Sprintf("Joined %s", s.addr)
+}
+
+// writePeer sends a peer its queued messages until it is dropped or the
+// connection fails
+func writePeer(conn net.Conn, p *streamClient) {
+	defer conn.Close()
+	for {
+		select {
+		case data := <-p.send:
+			if _, err := conn.Write(data); err != nil {
+				p.drop()
+				return
+			}
+		case <-p.gone:
+			return
+		}
+	}
+}
+
+// readPeer hands each message read from a peer to the game loop until the
+// connection ends
+func readPeer(conn net.Conn, events chan<- func(w *World), apply func(w *World, msg sessionMessage)) {
+	dec := json.NewDecoder(conn)
+	for {
+		var msg sessionMessage
+		if err := dec.Decode(&msg); err != nil {
+			return
+		}
+		events <- func(w *World) { apply(w, msg) }
+	}
+}
+
+// readEdits hands each edit read from a player to the game loop like
+// readPeer, but ends the connection when a line or the cells in it go over
+// the limits
+func readEdits(conn net.Conn, events chan<- func(w *World), apply func(w *World, msg sessionMessage)) {
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(nil, maxEditLine)
+	for sc.Scan() {
+		var msg sessionMessage
+		if err := json.Unmarshal(sc.Bytes(), &msg); err != nil {
+			return
+		}
+		if n := msg.cellCount(); n > maxEditCells {
+			log.Printf("session: dropping %s, an edit of %d cells is over %d", conn.RemoteAddr(), n, maxEditCells)
+			return
+		}
+		events <- func(w *World) { apply(w, msg) }
+	}
+	if err := sc.Err(); err != nil {
+		log.Printf("session: dropping %s: %v", conn.RemoteAddr(), err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// settings are the choices saved to the config file between runs
+type settings struct {
+	Rule      string `json:"rule,omitempty"`
+	Speed     string `json:"speed,omitempty"`
+	Boundary  string `json:"boundary,omitempty"`
+	Theme     string `json:"theme,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Density   int    `json:"density,omitempty"`
+	Soup      string `json:"soupSymmetry,omitempty"`
+	Antialias bool   `json:"antialias,omitempty"`
+	// StillAfter is how many generations a cell goes unchanged before F8
+	// mutes it
+	StillAfter int `json:"stillAfter,omitempty"`
+	// KeepRunning turns off pausing when the world dies out or settles
+	KeepRunning bool   `json:"keepRunning,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+
+	// StateColors colors the states of multi-state rules in every theme,
+	// state 1 first, such as ["#ffffff", "#3080ff"] for the firing and
+	// dying cells of Brian's Brain
+	StateColors []string `json:"stateColors,omitempty"`
+
+	// Keys maps action names to the keys that trigger them, such as
+	// "quit": ["Ctrl+Q"]. Actions that aren't listed keep their defaults.
+	Keys map[string][]string `json:"keys,omitempty"`
+}
+
+// defaultConfigPath returns the config file in the user's config directory,
+// or an empty string when there is none
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gameoflife", "config.json")
+}
+
+// loadSettings reads the config file, a missing file gives empty settings
+func loadSettings(path string) (settings, error) {
+	var s settings
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// save writes the settings to the config file, creating its directory
+func (s settings) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// settings returns the world's current choices in their saved form
+func (w *World) settings() settings {
+	// Versus games and puzzles only borrow their rule, the one to keep is
+	// the one from before them
+	rule := w.grid.RuleName()
+	switch {
+	case w.versus != nil:
+		rule = w.versus.rule
+	case w.puzzle != nil:
+		rule = w.puzzle.rule
+	}
+	return settings{
+		Rule:        rule,
+		Speed:       w.speed.String(),
+		Boundary:    w.grid.Boundary.String(),
+		Theme:       w.theme.Name,
+		Width:       w.grid.Width,
+		Height:      w.grid.Height,
+		Density:     w.density,
+		Soup:        w.soupSymmetry.String(),
+		Antialias:   w.antialias,
+		StillAfter:  w.still.after,
+		KeepRunning: !w.autoStop,
+	}
+}
+
+// applySettings fills in the options that weren't given on the command line
+// from the config file
+func (cfg *config) applySettings(s settings, set map[string]bool) error {
+	if !set["width"] && s.Width > 0 {
+		cfg.width = s.Width
+	}
+	if !set["height"] && s.Height > 0 {
+		cfg.height = s.Height
+	}
+	if !set["density"] && s.Density > 0 {
+		cfg.density = s.Density
+	}
+	if !set["still-after"] && s.StillAfter > 0 {
+		cfg.stillAfter = s.StillAfter
+	}
+	if !set["soup-symmetry"] && s.Soup != "" {
+		cfg.soup = s.Soup
+	}
+	if !set["speed"] && s.Speed != "" {
+		d, err := time.ParseDuration(s.Speed)
+		if err != nil {
+			return err
+		}
+		cfg.speed = d
+	}
+	if !set["boundary"] && s.Boundary != "" {
+		cfg.boundary = s.Boundary
+	}
+	if !set["theme"] && s.Theme != "" {
+		cfg.theme = s.Theme
+	}
+	if !set["profile"] && s.Profile != "" {
+		cfg.profile = s.Profile
+	}
+	if !set["antialias"] && s.Antialias {
+		cfg.antialias = true
+	}
+	if !set["keep-running"] && s.KeepRunning {
+		cfg.keepRunning = true
+	}
+	// The saved rule only applies when neither -rule nor the pattern has one
+	cfg.savedRule = s.Rule
+	cfg.keys = s.Keys
+	cfg.stateColors = s.StateColors
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// cellShape is how a theme draws live cells
+type cellShape int
+
+const (
+	shapeSquare cellShape = iota
+	// shapeRounded draws squares with rounded corners
+	shapeRounded
+	// shapeCircle draws a dot in the middle of each cell
+	shapeCircle
+)
+
+// cornerRadius is the share of a cell's size used to round its corners
+const cornerRadius = 0.3
+
+// cellGap is the share of a cell's size left empty around a shaped cell so
+// neighbouring cells stay apart
+const cellGap = 0.1
+
+// whitePixel is the source image for filling paths with a vertex color
+var whitePixel = func() *ebiten.Image {
+	img := ebiten.NewImage(3, 3)
+	img.Fill(color.White)
+	return img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+}()
+
+// cellSprite is a white cell in the theme's shape that is tinted and drawn
+// for every live cell. Drawing the same image over and over lets ebiten
+// batch all the cells into a few draw calls.
+type cellSprite struct {
+	image     *ebiten.Image
+	size      int
+	shape     cellShape
+	antialias bool
+}
+
+// get returns the sprite for cells of the given size and shape, rendering a
+// new one when anything has changed
+func (s *cellSprite) get(size int, shape cellShape, antialias bool) *ebiten.Image {
+	if s.image != nil && s.size == size && s.shape == shape && s.antialias == antialias {
+		return s.image
+	}
+	if s.image != nil {
+		s.image.Deallocate()
+	}
+	s.image = ebiten.NewImage(size, size)
+	s.size, s.shape, s.antialias = size, shape, antialias
+	drawShape(s.image, shape, 0, 0, float32(size), color.RGBA{255, 255, 255, 255}, antialias)
+	return s.image
+}
+
+// drawShape fills a cell-sized shape with its top left corner at x, y. Tiny
+// cells are always squares since there is no room to round them.
+func drawShape(dst *ebiten.Image, shape cellShape, x, y, size float32, c color.RGBA, antialias bool) {
+	if shape == shapeSquare || size < 4 {
+		vector.DrawFilledRect(dst, x, y, size, size, c, antialias)
+		return
+	}
+	inset := size * cellGap / 2
+	x, y, size = x+inset, y+inset, size-2*inset
+
+	if shape == shapeCircle {
+		vector.DrawFilledCircle(dst, x+size/2, y+size/2, size/2, c, antialias)
+		return
+	}
+
+	// Rounded squares are a path through the four corner arcs
+	r := size * cornerRadius
+	var path vector.Path
+	path.MoveTo(x+r, y)
+	path.ArcTo(x+size, y, x+size, y+size, r)
+	path.ArcTo(x+size, y+size, x, y+size, r)
+	path.ArcTo(x, y+size, x, y, r)
+	path.ArcTo(x, y, x+size, y, r)
+	path.Close()
+
+	vs, is := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	for i := range vs {
+		vs[i].SrcX, vs[i].SrcY = 1, 1
+		vs[i].ColorR = float32(c.R) / 255
+		vs[i].ColorG = float32(c.G) / 255
+		vs[i].ColorB = float32(c.B) / 255
+		vs[i].ColorA = float32(c.A) / 255
+	}
+	dst.DrawTriangles(vs, is, whitePixel, &ebiten.DrawTrianglesOptions{AntiAlias: antialias})
+}
+
+// drawCell draws a live cell in the theme's shape and the given color
+func (w *World) drawCell(screen *ebiten.Image, x, y int, c color.RGBA) {
+	sx, sy := w.cellToScreen(x, y)
+	var op ebiten.DrawImageOptions
+	op.GeoM.Translate(float64(sx), float64(sy))
+	op.ColorScale.ScaleWithColor(c)
+	screen.DrawImage(w.sprite.get(w.tileSize, w.theme.Shape, w.antialias), &op)
+}
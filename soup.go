@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// soupSymmetry is the symmetry random soups are generated with. Symmetric
+// soups are standard in soup searching as they often settle into more
+// interesting objects.
+type soupSymmetry int
+
+const (
+	soupAsymmetric soupSymmetry = iota
+	// soupC2 is unchanged by a half turn about the centre of the grid
+	soupC2
+	// soupC4 is unchanged by a quarter turn
+	soupC4
+	// soupD2 is mirrored across the vertical centre line
+	soupD2
+	// soupD8 has every symmetry of the square, quarter turns and mirrors
+	soupD8
+	soupSymmetries
+)
+
+// String returns the name of the soup symmetry
+func (s soupSymmetry) String() string {
+	switch s {
+	case soupC2:
+		return "C2"
+	case soupC4:
+		return "C4"
+	case soupD2:
+		return "D2"
+	case soupD8:
+		return "D8"
+	}
+	return "none"
+}
+
+// parseSoupSymmetry parses a soup symmetry name
+func parseSoupSymmetry(s string) (soupSymmetry, error) {
+	for sym := soupAsymmetric; sym < soupSymmetries; sym++ {
+		if strings.EqualFold(s, sym.String()) {
+			return sym, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown soup symmetry %q, expected none, C2, C4, D2 or D8", s)
+}
+
+// soupArea returns the part of the grid a soup fills. Soups with quarter
+// turns need a square, so they fill the largest square in the middle.
+func (w *World) soupArea() (x, y, width, height int) {
+	if w.soupSymmetry != soupC4 && w.soupSymmetry != soupD8 {
+		return 0, 0, w.grid.Width, w.grid.Height
+	}
+	n := min(w.grid.Width, w.grid.Height)
+	return (w.grid.Width - n) / 2, (w.grid.Height - n) / 2, n, n
+}
+
+// soupOrbit returns a cell of the soup area and the cells the soup's
+// symmetry maps it to, which are all alive or dead together
+func (w *World) soupOrbit(cell tile) []tile {
+	x0, y0, width, height := w.soupArea()
+	// Coordinates within the area and their mirror images
+	a, b := cell.X-x0, cell.Y-y0
+	ma, mb := width-1-a, height-1-b
+	at := func(a, b int) tile { return tile{X: x0 + a, Y: y0 + b} }
+
+	switch w.soupSymmetry {
+	case soupC2:
+		return []tile{cell, at(ma, mb)}
+	case soupC4:
+		return []tile{cell, at(mb, a), at(ma, mb), at(b, ma)}
+	case soupD2:
+		return []tile{cell, at(ma, b)}
+	case soupD8:
+		return []tile{cell, at(mb, a), at(ma, mb), at(b, ma), at(ma, b), at(a, mb), at(b, a), at(mb, ma)}
+	}
+	return []tile{cell}
+}
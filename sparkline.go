@@ -0,0 +1,77 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// sparklineSamples is how many generations the sparkline shows
+const sparklineSamples = 60
+
+// sparklineBarWidth is the width in pixels of one generation in the sparkline
+const sparklineBarWidth = 2
+
+// activity is the number of births and deaths in one generation, and of
+// cells that stayed alive in another state
+type activity struct {
+	births, deaths, changes int
+}
+
+// sparkline keeps the births and deaths of the most recent generations
+type sparkline struct {
+	samples []activity
+}
+
+// record adds the births and deaths of a step, dropping the oldest sample
+// once the sparkline is full
+func (s *sparkline) record(stats life.GenerationStats) {
+	s.samples = append(s.samples, activity{stats.Births, stats.Deaths, stats.Changes})
+	if len(s.samples) > sparklineSamples {
+		s.samples = s.samples[1:]
+	}
+}
+
+// heat returns the average number of cells changed a generation over the
+// last n generations, the heat of an oscillator of period n
+func (s *sparkline) heat(n int) float64 {
+	n = min(n, len(s.samples))
+	if n == 0 {
+		return 0
+	}
+	total := 0
+	for _, a := range s.samples[len(s.samples)-n:] {
+		total += a.births + a.deaths + a.changes
+	}
+	return float64(total) / float64(n)
+}
+
+// clear forgets all recorded generations
+func (s *sparkline) clear() {
+	s.samples = nil
+}
+
+// drawSparkline draws births above and deaths below a centre line at the
+// right hand end of the status bar, scaled to the busiest generation shown
+func (w *World) drawSparkline(screen *ebiten.Image) {
+	samples := w.sparkline.samples
+	if len(samples) == 0 {
+		return
+	}
+	busiest := 1
+	for _, a := range samples {
+		busiest = max(busiest, a.births, a.deaths)
+	}
+
+	half := float32(w.gridTop-4) / 2
+	mid := 2 + half
+	left := float32(w.screenWidth - sparklineSamples*sparklineBarWidth - 4)
+	for i, a := range samples {
+		x := left + float32(i*sparklineBarWidth)
+		born := half * float32(a.births) / float32(busiest)
+		died := half * float32(a.deaths) / float32(busiest)
+		vector.DrawFilledRect(screen, x, mid-born, sparklineBarWidth, born, w.theme.Cell, false)
+		vector.DrawFilledRect(screen, x, mid, sparklineBarWidth, died, w.theme.Warning, false)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/afroash/gameoflife/life"
+)
+
+// ghostColor is used to preview a stamp under the cursor
+func (w *World) ghostColor() color.Color {
+	return fade(w.theme.Cell, 110)
+}
+
+// copySelection returns the live cells of the selection as a pattern with
+// the selection's top left corner as its origin
+func (w *World) copySelection() *pattern {
+	minX, minY, maxX, maxY := w.selection.bounds()
+	p := &pattern{width: maxX - minX + 1, height: maxY - minY + 1}
+	for cell := range w.grid.Cells() {
+		if w.selection.contains(cell) {
+			p.add(tile{X: cell.X - minX, Y: cell.Y - minY}, w.grid.State(cell))
+		}
+	}
+	return p
+}
+
+// cutSelection copies the selection to the clipboard and clears it
+func (w *World) cutSelection() {
+	w.clipboard = w.copySelection()
+	w.editSelection(false)
+}
+
+// saveSelection writes the live cells of the selection to name.rle in the
+// user pattern directory, so it is listed in the pattern picker from then on.
+// It returns the path written.
+func (w *World) saveSelection(dir, name string) (string, error) {
+	if dir == "" {
+		return "", fmt.Errorf("no pattern directory, set one with -patterns")
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid pattern name %q", name)
+	}
+	p := w.copySelection()
+	cells := p.cellSet()
+	if len(cells) == 0 {
+		return "", fmt.Errorf("the selection is empty")
+	}
+	if e, ok := findDuplicate(dir, name, cells); ok {
+		return "", fmt.Errorf("the selection is already in the library as %s", e.label())
+	}
+
+	var b bytes.Buffer
+	var state func(tile) life.State
+	if w.grid.States() > 2 {
+		state = p.state
+	}
+	if err := writeRLE(&b, name, w.grid.RuleName(), cells, state); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name+".rle")
+	return path, os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// stampOrigin returns the cell where the stamp's origin lands so that the
+// stamp is centred on the cursor cell
+func (w *World) stampOrigin(cursor tile) tile {
+	return tile{X: cursor.X - w.stamp.width/2, Y: cursor.Y - w.stamp.height/2}
+}
+
+// handleStamp places the pending stamp on left click and drops it on right
+// click
+func (w *World) handleStamp(x, y int) {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		w.stamp = nil
+		return
+	}
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+	if cursor, ok := w.screenToCell(x, y); ok {
+		w.placeStamp(cursor)
+	}
+}
+
+// placeStamp sets the stamp's cells centred on a cell as one edit, adds it
+// to the recent patterns and leaves stamp mode
+func (w *World) placeStamp(cursor tile) {
+	origin := w.stampOrigin(cursor)
+	w.beginEdit()
+	for _, cell := range w.stamp.cells {
+		w.setCellState(tile{X: origin.X + cell.X, Y: origin.Y + cell.Y}, w.stamp.state(cell))
+	}
+	w.commitEdit()
+	w.recent.add(w.stamp)
+	w.stamp = nil
+}
+
+// drawStamp draws the pending stamp translucently under the cursor
+func (w *World) drawStamp(screen *ebiten.Image) {
+	if w.stamp == nil {
+		return
+	}
+	cursor, ok := w.pointedCell()
+	if !ok {
+		return
+	}
+	origin := w.stampOrigin(cursor)
+	for _, cell := range w.stamp.cells {
+		w.fillCell(screen, origin.X+cell.X, origin.Y+cell.Y, w.ghostColor())
+	}
+}
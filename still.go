@@ -0,0 +1,81 @@
+package main
+
+// Generations a cell and its neighbors must go without changing before the
+// cell counts as still, the settings menu steps it between the limits
+const (
+	defaultStillAfter = 10
+	minStillAfter     = 2
+	maxStillAfter     = 100
+	stillAfterStep    = 2
+)
+
+// stillCells tracks when cells last changed, so settled ash can be told
+// apart from the regions that are still active
+type stillCells struct {
+	muted bool
+	// after is how many generations without a change make a cell still
+	after int
+	// changed is the generation each cell last changed in, cells that
+	// aren't listed last changed at since
+	changed map[tile]int
+	since   int
+}
+
+// record notes the births and deaths between two generations and forgets
+// dead cells that have been quiet long enough not to matter
+func (s *stillCells) record(previous, next map[tile]struct{}, generation int) {
+	if s.changed == nil {
+		s.changed = make(map[tile]int)
+	}
+	for cell := range previous {
+		if _, survived := next[cell]; !survived {
+			s.changed[cell] = generation
+		}
+	}
+	for cell := range next {
+		if _, wasAlive := previous[cell]; !wasAlive {
+			s.changed[cell] = generation
+		}
+	}
+	for cell, g := range s.changed {
+		if _, alive := next[cell]; !alive && generation-g > s.after {
+			delete(s.changed, cell)
+		}
+	}
+}
+
+// touch notes an edit to a cell
+func (s *stillCells) touch(cell tile, generation int) {
+	if s.changed == nil {
+		s.changed = make(map[tile]int)
+	}
+	s.changed[cell] = generation
+}
+
+// reset starts again from a new world, which has just changed everywhere
+func (s *stillCells) reset(generation int) {
+	s.changed = nil
+	s.since = generation
+}
+
+// lastChange returns the generation a cell last changed in
+func (s *stillCells) lastChange(cell tile) int {
+	if g, ok := s.changed[cell]; ok {
+		return g
+	}
+	return s.since
+}
+
+// isStill reports whether a cell and its neighbors have gone the still
+// setting's generations without changing
+func (w *World) isStill(cell tile) bool {
+	for i := -1; i <= 1; i++ {
+		for j := -1; j <= 1; j++ {
+			c := w.grid.Wrap(tile{X: cell.X + i, Y: cell.Y + j})
+			if w.grid.Generation-w.still.lastChange(c) < w.still.after {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// streamBuffer is how many messages a client can fall behind by before it
+// is dropped
+const streamBuffer = 64
+
+// streamMessage is sent over the websocket whenever the cells change,
+// which is once a generation while the simulation runs
+type streamMessage struct {
+	Generation int `json:"generation"`
+	// Reset is set on the first message, whose born cells are every live
+	// cell, so a client starts again from an empty grid
+	Reset bool     `json:"reset,omitempty"`
+	Born  [][2]int `json:"born"`
+	Died  [][2]int `json:"died"`
+}
+
+// streamClient is a connection being sent the changes, a websocket client
+// or a player in a shared world
+type streamClient struct {
+	send chan []byte
+	// gone is closed when the client is dropped for falling behind
+	gone     chan struct{}
+	dropOnce sync.Once
+	// encode turns a message into what the client is sent, JSON when nil
+	encode func(streamMessage) ([]byte, error)
+}
+
+// drop stops sending to a client
+func (c *streamClient) drop() {
+	c.dropOnce.Do(func() { close(c.gone) })
+}
+
+// gridStream keeps the connected clients up to date. It is only used on
+// the game loop.
+type gridStream struct {
+	clients map[*streamClient]struct{}
+	// last is the cells as the clients last saw them
+	last map[tile]struct{}
+}
+
+// add sends a new client the whole grid, after bringing the existing clients
+// up to date so they all share last
+func (s *gridStream) add(c *streamClient, w *World) {
+	s.update(w)
+	if s.clients == nil {
+		s.clients = make(map[*streamClient]struct{})
+	}
+	if len(s.clients) == 0 {
+		s.last = copyCells(w.grid.Cells())
+	}
+	s.clients[c] = struct{}{}
+	s.send(c, streamMessage{Generation: w.grid.Generation, Reset: true, Born: cellPairs(sortedCells(s.last)), Died: [][2]int{}})
+}
+
+// remove stops sending to a client
+func (s *gridStream) remove(c *streamClient) {
+	delete(s.clients, c)
+}
+
+// update sends the cells born and died since the last update to every
+// client, in no particular order
+func (s *gridStream) update(w *World) {
+	if len(s.clients) == 0 {
+		return
+	}
+	msg := streamMessage{Generation: w.grid.Generation, Born: [][2]int{}, Died: [][2]int{}}
+	cells := w.grid.Cells()
+	for cell := range cells {
+		if _, ok := s.last[cell]; !ok {
+			msg.Born = append(msg.Born, [2]int{cell.X, cell.Y})
+		}
+	}
+	for cell := range s.last {
+		if _, ok := cells[cell]; !ok {
+			msg.Died = append(msg.Died, [2]int{cell.X, cell.Y})
+		}
+	}
+	if len(msg.Born) == 0 && len(msg.Died) == 0 {
+		return
+	}
+	// Only the changes are applied to last, rather than copying the grid
+	for _, c := range msg.Born {
+		s.last[tile{X: c[0], Y: c[1]}] = struct{}{}
+	}
+	for _, c := range msg.Died {
+		delete(s.last, tile{X: c[0], Y: c[1]})
+	}
+	for c := range s.clients {
+		s.send(c, msg)
+	}
+}
+
+// send queues a message for a client without waiting, dropping the client
+// if it has fallen too far behind
+func (s *gridStream) send(c *streamClient, msg streamMessage) {
+	var data []byte
+	var err error
+	if c.encode != nil {
+		data, err = c.encode(msg)
+	} else {
+		data, err = json.Marshal(msg)
+	}
+	if err != nil {
+		log.Printf("stream: %v", err)
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+		s.remove(c)
+		c.drop()
+	}
+}
+
+// streamGrid upgrades the request to a websocket and sends the grid
+// followed by the changes to it until the client goes away
+func (s *apiServer) streamGrid(rw http.ResponseWriter, r *http.Request) {
+	conn, buf, err := upgradeWebsocket(rw, r)
+	if err != nil {
+		log.Printf("stream: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	c := &streamClient{send: make(chan []byte, streamBuffer), gone: make(chan struct{})}
+	s.do(func(g *Game) { s.stream.add(c, g.world) })
+	defer s.do(func(g *Game) { s.stream.remove(c) })
+
+	// Read in the background to answer pings and notice the client closing
+	pings := make(chan []byte, 1)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			opcode, payload, err := readWebsocketFrame(buf.Reader)
+			if err != nil || opcode == opClose {
+				return
+			}
+			if opcode == opPing {
+				select {
+				case pings <- payload:
+				default:
+				}
+			}
+		}
+	}()
+
+	for {
+		var err error
+		select {
+		case data := <-c.send:
+			err = writeWebsocketFrame(buf.Writer, opText, data)
+		case payload := <-pings:
+			err = writeWebsocketFrame(buf.Writer, opPong, payload)
+		case <-c.gone:
+			writeWebsocketFrame(buf.Writer, opClose, nil)
+			return
+		case <-closed:
+			writeWebsocketFrame(buf.Writer, opClose, nil)
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// copyCells returns a copy of a set of cells
+func copyCells(cells map[tile]struct{}) map[tile]struct{} {
+	c := make(map[tile]struct{}, len(cells))
+	for cell := range cells {
+		c[cell] = struct{}{}
+	}
+	return c
+}
+
+// cellPairs converts cells to the [x, y] pairs used in JSON
+func cellPairs(cells []tile) [][2]int {
+	pairs := make([][2]int, 0, len(cells))
+	for _, cell := range cells {
+		pairs = append(pairs, [2]int{cell.X, cell.Y})
+	}
+	return pairs
+}
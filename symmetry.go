@@ -0,0 +1,97 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// symmetry is a drawing mode that mirrors every painted cell
+type symmetry int
+
+const (
+	symmetryNone symmetry = iota
+	// symmetryHorizontal mirrors across the horizontal centre line
+	symmetryHorizontal
+	// symmetryVertical mirrors across the vertical centre line
+	symmetryVertical
+	// symmetryDiagonal mirrors across the diagonal through the centre
+	symmetryDiagonal
+	// symmetryFourFold mirrors across both centre lines
+	symmetryFourFold
+	symmetryModes
+)
+
+// String returns the name of the symmetry mode shown in the HUD
+func (s symmetry) String() string {
+	switch s {
+	case symmetryHorizontal:
+		return "horizontal"
+	case symmetryVertical:
+		return "vertical"
+	case symmetryDiagonal:
+		return "diagonal"
+	case symmetryFourFold:
+		return "4-fold"
+	}
+	return "none"
+}
+
+// next returns the symmetry mode after s, wrapping round to none
+func (s symmetry) next() symmetry {
+	return (s + 1) % symmetryModes
+}
+
+// mirrorCells returns the cell and its mirror images for the current
+// symmetry mode. The axes go through the centre of the grid.
+func (w *World) mirrorCells(cell tile) []tile {
+	// Mirror coordinates on a grid of width n are n-1-x
+	mirrorX := w.grid.Width - 1 - cell.X
+	mirrorY := w.grid.Height - 1 - cell.Y
+
+	switch w.symmetry {
+	case symmetryHorizontal:
+		return []tile{cell, {X: cell.X, Y: mirrorY}}
+	case symmetryVertical:
+		return []tile{cell, {X: mirrorX, Y: cell.Y}}
+	case symmetryDiagonal:
+		cx, cy := w.grid.Width/2, w.grid.Height/2
+		return []tile{cell, {X: cx + (cell.Y - cy), Y: cy + (cell.X - cx)}}
+	case symmetryFourFold:
+		return []tile{cell, {X: mirrorX, Y: cell.Y}, {X: cell.X, Y: mirrorY}, {X: mirrorX, Y: mirrorY}}
+	}
+	return []tile{cell}
+}
+
+// paintCell sets a cell and its mirror images
+func (w *World) paintCell(cell tile, alive bool) {
+	for _, c := range w.mirrorCells(cell) {
+		w.setCell(c, alive)
+	}
+}
+
+// drawSymmetryAxes draws the mirror lines of the current symmetry mode
+func (w *World) drawSymmetryAxes(screen *ebiten.Image) {
+	if w.symmetry == symmetryNone {
+		return
+	}
+	axis := fade(w.theme.Accent, 160)
+	left, top := w.cellToScreen(0, 0)
+	right, bottom := w.cellToScreen(w.grid.Width, w.grid.Height)
+	midX, midY := (left+right)/2, (top+bottom)/2
+
+	switch w.symmetry {
+	case symmetryHorizontal:
+		vector.StrokeLine(screen, left, midY, right, midY, 2, axis, w.antialias)
+	case symmetryVertical:
+		vector.StrokeLine(screen, midX, top, midX, bottom, 2, axis, w.antialias)
+	case symmetryDiagonal:
+		// The diagonal goes through the centre cell at 45°
+		cx, cy := w.cellToScreen(w.grid.Width/2, w.grid.Height/2)
+		half := float32(w.tileSize) / 2
+		length := float32(max(w.grid.Width, w.grid.Height)*w.tileSize) / 2
+		vector.StrokeLine(screen, cx+half-length, cy+half-length, cx+half+length, cy+half+length, 2, axis, w.antialias)
+	case symmetryFourFold:
+		vector.StrokeLine(screen, left, midY, right, midY, 2, axis, w.antialias)
+		vector.StrokeLine(screen, midX, top, midX, bottom, 2, axis, w.antialias)
+	}
+}
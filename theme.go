@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Theme is a named set of colors used to draw the world
+type Theme struct {
+	Name       string
+	Background color.RGBA
+	GridLines  color.RGBA
+	Cell       color.RGBA
+	// OldCell is the end of the age gradient that starts at Cell
+	OldCell   color.RGBA
+	Selection color.RGBA
+	// Accent marks guides such as symmetry axes
+	Accent  color.RGBA
+	Warning color.RGBA
+	Heat    color.RGBA
+	// Shape is how live cells are drawn, squares unless set
+	Shape cellShape
+	// States colors the states of multi-state rules, starting with state 1.
+	// States past the end of the palette run from Cell to OldCell.
+	States []color.RGBA
+}
+
+// Palettes for multi-state rules, such as the firing and dying cells of
+// Brian's Brain or the two colors of Immigration
+var (
+	classicStates = []color.RGBA{{255, 255, 0, 255}, {220, 40, 0, 255}, {0, 90, 255, 255}, {140, 60, 0, 255}}
+	darkStates    = []color.RGBA{{90, 230, 255, 255}, {255, 80, 200, 255}, {255, 190, 60, 255}, {30, 80, 140, 255}}
+)
+
+// themes lists the built-in themes, the first one is the default
+var themes = []*Theme{
+	{
+		Name:       "classic",
+		Background: color.RGBA{128, 128, 128, 255},
+		GridLines:  color.RGBA{0, 0, 0, 255},
+		Cell:       color.RGBA{255, 255, 0, 255},
+		OldCell:    color.RGBA{140, 60, 0, 255},
+		Selection:  color.RGBA{0, 120, 255, 255},
+		Accent:     color.RGBA{255, 0, 255, 255},
+		Warning:    color.RGBA{200, 0, 0, 255},
+		Heat:       color.RGBA{255, 0, 0, 255},
+		States:     classicStates,
+	},
+	{
+		Name:       "dark",
+		Background: color.RGBA{18, 18, 22, 255},
+		GridLines:  color.RGBA{44, 44, 52, 255},
+		Cell:       color.RGBA{90, 230, 255, 255},
+		OldCell:    color.RGBA{30, 80, 140, 255},
+		Selection:  color.RGBA{80, 140, 255, 255},
+		Accent:     color.RGBA{255, 80, 200, 255},
+		Warning:    color.RGBA{220, 40, 40, 255},
+		Heat:       color.RGBA{255, 100, 0, 255},
+		States:     darkStates,
+	},
+	{
+		// The classic colors with rounded cells
+		Name:       "rounded",
+		Background: color.RGBA{128, 128, 128, 255},
+		GridLines:  color.RGBA{0, 0, 0, 255},
+		Cell:       color.RGBA{255, 255, 0, 255},
+		OldCell:    color.RGBA{140, 60, 0, 255},
+		Selection:  color.RGBA{0, 120, 255, 255},
+		Accent:     color.RGBA{255, 0, 255, 255},
+		Warning:    color.RGBA{200, 0, 0, 255},
+		Heat:       color.RGBA{255, 0, 0, 255},
+		States:     classicStates,
+		Shape:      shapeRounded,
+	},
+	{
+		// The dark colors with round cells, which look good in screenshots
+		Name:       "dots",
+		Background: color.RGBA{18, 18, 22, 255},
+		GridLines:  color.RGBA{44, 44, 52, 255},
+		Cell:       color.RGBA{90, 230, 255, 255},
+		OldCell:    color.RGBA{30, 80, 140, 255},
+		Selection:  color.RGBA{80, 140, 255, 255},
+		Accent:     color.RGBA{255, 80, 200, 255},
+		Warning:    color.RGBA{220, 40, 40, 255},
+		Heat:       color.RGBA{255, 100, 0, 255},
+		States:     darkStates,
+		Shape:      shapeCircle,
+	},
+	{
+		// Colors from Ethan Schoonover's Solarized palette
+		Name:       "solarized",
+		Background: color.RGBA{0, 43, 54, 255},
+		GridLines:  color.RGBA{7, 54, 66, 255},
+		Cell:       color.RGBA{181, 137, 0, 255},
+		OldCell:    color.RGBA{203, 75, 22, 255},
+		Selection:  color.RGBA{38, 139, 210, 255},
+		Accent:     color.RGBA{211, 54, 130, 255},
+		Warning:    color.RGBA{220, 50, 47, 255},
+		Heat:       color.RGBA{220, 50, 47, 255},
+		States:     []color.RGBA{{181, 137, 0, 255}, {38, 139, 210, 255}, {133, 153, 0, 255}, {203, 75, 22, 255}},
+	},
+	{
+		Name:       "high-contrast",
+		Background: color.RGBA{0, 0, 0, 255},
+		GridLines:  color.RGBA{110, 110, 110, 255},
+		Cell:       color.RGBA{255, 255, 255, 255},
+		OldCell:    color.RGBA{150, 150, 150, 255},
+		Selection:  color.RGBA{0, 255, 255, 255},
+		Accent:     color.RGBA{255, 0, 255, 255},
+		Warning:    color.RGBA{255, 0, 0, 255},
+		Heat:       color.RGBA{255, 255, 0, 255},
+		States:     []color.RGBA{{255, 255, 255, 255}, {0, 255, 255, 255}, {255, 0, 255, 255}, {255, 255, 0, 255}},
+	},
+}
+
+// themeNames lists the names of the built-in themes
+func themeNames() []string {
+	var names []string
+	for _, t := range themes {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// themeByName finds a built-in theme
+func themeByName(name string) (*Theme, error) {
+	for _, t := range themes {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown theme %q, available themes: %s", name, strings.Join(themeNames(), ", "))
+}
+
+// nextTheme returns the built-in theme after t, wrapping round to the first
+func nextTheme(t *Theme) *Theme {
+	for i, theme := range themes {
+		if theme == t {
+			return themes[(i+1)%len(themes)]
+		}
+	}
+	return themes[0]
+}
+
+// mustThemeByName is like themeByName but panics on error, for the themes
+// the code refers to by name
+func mustThemeByName(name string) *Theme {
+	t, err := themeByName(name)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// darkTheme is the theme dark mode switches to
+var darkTheme = mustThemeByName("dark")
+
+// toggleDarkMode switches to the dark theme, or back to the theme that was
+// in use before it
+func (w *World) toggleDarkMode() {
+	if w.theme == darkTheme {
+		w.theme = w.lightTheme
+		if w.theme == nil || w.theme == darkTheme {
+			w.theme = themes[0]
+		}
+		return
+	}
+	w.lightTheme = w.theme
+	w.theme = darkTheme
+}
+
+// stateColor returns the color of a state of a rule with the given number of
+// states. State 0 is dead and drawn as the background.
+func (t *Theme) stateColor(state, states int) color.RGBA {
+	switch {
+	case state <= 0:
+		return t.Background
+	case state <= len(t.States):
+		return t.States[state-1]
+	case states <= 2:
+		return t.Cell
+	}
+	return lerpColor(t.Cell, t.OldCell, float64(state-1)/float64(states-2))
+}
+
+// parsePalette parses colors written as "#rrggbb", such as the state
+// colors in the config file
+func parsePalette(names []string) ([]color.RGBA, error) {
+	palette := make([]color.RGBA, 0, len(names))
+	for _, name := range names {
+		rgb, err := strconv.ParseUint(strings.TrimPrefix(name, "#"), 16, 32)
+		if err != nil || len(name) != 7 || name[0] != '#' {
+			return nil, fmt.Errorf("invalid color %q, expected #rrggbb", name)
+		}
+		palette = append(palette, color.RGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255})
+	}
+	return palette, nil
+}
+
+// setStatePalette gives every theme the same colors for the states of
+// multi-state rules
+func setStatePalette(palette []color.RGBA) {
+	for _, t := range themes {
+		t.States = palette
+	}
+}
+
+// fade returns c with the given alpha. Colors are alpha-premultiplied, so
+// the color channels scale too.
+func fade(c color.RGBA, alpha uint8) color.RGBA {
+	scale := func(v uint8) uint8 {
+		return uint8(uint16(v) * uint16(alpha) / 255)
+	}
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), alpha}
+}
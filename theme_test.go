@@ -0,0 +1,45 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestStateColor(t *testing.T) {
+	for _, theme := range themes {
+		if len(theme.States) < 2 {
+			t.Errorf("%s has %d state colors, want a palette", theme.Name, len(theme.States))
+			continue
+		}
+		for s, want := range theme.States {
+			if got := theme.stateColor(s+1, 3); got != want {
+				t.Errorf("%s state %d is %v, want %v", theme.Name, s+1, got, want)
+			}
+		}
+		if got := theme.stateColor(0, 3); got != theme.Background {
+			t.Errorf("%s dead cells are %v, want the background", theme.Name, got)
+		}
+	}
+
+	// States past the palette fall back to the age gradient
+	theme := &Theme{Cell: color.RGBA{255, 0, 0, 255}, OldCell: color.RGBA{0, 0, 255, 255}, States: []color.RGBA{{0, 255, 0, 255}}}
+	if got := theme.stateColor(3, 4); got != theme.OldCell {
+		t.Errorf("last state is %v, want %v", got, theme.OldCell)
+	}
+}
+
+func TestParsePalette(t *testing.T) {
+	palette, err := parsePalette([]string{"#ffffff", "#3080ff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []color.RGBA{{255, 255, 255, 255}, {48, 128, 255, 255}}
+	if len(palette) != len(want) || palette[0] != want[0] || palette[1] != want[1] {
+		t.Errorf("got %v, want %v", palette, want)
+	}
+	for _, bad := range []string{"white", "#fff", "#12345g", "#1234567"} {
+		if _, err := parsePalette([]string{bad}); err == nil {
+			t.Errorf("%q parsed without an error", bad)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// scene is the screen being shown
+type scene int
+
+const (
+	sceneGame scene = iota
+	// sceneTitle is shown at start unless a pattern or soup was asked for,
+	// and when leaving the game
+	sceneTitle
+)
+
+// titleScreen is the menu shown before playing
+type titleScreen struct {
+	selected int
+	// loading is set while the pattern picker opened from the title is open
+	loading bool
+	// started is set once the game has been played, so it can be continued
+	started bool
+}
+
+// titleItem is an entry of the title menu
+type titleItem struct {
+	label string
+	run   func(g *Game)
+}
+
+// titleItems returns the entries of the title menu
+func (g *Game) titleItems() []titleItem {
+	var items []titleItem
+	if g.title.started {
+		items = append(items, titleItem{"Continue", (*Game).startGame})
+	}
+	items = append(items,
+		titleItem{"New world", func(g *Game) {
+			g.world.leaveGameModes()
+			g.world.beginEdit()
+			g.world.setCells(make(map[tile]struct{}))
+			g.world.commitEdit()
+			g.world.isSimulating = false
+			g.startGame()
+		}},
+		titleItem{"Random soup", func(g *Game) {
+			g.world.leaveGameModes()
+			g.world.generateRandomCells()
+			g.startGame()
+		}},
+		titleItem{"Two players", func(g *Game) {
+			// The defaults always make a valid game
+			_ = g.world.startVersus(versusCells, versusGenerations)
+			g.startGame()
+		}},
+		titleItem{"Load pattern", func(g *Game) {
+			g.title.loading = true
+			g.picker.show(g.patternDir)
+		}},
+		titleItem{"Settings", func(g *Game) { g.menu.open = true }},
+	)
+	// A web page can't be closed from inside, quitting would only freeze it
+	if !inBrowser {
+		items = append(items, titleItem{"Quit", func(g *Game) { g.quitting = true }})
+	}
+	return items
+}
+
+// startGame leaves the title screen for the game
+func (g *Game) startGame() {
+	g.scene = sceneGame
+	g.title.started = true
+}
+
+// leaveGame goes back to the title screen, or quits in the terminal where
+// there is no title screen
+func (g *Game) leaveGame() {
+	if g.terminal {
+		g.quitting = true
+		return
+	}
+	g.world.isSimulating = false
+	g.scene = sceneTitle
+	g.title.selected = 0
+}
+
+// handleTitle moves through the title menu with up and down and chooses on
+// enter, Esc goes back to the game if there is one
+func (g *Game) handleTitle() {
+	t := &g.title
+	if t.loading {
+		// The picker has closed, a chosen pattern is waiting to be stamped
+		t.loading = false
+		if g.world.stamp != nil {
+			g.world.leaveGameModes()
+			g.startGame()
+			return
+		}
+	}
+	items := g.titleItems()
+	t.selected = min(t.selected, len(items)-1)
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape) && t.started:
+		g.startGame()
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		t.selected = cycle(t.selected, len(items), -1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		t.selected = cycle(t.selected, len(items), 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		items[t.selected].run(g)
+	}
+}
+
+// drawTitle shows the title menu in the middle of the screen
+func (g *Game) drawTitle(screen *ebiten.Image) {
+	var b strings.Builder
+	b.WriteString("GAME OF LIFE\n\n")
+	for i, item := range g.titleItems() {
+		if i == g.title.selected {
+			b.WriteString("> ")
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(item.label + "\n")
+	}
+	b.WriteString("\nUp/Down select, Enter to choose")
+	drawCentredBox(screen, g.world, b.String())
+}
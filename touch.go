@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// pinch is the state of a two finger gesture on a touch screen
+type pinch struct {
+	active bool
+
+	// Distance between the fingers and cell size when the gesture started
+	startDistance float64
+	startSize     int
+
+	// Midpoint of the fingers in the last frame
+	x, y int
+}
+
+// handleTouch handles touch screen gestures and reports whether the screen
+// is being touched. Two fingers pan the camera and zoom as they pinch
+// together or spread apart, keeping the point between them in place. One
+// finger paints, places a stamp, or starts and pauses the simulation when
+// it taps the top bar.
+func (w *World) handleTouch() bool {
+	ids := ebiten.AppendTouchIDs(nil)
+	wasTouching := w.touching
+	w.touching = len(ids) > 0
+	if len(ids) == 1 && !w.camera.pinch.active {
+		w.handleTap(ids[0])
+		return true
+	}
+	if len(ids) != 2 {
+		w.camera.pinch.active = false
+		if wasTouching {
+			// Lifting the finger ends a stroke
+			w.paintStroke(0, 0, false, false, false)
+		}
+		return w.touching
+	}
+	x0, y0 := ebiten.TouchPosition(ids[0])
+	x1, y1 := ebiten.TouchPosition(ids[1])
+	midX, midY := (x0+x1)/2, (y0+y1)/2
+	distance := math.Hypot(float64(x1-x0), float64(y1-y0))
+
+	p := &w.camera.pinch
+	if !p.active {
+		// A second finger turns a stroke into a pinch
+		w.paintStroke(0, 0, false, false, false)
+		*p = pinch{active: true, startDistance: distance, startSize: w.tileSize, x: midX, y: midY}
+		return true
+	}
+	w.pan(p.x-midX, p.y-midY)
+	p.x, p.y = midX, midY
+	if p.startDistance > 0 {
+		size := int(math.Round(float64(p.startSize) * distance / p.startDistance))
+		w.zoom(size, midX, midY)
+	}
+	return true
+}
+
+// handleTap acts on a single finger touch
+func (w *World) handleTap(id ebiten.TouchID) {
+	x, y := ebiten.TouchPosition(id)
+	pressed := inpututil.TouchPressDuration(id) == 1
+	switch {
+	case pressed && y < w.gridTop:
+		w.setRunning(!w.isSimulating)
+	case w.stamp != nil:
+		if cell, ok := w.screenToCell(x, y); ok && pressed {
+			w.placeStamp(cell)
+		}
+	default:
+		w.paintStroke(x, y, true, false, pressed)
+	}
+}
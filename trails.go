@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// trailLength is how many generations a dead cell keeps fading for
+const trailLength = 6
+
+// trails remembers how many generations ago recently dead cells died
+type trails struct {
+	visible bool
+	dead    map[tile]int
+}
+
+// record ages the existing trails and starts one for every cell that died
+// between the two generations
+func (t *trails) record(previous, next map[tile]struct{}) {
+	if t.dead == nil {
+		t.dead = make(map[tile]int)
+	}
+	for cell, n := range t.dead {
+		if _, reborn := next[cell]; reborn || n >= trailLength {
+			delete(t.dead, cell)
+			continue
+		}
+		t.dead[cell] = n + 1
+	}
+	for cell := range previous {
+		if _, survived := next[cell]; !survived {
+			t.dead[cell] = 1
+		}
+	}
+}
+
+// clear removes all trails
+func (t *trails) clear() {
+	t.dead = nil
+}
+
+// drawTrails draws recently dead cells, fading out as they get older
+func (w *World) drawTrails(screen *ebiten.Image) {
+	if !w.trails.visible {
+		return
+	}
+	for cell, n := range w.trails.dead {
+		if w.grid.Get(cell) || !w.onScreen(cell) {
+			continue
+		}
+		alpha := uint8(160 * (trailLength + 1 - n) / (trailLength + 1))
+		w.drawCell(screen, cell.X, cell.Y, fade(w.theme.Cell, alpha))
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+// rotate returns the pattern turned 90° clockwise
+func (p *pattern) rotate() *pattern {
+	r := &pattern{name: p.name, rule: p.rule, width: p.height, height: p.width}
+	for _, cell := range p.cells {
+		r.add(tile{X: p.height - 1 - cell.Y, Y: cell.X}, p.state(cell))
+	}
+	return r
+}
+
+// rotateCounterClockwise returns the pattern turned 90° anticlockwise
+func (p *pattern) rotateCounterClockwise() *pattern {
+	return p.rotate().rotate().rotate()
+}
+
+// flipHorizontal returns the pattern mirrored left to right
+func (p *pattern) flipHorizontal() *pattern {
+	r := &pattern{name: p.name, rule: p.rule, width: p.width, height: p.height}
+	for _, cell := range p.cells {
+		r.add(tile{X: p.width - 1 - cell.X, Y: cell.Y}, p.state(cell))
+	}
+	return r
+}
+
+// flipVertical returns the pattern mirrored top to bottom
+func (p *pattern) flipVertical() *pattern {
+	r := &pattern{name: p.name, rule: p.rule, width: p.width, height: p.height}
+	for _, cell := range p.cells {
+		r.add(tile{X: cell.X, Y: p.height - 1 - cell.Y}, p.state(cell))
+	}
+	return r
+}
+
+// canTransform reports whether there is a stamp or selection for the
+// transform keys to act on
+func (w *World) canTransform() bool {
+	return w.stamp != nil || (w.selecting && w.selection.active && w.selection.moving == nil)
+}
+
+// applyTransform rotates or mirrors the pending stamp, or the selection
+// when there is no stamp
+func (w *World) applyTransform(transform func(*pattern) *pattern) {
+	if w.stamp != nil {
+		w.stamp = transform(w.stamp)
+		return
+	}
+	w.transformSelection(transform)
+}
+
+// transformSelection replaces the selected cells with their transformed
+// copy, keeping the top left corner of the selection fixed
+func (w *World) transformSelection(transform func(*pattern) *pattern) {
+	minX, minY, _, _ := w.selection.bounds()
+	p := transform(w.copySelection())
+	w.beginEdit()
+	defer w.commitEdit()
+	w.fillSelection(false)
+
+	w.selection.start = tile{X: minX, Y: minY}
+	w.selection.end = tile{X: minX + p.width - 1, Y: minY + p.height - 1}
+	for _, cell := range p.cells {
+		w.setCellState(tile{X: minX + cell.X, Y: minY + cell.Y}, p.state(cell))
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Births and deaths only fade when generations are at least
+// minTransitionSpeed apart, faster runs change too quickly to follow
+const minTransitionSpeed = 100 * time.Millisecond
+
+// maxTransition caps how long a birth or death takes to fade in or out
+const maxTransition = 250 * time.Millisecond
+
+// transitionProgress returns how far through fading in the latest generation
+// is, from 0 just after the step to 1 once the fade is over. It is 1 when
+// smooth transitions are off or the simulation is too fast for them.
+func (w *World) transitionProgress() float64 {
+	if !w.smooth || w.previous == nil || w.speed < minTransitionSpeed {
+		return 1
+	}
+	duration := min(w.speed/2, maxTransition)
+	return min(float64(time.Since(w.steppedAt))/float64(duration), 1)
+}
+
+// drawDying draws the cells that died in the latest generation fading out
+func (w *World) drawDying(screen *ebiten.Image, t float64) {
+	if t >= 1 {
+		return
+	}
+	alpha := uint8(255 * (1 - t))
+	for cell := range w.previous {
+		if !w.grid.Get(cell) && w.onScreen(cell) {
+			w.drawCell(screen, cell.X, cell.Y, fade(w.theme.Cell, alpha))
+		}
+	}
+}
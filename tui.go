@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// terminalFrame is the time between redraws in the terminal
+const terminalFrame = 50 * time.Millisecond
+
+// terminalUnsupported are the actions that open overlays only the window
+// can show, they do nothing in the terminal
+var terminalUnsupported = map[string]bool{
+	"settings":     true,
+	"patterns":     true,
+	"catalog":      true,
+	"recent":       true,
+	"methuselahs":  true,
+	"puzzles":      true,
+	"census":       true,
+	"metrics":      true,
+	"command":      true,
+	"record-macro": true,
+	"select":       true,
+	"fullscreen":   true,
+}
+
+// shiftedDigits are the characters typed by shift and a digit on a US
+// keyboard, so Shift+1 bindings work in the terminal
+const shiftedDigits = ")!@#$%^&*("
+
+// terminalKeys maps other characters to the keys that type them
+var terminalKeys = map[byte]keyCombo{
+	' ':  key(ebiten.KeySpace),
+	'\r': key(ebiten.KeyEnter),
+	'\t': key(ebiten.KeyTab),
+	0x7f: key(ebiten.KeyBackspace),
+	'.':  key(ebiten.KeyPeriod),
+	',':  key(ebiten.KeyComma),
+	'=':  key(ebiten.KeyEqual),
+	'+':  shift(ebiten.KeyEqual),
+	'-':  key(ebiten.KeyMinus),
+	';':  key(ebiten.KeySemicolon),
+	':':  shift(ebiten.KeySemicolon),
+	'/':  key(ebiten.KeySlash),
+	'?':  shift(ebiten.KeySlash),
+}
+
+// terminalKey decodes what the terminal sent for one key press. Escape
+// sequences give the arrow keys, and escape before a character is how
+// terminals send Alt.
+func terminalKey(in []byte) (keyCombo, bool) {
+	switch {
+	case len(in) == 0:
+		return keyCombo{}, false
+	case len(in) == 1 && in[0] == 0x1b:
+		return key(ebiten.KeyEscape), true
+	case len(in) == 3 && in[0] == 0x1b && in[1] == '[':
+		arrows := map[byte]ebiten.Key{'A': ebiten.KeyArrowUp, 'B': ebiten.KeyArrowDown, 'C': ebiten.KeyArrowRight, 'D': ebiten.KeyArrowLeft, 'H': ebiten.KeyHome}
+		k, ok := arrows[in[2]]
+		return key(k), ok
+	case len(in) == 2 && in[0] == 0x1b:
+		k, ok := terminalKey(in[1:])
+		k.alt = true
+		return k, ok
+	case len(in) != 1:
+		return keyCombo{}, false
+	}
+
+	c := in[0]
+	if k, ok := terminalKeys[c]; ok {
+		return k, true
+	}
+	switch {
+	case c >= 'a' && c <= 'z':
+		return key(ebiten.KeyA + ebiten.Key(c-'a')), true
+	case c >= 'A' && c <= 'Z':
+		return shift(ebiten.KeyA + ebiten.Key(c-'A')), true
+	case c >= '0' && c <= '9':
+		return key(ebiten.Key0 + ebiten.Key(c-'0')), true
+	case strings.IndexByte(shiftedDigits, c) >= 0:
+		return shift(ebiten.Key0 + ebiten.Key(strings.IndexByte(shiftedDigits, c))), true
+	case c >= 1 && c <= 26:
+		// Control and a letter sends its position in the alphabet
+		return ctrl(ebiten.KeyA + ebiten.Key(c-1)), true
+	}
+	return keyCombo{}, false
+}
+
+// handleTerminalKey runs the bindings for a key typed in the terminal. The
+// terminal only sends presses, so bindings that wait for a release run
+// straight away.
+func (g *Game) handleTerminalKey(k keyCombo) {
+	m := g.mode()
+	var triggered []binding
+	for _, b := range bindings {
+		if terminalUnsupported[b.action] || !b.appliesIn(g, m) {
+			continue
+		}
+		for _, bk := range b.keys {
+			if bk == k {
+				triggered = append(triggered, b)
+				break
+			}
+		}
+	}
+	for _, b := range triggered {
+		b.run(g)
+	}
+}
+
+// terminalRenderer draws the world with block characters. Every character
+// shows two pixels, one above the other, and a cell is minTileSize pixels
+// square so it is as wide as two characters and as tall as one.
+type terminalRenderer struct {
+	lines []string
+}
+
+// DrawGrid starts a blank frame, grid lines don't fit between characters
+func (r *terminalRenderer) DrawGrid(w *World) {
+	r.lines = make([]string, w.screenHeight/2)
+}
+
+// DrawCells draws the live cells in the theme colors, with the keyboard
+// cursor in reverse video, or the diff instead while one is shown
+func (r *terminalRenderer) DrawCells(w *World) {
+	if w.diff != nil {
+		r.drawDiff(w)
+		return
+	}
+	var b strings.Builder
+	for row := w.gridTop / 2; row < len(r.lines); row++ {
+		b.Reset()
+		b.WriteString(ansiColors(w.theme.Cell, w.theme.Background))
+		for x := 0; x < w.screenWidth; x++ {
+			top, onCursor := w.pixelAlive(x, row*2)
+			bottom, _ := w.pixelAlive(x, row*2+1)
+			if onCursor {
+				b.WriteString("\x1b[7m")
+			}
+			b.WriteString(blockChar(top, bottom))
+			if onCursor {
+				b.WriteString("\x1b[27m")
+			}
+		}
+		b.WriteString("\x1b[0m")
+		r.lines[row] = b.String()
+	}
+}
+
+// drawDiff draws the diff in the colors the window uses. Each character is
+// an upper half block colored like its top pixel over a background colored
+// like its bottom one.
+func (r *terminalRenderer) drawDiff(w *World) {
+	var b strings.Builder
+	for row := w.gridTop / 2; row < len(r.lines); row++ {
+		b.Reset()
+		last := ""
+		for x := 0; x < w.screenWidth; x++ {
+			colors := ansiColors(w.diffPixel(x, row*2), w.diffPixel(x, row*2+1))
+			if colors != last {
+				b.WriteString(colors)
+				last = colors
+			}
+			b.WriteString("▀")
+		}
+		b.WriteString("\x1b[0m")
+		r.lines[row] = b.String()
+	}
+}
+
+// DrawHUD writes the status line on the top row
+func (r *terminalRenderer) DrawHUD(w *World) {
+	var texts []string
+	for _, item := range w.hudItems() {
+		texts = append(texts, item.text)
+	}
+	line := strings.Join(texts, "   ")
+	if len(line) > w.screenWidth {
+		line = line[:w.screenWidth]
+	}
+	r.lines[0] = "\x1b[7m" + line + strings.Repeat(" ", w.screenWidth-len(line)) + "\x1b[0m"
+}
+
+// drawHelp lists the controls over the grid rows, as many as fit
+func (r *terminalRenderer) drawHelp(w *World) {
+	lines := append(helpLines(), "", "Press any key to close")
+	for row := w.gridTop / 2; row < len(r.lines); row++ {
+		line := ""
+		if i := row - w.gridTop/2; i < len(lines) {
+			line = lines[i]
+		}
+		if len(line) > w.screenWidth {
+			line = line[:w.screenWidth]
+		}
+		r.lines[row] = line + strings.Repeat(" ", w.screenWidth-len(line))
+	}
+}
+
+// pixelAlive reports whether the cell at a screen position is alive and
+// whether it is the keyboard cursor
+func (w *World) pixelAlive(x, y int) (alive, cursor bool) {
+	cell, ok := w.screenToCell(x, y)
+	if !ok {
+		return false, false
+	}
+	return w.grid.Get(cell), w.cursor.active && cell == w.cursor.cell
+}
+
+// diffPixel returns the color of the diff at a screen position
+func (w *World) diffPixel(x, y int) color.RGBA {
+	if cell, ok := w.screenToCell(x, y); ok {
+		if c, ok := w.diff.cellColor(w.theme, cell); ok {
+			return c
+		}
+	}
+	return w.theme.Background
+}
+
+// blockChar returns the character that fills the top half, bottom half,
+// both or neither
+func blockChar(top, bottom bool) string {
+	switch {
+	case top && bottom:
+		return "█"
+	case top:
+		return "▀"
+	case bottom:
+		return "▄"
+	}
+	return " "
+}
+
+// ansiColors returns the escape sequence for 24 bit foreground and
+// background colors
+func ansiColors(fg, bg color.RGBA) string {
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm", fg.R, fg.G, fg.B, bg.R, bg.G, bg.B)
+}
+
+// stty runs stty on the terminal and returns its output
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// runTerminal plays the game in the terminal instead of a window, with the
+// same key bindings. The mouse isn't used, the keyboard cursor draws cells
+// and places stamps.
+func (g *Game) runTerminal() error {
+	// Put the terminal in raw mode so keys arrive as they are pressed
+	saved, err := stty("-g")
+	if err != nil {
+		return fmt.Errorf("terminal mode needs a terminal with stty: %w", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return err
+	}
+	defer stty(saved)
+
+	var rows, cols int
+	size, err := stty("size")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Sscan(size, &rows, &cols); err != nil || rows < 2 || cols < 1 {
+		return fmt.Errorf("unexpected terminal size %q", size)
+	}
+
+	// Screen pixels are half characters, the top row is the HUD
+	w := g.world
+	w.tileSize = minTileSize
+	w.gridTop = 2
+	w.screenWidth, w.screenHeight = cols, rows*2
+
+	out := bufio.NewWriter(os.Stdout)
+	// Switch to the alternate screen and hide the cursor, undone on exit
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+		out.Flush()
+	}()
+
+	keys := make(chan []byte)
+	go readTerminalKeys(os.Stdin, keys)
+	ticker := time.NewTicker(terminalFrame)
+	defer ticker.Stop()
+
+	r := &terminalRenderer{}
+	for !g.quitting {
+		select {
+		case in, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			k, ok := terminalKey(in)
+			switch {
+			case g.help:
+				// Any key closes the help
+				g.help = false
+			case ok:
+				g.handleTerminalKey(k)
+			}
+		case <-ticker.C:
+		}
+		g.server.runPending(g)
+		w.session.update(w)
+
+		if w.isSimulating && w.session.simulates() && time.Since(w.lastUpdate) > w.speed {
+			w.SimulateWorld()
+			w.lastUpdate = time.Now()
+		}
+		w.followCells()
+
+		w.render(r)
+		if g.help {
+			r.drawHelp(w)
+		}
+		fmt.Fprint(out, "\x1b[H", strings.Join(r.lines, "\r\n"))
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readTerminalKeys sends each read from the terminal as one key press,
+// closing the channel when input ends
+func readTerminalKeys(in io.Reader, keys chan<- []byte) {
+	buf := make([]byte, 16)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			keys <- append([]byte(nil), buf[:n]...)
+		}
+		if err != nil {
+			close(keys)
+			return
+		}
+	}
+}
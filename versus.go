@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// Defaults of a versus game
+const (
+	versusCells       = 10
+	versusGenerations = 200
+)
+
+// versusPlayers are the names of the two players, whose cells are states
+// 1 and 2 of Immigration
+var versusPlayers = [2]string{"Red", "Blue"}
+
+// versusColors are the colors the players' cells are drawn in
+var versusColors = [2]color.RGBA{playerColors[0], playerColors[2]}
+
+// versusGame is two players taking turns to place cells under Immigration,
+// then running the world to see whose color has more cells. Whose turn it
+// is comes from the cells, so undoing a cell gives the turn back.
+type versusGame struct {
+	cells       int
+	generations int
+	// rule is the rule in use before the game, put back when leaving it
+	rule string
+	// result is set once the game is over
+	result string
+}
+
+// startVersus clears the world and switches to Immigration for a game of
+// cells each, run for generations
+func (w *World) startVersus(cells, generations int) error {
+	if cells < 1 || generations < 1 {
+		return fmt.Errorf("a versus game needs at least a cell each and a generation")
+	}
+	w.leaveGameModes()
+	rule := w.grid.RuleName()
+	if err := w.grid.SetRule(immigrationRule); err != nil {
+		return err
+	}
+	w.beginEdit()
+	w.setCells(make(map[tile]struct{}))
+	w.commitEdit()
+	w.isSimulating = false
+	w.versus = &versusGame{cells: cells, generations: generations, rule: rule}
+	return nil
+}
+
+// reset forgets the result once the world goes back in time
+func (v *versusGame) reset() {
+	if v != nil {
+		v.result = ""
+	}
+}
+
+// versusScores counts the live cells of each player
+func (w *World) versusScores() [2]int {
+	var scores [2]int
+	for cell := range w.grid.Cells() {
+		if s := w.grid.State(cell); s == 1 || s == 2 {
+			scores[s-1]++
+		}
+	}
+	return scores
+}
+
+// versusPlacing reports whether the players are still placing cells, and
+// whose turn it is. Red goes first and the turn passes after every cell.
+func (w *World) versusPlacing() (turn int, placing bool) {
+	if w.versus == nil || w.grid.Generation != 0 {
+		return 0, false
+	}
+	scores := w.versusScores()
+	if scores[0] >= w.versus.cells && scores[1] >= w.versus.cells {
+		return 0, false
+	}
+	if scores[0] > scores[1] || scores[0] >= w.versus.cells {
+		return 1, true
+	}
+	return 0, true
+}
+
+// placeVersusCell places a cell at x, y on the screen, which setCell gives
+// the color of the player whose turn it is. The run starts once both
+// players have placed all their cells.
+func (w *World) placeVersusCell(x, y int) {
+	cell, ok := w.screenToCell(x, y)
+	if !ok || w.grid.Get(cell) {
+		return
+	}
+	w.beginEdit()
+	w.setCell(cell, true)
+	w.commitEdit()
+	if _, placing := w.versusPlacing(); !placing {
+		w.isSimulating = true
+	}
+}
+
+// versusReady is called before stepping and keeps the world at generation
+// 0 until both players have placed their cells, and no more
+func (w *World) versusReady() bool {
+	v := w.versus
+	if v == nil || w.grid.Generation != 0 {
+		return true
+	}
+	scores := w.versusScores()
+	for i, n := range scores {
+		if n > v.cells {
+			w.stop(fmt.Sprintf("%s has %d cells, at most %d", versusPlayers[i], n, v.cells))
+			return false
+		}
+	}
+	if turn, placing := w.versusPlacing(); placing {
+		w.stop(fmt.Sprintf("%s to place, %d of %d cells placed", versusPlayers[turn], scores[turn], v.cells))
+		return false
+	}
+	return true
+}
+
+// checkVersus ends the game after the last generation, or sooner when the
+// world dies out or settles as the score can't change any more. It returns
+// whether it did.
+func (w *World) checkVersus() bool {
+	v := w.versus
+	if v == nil || v.result != "" {
+		return false
+	}
+	gen := w.grid.Generation
+	if gen < v.generations && w.grid.Population() > 0 && w.cycle.period == 0 {
+		return false
+	}
+	scores := w.versusScores()
+	switch {
+	case scores[0] > scores[1]:
+		v.result = fmt.Sprintf("%s wins %d to %d at generation %d", versusPlayers[0], scores[0], scores[1], gen)
+	case scores[1] > scores[0]:
+		v.result = fmt.Sprintf("%s wins %d to %d at generation %d", versusPlayers[1], scores[1], scores[0], gen)
+	default:
+		v.result = fmt.Sprintf("Draw, %d each at generation %d", scores[0], gen)
+	}
+	w.isSimulating = false
+	return true
+}
+
+// versusHUD returns the score of each player in their color, then whose
+// turn it is, how far the run has got or the result
+func (w *World) versusHUD() []hudItem {
+	v := w.versus
+	scores := w.versusScores()
+	items := make([]hudItem, 0, 3)
+	turn, placing := w.versusPlacing()
+	for i, name := range versusPlayers {
+		text := fmt.Sprintf("%s: %d", name, scores[i])
+		if placing {
+			text = fmt.Sprintf("%s: %d of %d", name, scores[i], v.cells)
+		}
+		items = append(items, hudItem{text, versusColors[i]})
+	}
+	switch {
+	case v.result != "":
+		items = append(items, hudItem{v.result, w.theme.Accent})
+	case placing:
+		items = append(items, hudItem{text: fmt.Sprintf("%s to place a cell", versusPlayers[turn])})
+	default:
+		items = append(items, hudItem{text: fmt.Sprintf("Versus: generation %d of %d", w.grid.Generation, v.generations)})
+	}
+	return items
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// websocketGUID is the fixed key suffix from RFC 6455 used to accept a
+// websocket handshake
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Websocket frame opcodes
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+// upgradeWebsocket answers a websocket handshake and takes over the
+// connection from the HTTP server
+func upgradeWebsocket(rw http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(rw, "expected a websocket handshake", http.StatusBadRequest)
+		return nil, nil, errors.New("not a websocket handshake")
+	}
+	if !sameOrigin(r) {
+		http.Error(rw, "cross-origin websockets are not allowed", http.StatusForbidden)
+		return nil, nil, fmt.Errorf("refused a websocket from %s", r.Header.Get("Origin"))
+	}
+	hijacker, ok := rw.(http.Hijacker)
+	if !ok {
+		http.Error(rw, "websockets are not supported", http.StatusInternalServerError)
+		return nil, nil, errors.New("connection can't be hijacked")
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := buf.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, buf, nil
+}
+
+// sameOrigin reports whether a handshake came from a page served by this
+// server, or from a client that isn't a browser and sends no Origin.
+// Browsers let any page open a websocket, so without this a page the user
+// visits could watch a server on localhost.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// writeWebsocketFrame writes one unmasked frame, as servers send them
+func writeWebsocketFrame(w *bufio.Writer, opcode byte, payload []byte) error {
+	w.WriteByte(0x80 | opcode)
+	switch n := len(payload); {
+	case n < 126:
+		w.WriteByte(byte(n))
+	case n <= 0xffff:
+		w.WriteByte(126)
+		binary.Write(w, binary.BigEndian, uint16(n))
+	default:
+		w.WriteByte(127)
+		binary.Write(w, binary.BigEndian, uint64(n))
+	}
+	w.Write(payload)
+	return w.Flush()
+}
+
+// readWebsocketFrame reads one frame sent by a client, unmasking its
+// payload
+func readWebsocketFrame(r *bufio.Reader) (opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var n uint16
+		err = binary.Read(r, binary.BigEndian, &n)
+		length = uint64(n)
+	case 127:
+		err = binary.Read(r, binary.BigEndian, &length)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	// Clients only send small control frames to this server
+	if length > 1<<16 {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}